@@ -0,0 +1,99 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio
+
+// Import standard library packages
+import (
+	"strings" // strings
+	"testing" // testing
+	"unicode" // unicode
+)
+
+// AssertOptions holds the options for AssertGoldenWith.
+type AssertOptions struct {
+	Fatal bool // Fatal reports a failed assertion with t.Fatalf instead of t.Errorf, which stops the test
+}
+
+// AssertGolden asserts that got equals the golden file of the test or subtest t. It is a shortcut for
+// AssertGoldenWith with the zero value of AssertOptions, e.g.,
+//
+//	func TestOutput(t *testing.T) {
+//		tsfio.AssertGolden(t, "", output())
+//	}
+//
+// evaluates output() with the golden file testdata/TestOutput.golden.
+func AssertGolden(t testing.TB, name string, got []byte) {
+	t.Helper()
+	stdGolden.AssertGoldenWith(t, name, got, AssertOptions{})
+}
+
+// AssertGolden performs the package function AssertGolden with the settings of g.
+func (g *Golden) AssertGolden(t testing.TB, name string, got []byte) {
+	t.Helper()
+	g.AssertGoldenWith(t, name, got, AssertOptions{})
+}
+
+// AssertGoldenWith asserts that got equals the golden file of the test or subtest t with the options o. The name of
+// the golden file is derived from t.Name(). The slashes of subtests are kept, so the golden files of subtests reside
+// in a subdirectory of testdata/ named after the test. If name is not empty, it is appended as a further path element,
+// e.g., for several golden files of a test. Runes of the path elements other than letters, digits, dash, underscore
+// and dot are replaced by an underscore. The golden file is evaluated with EvalGoldenFile. If the evaluation fails,
+// the error holding the unified diff is reported with t.Errorf. In update mode, see GoldenUpdate, a replaced golden
+// file is logged with t.Logf.
+func AssertGoldenWith(t testing.TB, name string, got []byte, o AssertOptions) {
+	t.Helper()
+	stdGolden.AssertGoldenWith(t, name, got, o)
+}
+
+// AssertGoldenWith performs the package function AssertGoldenWith with the settings of g. If Flat of g is true, the
+// path elements of the golden file name are joined by underscores, so the golden file resides in Dir of g.
+func (g *Golden) AssertGoldenWith(t testing.TB, name string, got []byte, o AssertOptions) {
+	t.Helper()
+	// Report with t.Fatalf, if requested
+	report := t.Errorf
+	if o.Fatal {
+		report = t.Fatalf
+	}
+	// Evaluate got with the golden file of t and name
+	sep := "/"
+	if g.Flat {
+		sep = "_"
+	}
+	tc := &Testcase{Name: goldenName(t.Name(), name, sep), Data: string(got)}
+	u, e := g.evalGoldenFile(tc)
+	if e != nil {
+		report("%v", e)
+		return
+	}
+	// Log the replaced golden file in update mode
+	if u {
+		fn, _ := g.GoldenFilePath(tc.Name)
+		t.Logf("updated golden file %v", fn)
+	}
+}
+
+// goldenName returns the name of a golden file for the test name tn and name. The path elements of tn and name
+// are joined by sep with runes other than letters, digits, dash, underscore and dot replaced by an underscore.
+// Empty path elements, . and .. are replaced by an underscore.
+func goldenName(tn, name, sep string) string {
+	// Append name to the test name, if not empty
+	if name != "" {
+		tn += "/" + name
+	}
+	// Sanitize each path element
+	el := strings.Split(tn, "/")
+	for i, s := range el {
+		s = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_.", r) {
+				return r
+			}
+			return '_'
+		}, s)
+		if (s == "") || (s == ".") || (s == "..") {
+			s = "_"
+		}
+		el[i] = s
+	}
+	return strings.Join(el, sep)
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio_test
+
+// Import standard library packages as well as tserr and tsfio
+import (
+	"fmt"           // fmt
+	"path/filepath" // filepath
+	"strings"       // strings
+	"testing"       // testing
+
+	"github.com/thorstenrie/tserr" // tserr
+	"github.com/thorstenrie/tsfio" // tsfio
+)
+
+// A recTB is a testing.TB, which records the reported errors, fatal errors and logs instead of reporting them
+type recTB struct {
+	testing.TB                  // TB holds the test
+	errs, fatals, logs []string // errs, fatals and logs hold the recorded messages
+}
+
+// Errorf records the error.
+func (r *recTB) Errorf(format string, args ...any) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+// Fatalf records the fatal error without stopping the test.
+func (r *recTB) Fatalf(format string, args ...any) {
+	r.fatals = append(r.fatals, fmt.Sprintf(format, args...))
+}
+
+// Logf records the log.
+func (r *recTB) Logf(format string, args ...any) {
+	r.logs = append(r.logs, fmt.Sprintf(format, args...))
+}
+
+// testRec tests the number of recorded errors, fatal errors and logs of r to equal w and resets them.
+// The test fails if any number differs.
+func testRec(t *testing.T, r *recTB, w [3]int) {
+	// Panic if t or r is nil
+	if (t == nil) || (r == nil) {
+		panic("nil pointer")
+	}
+	// The test fails if any number of recorded messages differs
+	for i, m := range [][]string{r.errs, r.fatals, r.logs} {
+		if len(m) != w[i] {
+			t.Error(tserr.Equal(&tserr.EqualArgs{Var: fmt.Sprintf("recorded messages %d %v", i, m), Actual: int64(len(m)), Want: int64(w[i])}))
+		}
+	}
+	r.errs, r.fatals, r.logs = nil, nil, nil
+}
+
+// TestAssertGolden tests AssertGolden and AssertGoldenWith in a subtest to evaluate the golden file derived from the
+// subtest name and name. The test fails if an equal golden file is reported, if a golden file, which is not equal,
+// is not reported with the diff, or if the golden file does not reside in the subdirectory of the test.
+func TestAssertGolden(t *testing.T) {
+	t.Run("sub test", func(t *testing.T) {
+		r := &recTB{TB: t}
+		// Create golden file testdata/TestAssertGolden/sub_test/a_b.golden
+		tc := &tsfio.Testcase{Name: "TestAssertGolden/sub_test/a_b", Data: "a\nb\nc\n"}
+		if e := tsfio.CreateGoldenFile(tc); e != nil {
+			t.Error(tserr.Op(&tserr.OpArgs{Op: "CreateGoldenFile", Fn: tc.Name, Err: e}))
+		}
+		// The test fails if an equal golden file is reported
+		tsfio.AssertGolden(r, "a b", []byte(tc.Data))
+		testRec(t, r, [3]int{0, 0, 0})
+		// The test fails if a golden file, which is not equal, is not reported with the diff
+		tsfio.AssertGolden(r, "a b", []byte("a\nx\nc\n"))
+		testRec(t, r, [3]int{1, 0, 0})
+		tsfio.AssertGoldenWith(r, "a b", []byte("a\nx\nc\n"), tsfio.AssertOptions{Fatal: true})
+		if (len(r.fatals) > 0) && !strings.Contains(r.fatals[0], "-b\n+x\n") {
+			t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: "fatal error of AssertGoldenWith", Actual: r.fatals[0], Want: "-b\n+x\n"}))
+		}
+		testRec(t, r, [3]int{0, 1, 0})
+		// The test fails if the golden file of a missing name is not reported
+		tsfio.AssertGolden(r, "", []byte(tc.Data))
+		testRec(t, r, [3]int{1, 0, 0})
+	})
+	// Remove the golden files of the test
+	rmAll(t, tsfio.Directory(filepath.Join("testdata", "TestAssertGolden")))
+}
+
+// TestAssertGoldenUpdate tests AssertGolden in update mode to create a missing golden file and log it.
+// The test fails if AssertGolden reports an error, does not log the golden file or if the golden file does not exist.
+func TestAssertGoldenUpdate(t *testing.T) {
+	// Enable the update mode
+	t.Setenv("TSFIO_UPDATE_GOLDEN", "1")
+	r := &recTB{TB: t}
+	// The test fails if AssertGolden reports an error or does not log the created golden file
+	tsfio.AssertGolden(r, "../a", []byte(testcase))
+	testRec(t, r, [3]int{0, 0, 1})
+	// The test fails if the golden file does not exist in the subdirectory of the test
+	fn := tsfio.Filename(filepath.Join("testdata", "TestAssertGoldenUpdate", "_", "a.golden"))
+	if b, e := tsfio.ExistsFile(fn); !b || (e != nil) {
+		t.Error(tserr.NotExistent(string(fn)))
+	}
+	// The test fails if AssertGolden logs an equal golden file
+	tsfio.AssertGolden(r, "../a", []byte(testcase))
+	testRec(t, r, [3]int{0, 0, 0})
+	// Remove the golden files of the test
+	rmAll(t, tsfio.Directory(filepath.Join("testdata", "TestAssertGoldenUpdate")))
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio
+
+// Import standard library packages and tserr
+import (
+	"bytes"         // bytes
+	"context"       // context
+	"io"            // io
+	"io/fs"         // fs
+	"path/filepath" // filepath
+
+	"github.com/thorstenrie/tserr" // tserr
+)
+
+// Prefix and suffix of temporary files created next to the target file of an atomic write.
+// The temporary file name is the prefix, the base name of the target file, a random string and the suffix.
+const (
+	tmpPrefix string = "."    // Prefix of temporary files
+	tmpSuffix string = ".tmp" // Suffix of temporary files
+)
+
+// WriteAtomic writes byte slice b to file fn by replacing fn atomically. The data is written to a temporary
+// file in the same directory as fn. The temporary file is synced to disk and renamed to fn. Afterwards, the
+// directory of fn is synced. Therefore, a concurrent reader or a reader after a crash either sees the
+// previous contents of fn or b, but never an empty or partially written file. If fn does not exist, it is
+// created with the default permission bits. If fn exists, its permission bits are retained. If fn is a symbolic
+// link, the file it links to is replaced in its directory and the symbolic link is retained. If the directory
+// to the file does not exist, WriteAtomic returns an error. It returns an error, if any.
+func WriteAtomic(fn Filename, b []byte) error {
+	return std.WriteAtomic(fn, b)
+}
+
+// WriteAtomic performs the package function WriteAtomic with the settings of fsys.
+func (fsys *FS) WriteAtomic(fn Filename, b []byte) error {
+	return fsys.WriteAtomicCtx(context.Background(), fn, b)
+}
+
+// WriteAtomicCtx writes byte slice b to file fn by replacing fn atomically as done by WriteAtomic. The temporary
+// file is written in chunks and ctx is checked for cancellation before each chunk. If ctx is canceled, the temporary
+// file is removed and fn remains unchanged. It returns an error, if any, also if ctx is canceled.
+func WriteAtomicCtx(ctx context.Context, fn Filename, b []byte) error {
+	return std.WriteAtomicCtx(ctx, fn, b)
+}
+
+// WriteAtomicCtx performs the package function WriteAtomicCtx with the settings of fsys.
+func (fsys *FS) WriteAtomicCtx(ctx context.Context, fn Filename, b []byte) error {
+	_, err := fsys.writeAtomic(ctx, fn, bytes.NewReader(b))
+	return err
+}
+
+// writeAtomic replaces fn atomically with the contents of r as described by WriteAtomicCtx. It returns the number
+// of bytes written and an error, if any.
+func (fsys *FS) writeAtomic(ctx context.Context, fn Filename, r io.Reader) (int64, error) {
+	// Resolve fn against the root directory of fsys
+	fn = rooted(fsys, fn)
+	// Return an error in case fn contains a blocked directory or filename
+	if e := fsys.CheckFile(fn); e != nil {
+		return 0, tserr.Check(&tserr.CheckArgs{F: string(fn), Err: e})
+	}
+	// Replace the target of fn instead of fn, if fn is a symbolic link, so the symbolic link is retained
+	if fi, e := fsys.backend().Lstat(string(fn)); (e == nil) && (fi.Mode()&fs.ModeSymlink != 0) {
+		t, err := resolve(fsys.backend(), string(fn))
+		// Return an error if the symbolic link cannot be evaluated
+		if err != nil {
+			return 0, tserr.Op(&tserr.OpArgs{Op: "evaluate symbolic link", Fn: string(fn), Err: err})
+		}
+		fn = Filename(t)
+	}
+	// Get directory of filename
+	dn := Directory(filepath.Dir(string(fn)))
+	// Check if directory exists
+	ok, err := fsys.ExistsDir(dn)
+	// Return an error if ExistsDir fails
+	if err != nil {
+		return 0, tserr.Op(&tserr.OpArgs{Op: "ExistsDir", Fn: string(dn), Err: err})
+	}
+	// Return an error if the directory does not exist
+	if !ok {
+		return 0, errNotExist("directory " + string(dn))
+	}
+	// Use default permission bits, if fn does not exist. Otherwise, retain the permission bits of fn.
+	perm := fsys.fileMode()
+	if fi, e := fsys.backend().Stat(string(fn)); e == nil {
+		perm = fi.Mode().Perm()
+	}
+	// Create the temporary file in the directory of fn
+	f, err := fsys.backend().CreateTemp(string(dn), tmpPrefix+filepath.Base(string(fn))+".*"+tmpSuffix)
+	// Return an error if CreateTemp fails
+	if err != nil {
+		return 0, tserr.Op(&tserr.OpArgs{Op: "create temporary file in", Fn: string(dn), Err: err})
+	}
+	// Retrieve the name of the temporary file
+	tmp := Filename(f.Name())
+	// Return an error in case the temporary file contains a blocked directory or filename
+	if e := fsys.CheckFile(tmp); e != nil {
+		// Close and remove the temporary file
+		f.Close()
+		fsys.backend().Remove(string(tmp))
+		return 0, tserr.Check(&tserr.CheckArgs{F: string(tmp), Err: e})
+	}
+	// Write the contents of r to the temporary file, sync it to disk, set permission bits and close it
+	n, err := writeTmp(ctx, f, r, perm)
+	if err != nil {
+		// On error, remove the temporary file and return the error
+		fsys.backend().Remove(string(tmp))
+		return 0, tserr.Op(&tserr.OpArgs{Op: "write temporary file", Fn: string(tmp), Err: err})
+	}
+	// Replace fn with the temporary file
+	if e := fsys.backend().Rename(string(tmp), string(fn)); e != nil {
+		// On error, remove the temporary file and return the error
+		fsys.backend().Remove(string(tmp))
+		return 0, tserr.Op(&tserr.OpArgs{Op: "Rename " + string(tmp) + " to", Fn: string(fn), Err: e})
+	}
+	// Sync the directory of fn to persist the rename
+	if e := fsys.backend().SyncDir(string(dn)); e != nil {
+		// Return an error if SyncDir fails
+		return 0, tserr.Op(&tserr.OpArgs{Op: "sync directory", Fn: string(dn), Err: e})
+	}
+	// No error occurred, return the number of bytes written
+	return n, nil
+}
+
+// WriteAtomicStr writes string s to file fn by replacing fn atomically. It behaves like WriteAtomic.
+// It returns an error, if any.
+func WriteAtomicStr(fn Filename, s string) error {
+	return std.WriteAtomicStr(fn, s)
+}
+
+// WriteAtomicStr performs the package function WriteAtomicStr with the settings of fsys.
+func (fsys *FS) WriteAtomicStr(fn Filename, s string) error {
+	return fsys.WriteAtomic(fn, []byte(s))
+}
+
+// writeTmp writes the contents of r to the open temporary file f with copyCtx, syncs f to disk, sets the permission
+// bits of f to perm and closes f. It always closes f and returns the number of bytes written and an error, if any.
+func writeTmp(ctx context.Context, f File, r io.Reader, perm fs.FileMode) (int64, error) {
+	// Write the contents of r to f
+	n, err := copyCtx(ctx, f, r)
+	if err != nil {
+		f.Close()
+		return n, err
+	}
+	// Sync f to disk
+	if e := f.Sync(); e != nil {
+		f.Close()
+		return n, e
+	}
+	// Set the permission bits of f
+	if e := f.Chmod(perm); e != nil {
+		f.Close()
+		return n, e
+	}
+	// Close f and return the error of Close, if any
+	return n, f.Close()
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio_test
+
+// Import standard library packages as well as tserr and tsfio
+import (
+	"fmt"           // fmt
+	"io/fs"         // fs
+	"os"            // os
+	"path/filepath" // filepath
+	"runtime"       // runtime
+	"testing"       // testing
+
+	"github.com/thorstenrie/tserr" // tserr
+	"github.com/thorstenrie/tsfio" // tsfio
+)
+
+// TestWriteAtomic tests WriteAtomicStr to replace the contents of a file in a temporary directory twice.
+// The test fails if the file does not contain the data of the second write or if a temporary file remains
+// in the directory.
+func TestWriteAtomic(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Create Filename fn in d
+	fn := tsfio.Filename(filepath.Join(string(d), string(testfile)))
+	// Write testcase twice, the second time with a suffix
+	for _, s := range []string{testcase, testcase + testcase} {
+		// If WriteAtomicStr returns an error, the test fails
+		if e := tsfio.WriteAtomicStr(fn, s); e != nil {
+			t.Error(tserr.Op(&tserr.OpArgs{Op: fmt.Sprintf("WriteAtomicStr %v to file", s), Fn: string(fn), Err: e}))
+		}
+	}
+	// Read file fn in b
+	b, err := os.ReadFile(string(fn))
+	// If ReadFile returns an error, the test fails
+	if err != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "ReadFile", Fn: string(fn), Err: err}))
+	}
+	// If b does not match the data of the second write, the test fails
+	if string(b) != testcase+testcase {
+		t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: string(fn), Actual: string(b), Want: testcase + testcase}))
+	}
+	// The test fails if d contains other entries than fn, e.g., a remaining temporary file
+	testEntries(t, d, 1)
+	// Remove fn and d
+	rm(t, fn)
+	rm(t, d)
+}
+
+// TestWriteAtomicSymlink tests WriteAtomicStr and WriteSingleStr to replace the file a symbolic link points to.
+// The test fails if the symbolic link is replaced, if the file does not contain the written data or if a temporary
+// file remains.
+func TestWriteAtomicSymlink(t *testing.T) {
+	// Create temporary directory d with file t/a
+	d := tmpDir(t)
+	writeTree(t, d, map[string]string{"t/a": testcase})
+	// Create symbolic link l to t/a
+	l := filepath.Join(string(d), "l")
+	if e := os.Symlink(filepath.Join("t", "a"), l); e != nil {
+		rmAll(t, d)
+		t.Skip(e)
+	}
+	for _, w := range []struct {
+		name string
+		f    func(tsfio.Filename, string) error
+	}{{"WriteAtomicStr", tsfio.WriteAtomicStr}, {"WriteSingleStr", tsfio.WriteSingleStr}} {
+		// If the write returns an error, the test fails
+		if e := w.f(tsfio.Filename(l), w.name); e != nil {
+			t.Error(tserr.Op(&tserr.OpArgs{Op: w.name, Fn: l, Err: e}))
+		}
+		// The test fails if l is not a symbolic link anymore
+		if fi, e := os.Lstat(l); (e != nil) || (fi.Mode()&fs.ModeSymlink == 0) {
+			t.Error(tserr.Return(&tserr.ReturnArgs{Op: "Lstat of " + l + " after " + w.name, Actual: fmt.Sprint(fi, e), Want: "symbolic link"}))
+		}
+		// The test fails if t/a does not hold the written data or a temporary file remains in t
+		checkTree(t, tsfio.Directory(filepath.Join(string(d), "t")), map[string]string{"a": w.name})
+		testEntries(t, tsfio.Directory(filepath.Join(string(d), "t")), 1)
+	}
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestWriteAtomicPerm tests WriteAtomic to retain the permission bits of an existing file. The test
+// fails if WriteAtomic returns an error or if the permission bits of the file changed. The test is
+// skipped on Windows.
+func TestWriteAtomicPerm(t *testing.T) {
+	// Skip test on Windows, which only supports the read-only permission bit
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not supported on Windows")
+	}
+	// Create temporary file fn
+	fn := tmpFile(t)
+	// Set permission bits of fn
+	if e := os.Chmod(string(fn), 0600); e != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "Chmod", Fn: string(fn), Err: e}))
+	}
+	// If WriteAtomic returns an error, the test fails
+	if e := tsfio.WriteAtomic(fn, []byte(testcase)); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "WriteAtomic", Fn: string(fn), Err: e}))
+	}
+	// Retrieve FileInfo of fn
+	fi, err := os.Stat(string(fn))
+	// If Stat returns an error, the test fails
+	if err != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "FileInfo (Stat) of", Fn: string(fn), Err: err}))
+	}
+	// If the permission bits changed, the test fails
+	if fi.Mode().Perm() != 0600 {
+		t.Error(tserr.Equal(&tserr.EqualArgs{Var: fmt.Sprintf("permission bits of %v", fn), Actual: int64(fi.Mode().Perm()), Want: 0600}))
+	}
+	// Remove fn
+	rm(t, fn)
+}
+
+// TestWriteAtomicEmpty tests WriteAtomic to return an error for an empty filename.
+// If WriteAtomic returns nil, the test fails.
+func TestWriteAtomicEmpty(t *testing.T) {
+	// Write testcase to empty filename
+	if e := tsfio.WriteAtomic("", []byte(testcase)); e == nil {
+		// If WriteAtomic returns nil, the test fails
+		t.Error(tserr.NilFailed("WriteAtomic"))
+	}
+}
+
+// TestWriteAtomicNoDir tests WriteAtomicStr to return an error for a file in a directory which does not exist.
+// If WriteAtomicStr returns nil, the test fails.
+func TestWriteAtomicNoDir(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Remove d
+	rm(t, d)
+	// Create Filename fn in d
+	fn := tsfio.Filename(filepath.Join(string(d), string(testfile)))
+	// Write testcase to fn
+	if e := tsfio.WriteAtomicStr(fn, testcase); e == nil {
+		// If WriteAtomicStr returns nil, the test fails
+		t.Error(tserr.NilFailed("WriteAtomicStr"))
+	}
+}
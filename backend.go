@@ -0,0 +1,181 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio
+
+// Import standard library packages
+import (
+	"io"            // io
+	"io/fs"         // fs
+	"os"            // os
+	"path/filepath" // filepath
+	"time"          // time
+)
+
+// A Backend provides the storage for the file input output of an FS. The methods of a Backend behave like
+// the functions of the same name in the standard library package os. Names are passed to a Backend after
+// they are checked with CheckFile or CheckDir. Errors should be of type *fs.PathError wrapping the errors
+// of package io/fs, e.g., fs.ErrNotExist, so they can be tested with errors.Is. The checks of an FS retrieve
+// FileInfo and evaluate symbolic links with its Backend. Package tsfio ships OSBackend, which uses the
+// operating system, and MemBackend, which holds all files and directories in memory.
+type Backend interface {
+	Stat(name string) (fs.FileInfo, error)                          // Stat returns the FileInfo of name
+	Lstat(name string) (fs.FileInfo, error)                         // Lstat returns the FileInfo of name without following a symbolic link
+	Readlink(name string) (string, error)                           // Readlink returns the target of symbolic link name
+	OpenFile(name string, flag int, perm fs.FileMode) (File, error) // OpenFile opens name with flag and perm
+	ReadFile(name string) ([]byte, error)                           // ReadFile returns the contents of name
+	ReadDir(name string) ([]fs.DirEntry, error)                     // ReadDir returns the entries of directory name sorted by filename
+	Truncate(name string, size int64) error                         // Truncate changes the size of name
+	Chtimes(name string, atime time.Time, mtime time.Time) error    // Chtimes changes the access and modification times of name
+	Chmod(name string, mode fs.FileMode) error                      // Chmod changes the mode of name
+	MkdirAll(name string, perm fs.FileMode) error                   // MkdirAll creates directory name with any necessary parents
+	Remove(name string) error                                       // Remove removes file or empty directory name
+	Rename(oldname, newname string) error                           // Rename moves oldname to newname
+	CreateTemp(dir, pattern string) (File, error)                   // CreateTemp creates and opens a new temporary file in dir
+	MkdirTemp(dir, pattern string) (string, error)                  // MkdirTemp creates a new temporary directory in dir
+	SameFile(fi1, fi2 fs.FileInfo) bool                             // SameFile reports whether fi1 and fi2 describe the same file
+	SyncDir(name string) error                                      // SyncDir commits the directory entries of name to stable storage
+}
+
+// A File is an open file of a Backend. It is returned by OpenFile of an FS. An *os.File implements File.
+type File interface {
+	io.Reader                     // Read reads from the file
+	io.Writer                     // Write writes to the file
+	io.Closer                     // Close closes the file
+	io.ReaderAt                   // ReadAt reads from the file at an offset
+	io.WriterAt                   // WriteAt writes to the file at an offset
+	Name() string                 // Name returns the name of the file as passed to OpenFile
+	Stat() (fs.FileInfo, error)   // Stat returns the FileInfo of the file
+	Sync() error                  // Sync commits the contents of the file to stable storage
+	Truncate(size int64) error    // Truncate changes the size of the file
+	Chmod(mode fs.FileMode) error // Chmod changes the mode of the file
+}
+
+// OSBackend is the Backend of the operating system. Its methods call the functions of the same name in the standard
+// library package os. An FS without a Backend uses OSBackend.
+type OSBackend struct{}
+
+// Stat calls os.Stat.
+func (OSBackend) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+// Lstat calls os.Lstat.
+func (OSBackend) Lstat(name string) (fs.FileInfo, error) {
+	return os.Lstat(name)
+}
+
+// Readlink calls os.Readlink.
+func (OSBackend) Readlink(name string) (string, error) {
+	return os.Readlink(name)
+}
+
+// OpenFile calls os.OpenFile. The returned File is an *os.File.
+func (OSBackend) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	f, err := os.OpenFile(name, flag, perm)
+	// Return a nil File instead of a nil *os.File on error
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// ReadFile calls os.ReadFile.
+func (OSBackend) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+// ReadDir calls os.ReadDir.
+func (OSBackend) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+// Truncate calls os.Truncate.
+func (OSBackend) Truncate(name string, size int64) error {
+	return os.Truncate(name, size)
+}
+
+// Chtimes calls os.Chtimes.
+func (OSBackend) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return os.Chtimes(name, atime, mtime)
+}
+
+// Chmod calls os.Chmod.
+func (OSBackend) Chmod(name string, mode fs.FileMode) error {
+	return os.Chmod(name, mode)
+}
+
+// MkdirAll calls os.MkdirAll.
+func (OSBackend) MkdirAll(name string, perm fs.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+
+// Remove calls os.Remove.
+func (OSBackend) Remove(name string) error {
+	return os.Remove(name)
+}
+
+// Rename calls os.Rename.
+func (OSBackend) Rename(oldname, newname string) error {
+	return os.Rename(oldname, newname)
+}
+
+// CreateTemp calls os.CreateTemp. The returned File is an *os.File.
+func (OSBackend) CreateTemp(dir, pattern string) (File, error) {
+	f, err := os.CreateTemp(dir, pattern)
+	// Return a nil File instead of a nil *os.File on error
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// MkdirTemp calls os.MkdirTemp.
+func (OSBackend) MkdirTemp(dir, pattern string) (string, error) {
+	return os.MkdirTemp(dir, pattern)
+}
+
+// SameFile calls os.SameFile.
+func (OSBackend) SameFile(fi1, fi2 fs.FileInfo) bool {
+	return os.SameFile(fi1, fi2)
+}
+
+// SyncDir opens directory name and syncs it. It is a no-op on Windows.
+func (OSBackend) SyncDir(name string) error {
+	return syncDir(Directory(name))
+}
+
+// walkDir walks the file tree rooted at root with Backend b and calls fn for each file or directory in the tree,
+// including root. The entries of a directory are walked in lexical order and symbolic links are not followed,
+// as done by filepath.WalkDir. If fn returns an error, the walk stops and walkDir returns the error.
+func walkDir(b Backend, root string, fn fs.WalkDirFunc) error {
+	// Retrieve FileInfo of root without following a symbolic link
+	fi, err := b.Lstat(root)
+	if err != nil {
+		return fn(root, nil, err)
+	}
+	// Walk root
+	return walk(b, root, fs.FileInfoToDirEntry(fi), fn)
+}
+
+// walk calls fn for path p with directory entry d and, if d is a directory, walks its entries recursively
+// with Backend b. It returns an error, if any.
+func walk(b Backend, p string, d fs.DirEntry, fn fs.WalkDirFunc) error {
+	// Call fn for p and return, if p is not a directory
+	if err := fn(p, d, nil); (err != nil) || !d.IsDir() {
+		return err
+	}
+	// Read the entries of directory p
+	des, err := b.ReadDir(p)
+	if err != nil {
+		return fn(p, d, err)
+	}
+	// Walk the entries of p
+	for _, de := range des {
+		if e := walk(b, filepath.Join(p, de.Name()), de, fn); e != nil {
+			return e
+		}
+	}
+	// No error occurred, return nil
+	return nil
+}
@@ -0,0 +1,344 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio
+
+// Import standard library packages and tserr
+import (
+	"context"       // context
+	"io/fs"         // fs
+	"os"            // os
+	"path/filepath" // filepath
+
+	"github.com/thorstenrie/tserr" // tserr
+)
+
+// CopyOptions holds the options for copying files and directories with CopyFileWith and CopyDirWith.
+// The zero value copies the contents only and uses the default permission bits for new files and directories.
+type CopyOptions struct {
+	Mode    bool // Mode preserves the permission bits of the source
+	ModTime bool // ModTime preserves the modification time of the source
+}
+
+// CopyFile copies the contents of regular file src to dst. If dst exists, it is truncated first.
+// If dst does not exist, it is created with the default permission bits. If the directory to dst does
+// not exist, CopyFile returns an error. It returns an error, if any.
+func CopyFile(src, dst Filename) error {
+	return std.CopyFile(src, dst)
+}
+
+// CopyFile performs the package function CopyFile with the settings of fsys.
+func (fsys *FS) CopyFile(src, dst Filename) error {
+	return fsys.CopyFileWith(src, dst, CopyOptions{})
+}
+
+// CopyFileWith copies the contents of regular file src to dst with the options o. If o.Mode is true,
+// the permission bits of src are applied to dst. If o.ModTime is true, the modification time of src is
+// applied to dst. Otherwise, it behaves like CopyFile. It returns an error, if any.
+func CopyFileWith(src, dst Filename, o CopyOptions) error {
+	return std.CopyFileWith(src, dst, o)
+}
+
+// CopyFileWith performs the package function CopyFileWith with the settings of fsys.
+func (fsys *FS) CopyFileWith(src, dst Filename, o CopyOptions) error {
+	return fsys.CopyFileWithCtx(context.Background(), src, dst, o)
+}
+
+// CopyFileCtx copies the contents of regular file src to dst as done by CopyFile. It is a shortcut for
+// CopyFileWithCtx with the zero value of CopyOptions. It returns an error, if any, also if ctx is canceled.
+func CopyFileCtx(ctx context.Context, src, dst Filename) error {
+	return std.CopyFileCtx(ctx, src, dst)
+}
+
+// CopyFileCtx performs the package function CopyFileCtx with the settings of fsys.
+func (fsys *FS) CopyFileCtx(ctx context.Context, src, dst Filename) error {
+	return fsys.CopyFileWithCtx(ctx, src, dst, CopyOptions{})
+}
+
+// CopyFileWithCtx copies the contents of regular file src to dst with the options o as done by CopyFileWith.
+// The contents are copied in chunks and ctx is checked for cancellation before each chunk. If ctx is canceled,
+// the partial copy is removed: dst is truncated to size zero or removed, if it did not exist before. It returns
+// an error, if any, also if ctx is canceled.
+func CopyFileWithCtx(ctx context.Context, src, dst Filename, o CopyOptions) error {
+	return std.CopyFileWithCtx(ctx, src, dst, o)
+}
+
+// CopyFileWithCtx performs the package function CopyFileWithCtx with the settings of fsys.
+func (fsys *FS) CopyFileWithCtx(ctx context.Context, src, dst Filename, o CopyOptions) error {
+	// Resolve src and dst against the root directory of fsys
+	src, dst = rooted(fsys, src), rooted(fsys, dst)
+	// Return an error in case src contains a blocked directory or filename
+	if e := fsys.CheckFile(src); e != nil {
+		return tserr.Check(&tserr.CheckArgs{F: string(src), Err: e})
+	}
+	// Return an error in case dst contains a blocked directory or filename
+	if e := fsys.CheckFile(dst); e != nil {
+		return tserr.Check(&tserr.CheckArgs{F: string(dst), Err: e})
+	}
+	// Retrieve FileInfo of src
+	si, err := fsys.backend().Stat(string(src))
+	// Return an error if Stat fails, e.g., if src does not exist
+	if err != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "FileInfo (Stat) of", Fn: string(src), Err: err})
+	}
+	// Return an error if src and dst are the same file
+	if di, e := fsys.backend().Stat(string(dst)); (e == nil) && fsys.backend().SameFile(si, di) {
+		return tserr.Forbidden("copy " + string(src) + " to itself")
+	}
+	// Copy src to dst
+	if e := fsys.copyFile(ctx, src, dst, si, o); e != nil {
+		// Return an error if copyFile fails
+		return tserr.Op(&tserr.OpArgs{Op: "copy " + string(src) + " to", Fn: string(dst), Err: e})
+	}
+	// No error occurred, return nil
+	return nil
+}
+
+// CopyDir copies the directory tree src to dst. Directories are created with the default directory mode
+// and files with the default permission bits. If dst exists, the contents of src are merged into dst and
+// existing files are truncated first. Each directory and file in src and dst is checked with CheckDir and
+// CheckFile. CopyDir returns an error if dst is src or resides in src, or if src contains an entry which is
+// neither a directory nor a regular file, e.g., a symbolic link. It returns an error, if any.
+func CopyDir(src, dst Directory) error {
+	return std.CopyDir(src, dst)
+}
+
+// CopyDir performs the package function CopyDir with the settings of fsys.
+func (fsys *FS) CopyDir(src, dst Directory) error {
+	return fsys.CopyDirWith(src, dst, CopyOptions{})
+}
+
+// CopyDirWith copies the directory tree src to dst with the options o. If o.Mode is true, the permission bits
+// of the directories and files in src are applied to dst. If o.ModTime is true, the modification times of the
+// directories and files in src are applied to dst. Otherwise, it behaves like CopyDir. It returns an error, if any.
+func CopyDirWith(src, dst Directory, o CopyOptions) error {
+	return std.CopyDirWith(src, dst, o)
+}
+
+// CopyDirWith performs the package function CopyDirWith with the settings of fsys.
+func (fsys *FS) CopyDirWith(src, dst Directory, o CopyOptions) error {
+	return fsys.CopyDirWithCtx(context.Background(), src, dst, o)
+}
+
+// CopyDirCtx copies the directory tree src to dst as done by CopyDir. It is a shortcut for CopyDirWithCtx
+// with the zero value of CopyOptions. It returns an error, if any, also if ctx is canceled.
+func CopyDirCtx(ctx context.Context, src, dst Directory) error {
+	return std.CopyDirCtx(ctx, src, dst)
+}
+
+// CopyDirCtx performs the package function CopyDirCtx with the settings of fsys.
+func (fsys *FS) CopyDirCtx(ctx context.Context, src, dst Directory) error {
+	return fsys.CopyDirWithCtx(ctx, src, dst, CopyOptions{})
+}
+
+// CopyDirWithCtx copies the directory tree src to dst with the options o as done by CopyDirWith. The files are
+// copied as done by CopyFileWithCtx and ctx is checked for cancellation before each entry of src. If ctx is canceled,
+// the partial copy is removed: copied files are truncated to size zero or removed, if they did not exist before, and
+// created directories are removed. It returns an error, if any, also if ctx is canceled.
+func CopyDirWithCtx(ctx context.Context, src, dst Directory, o CopyOptions) error {
+	return std.CopyDirWithCtx(ctx, src, dst, o)
+}
+
+// CopyDirWithCtx performs the package function CopyDirWithCtx with the settings of fsys.
+func (fsys *FS) CopyDirWithCtx(ctx context.Context, src, dst Directory, o CopyOptions) error {
+	// Resolve src and dst against the root directory of fsys
+	src, dst = rooted(fsys, src), rooted(fsys, dst)
+	// Return an error in case src contains a blocked directory or filename
+	if e := fsys.CheckDir(src); e != nil {
+		return tserr.Check(&tserr.CheckArgs{F: string(src), Err: e})
+	}
+	// Return an error in case dst contains a blocked directory or filename
+	if e := fsys.CheckDir(dst); e != nil {
+		return tserr.Check(&tserr.CheckArgs{F: string(dst), Err: e})
+	}
+	// Check if src exists
+	b, err := fsys.ExistsDir(src)
+	// Return an error if ExistsDir fails
+	if err != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "ExistsDir", Fn: string(src), Err: err})
+	}
+	// Return an error if src does not exist
+	if !b {
+		return errNotExist("directory " + string(src))
+	}
+	// Return an error if dst is src or resides in src
+	in, err := inside(fsys.backend(), src, dst)
+	if err != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "compare paths", Fn: string(dst), Err: err})
+	}
+	if in {
+		return tserr.Forbidden("copy " + string(src) + " into itself")
+	}
+	// Copy the directory tree src to dst
+	if e := fsys.copyDir(ctx, src, dst, o); e != nil {
+		// Return an error if copyDir fails
+		return tserr.Op(&tserr.OpArgs{Op: "copy " + string(src) + " to", Fn: string(dst), Err: e})
+	}
+	// No error occurred, return nil
+	return nil
+}
+
+// copyFile copies regular file src with FileInfo si to dst with the options o. The contents are copied with copyCtx.
+// If ctx is canceled, dst is truncated to size zero or removed, if it did not exist before. It returns an error, if any.
+func (fsys *FS) copyFile(ctx context.Context, src, dst Filename, si fs.FileInfo, o CopyOptions) error {
+	// Open src read-only
+	in, err := fsys.backend().OpenFile(string(src), os.O_RDONLY, 0)
+	// Return an error if Open fails
+	if err != nil {
+		return err
+	}
+	// Close src when returning
+	defer in.Close()
+	// Retrieve whether dst exists. Since dst is truncated, the partial copy is removed by truncating it to size zero.
+	u := fsys.appendUndo(dst)
+	u.size = 0
+	// Open dst with OpenFile to check its directory and create it, if it does not exist
+	out, err := fsys.OpenFile(dst)
+	// Return an error if OpenFile fails
+	if err != nil {
+		return err
+	}
+	// Truncate dst to size zero
+	if e := out.Truncate(0); e != nil {
+		// On error, close dst and return the error
+		out.Close()
+		return e
+	}
+	// Copy the contents of src to dst
+	if _, e := copyCtx(ctx, out, in); e != nil {
+		// On error, close dst, remove the partial copy if ctx is canceled and return the error
+		u.close(ctx, out)
+		return e
+	}
+	// Sync and close dst as requested by the durability of fsys
+	if e := fsys.closeDurable(out); e != nil {
+		return e
+	}
+	// Apply the metadata of src to dst
+	return fsys.copyMeta(string(dst), si, o)
+}
+
+// copyDir copies the directory tree src to dst with the options o. The files are copied with copyFile and ctx
+// is checked for cancellation before each entry of src. If ctx is canceled, the partial copy is removed with
+// the undo of each copied file and the directories which did not exist before. It returns an error, if any.
+func (fsys *FS) copyDir(ctx context.Context, src, dst Directory, o CopyOptions) error {
+	// dirs holds the created directories and the FileInfo of their source directories
+	type dir struct {
+		name string
+		fi   fs.FileInfo
+	}
+	var dirs []dir
+	// made holds the directories which did not exist before and files the undo of the copied files
+	var (
+		made  []string
+		files []*undo
+	)
+	// Walk the directory tree src
+	err := walkDir(fsys.backend(), string(src), func(p string, d fs.DirEntry, e error) error {
+		// Return an error if walkDir fails for p
+		if e != nil {
+			return e
+		}
+		// Return an error if ctx is canceled
+		if e := ctx.Err(); e != nil {
+			return e
+		}
+		// Retrieve the path of p relative to src
+		rel, e := filepath.Rel(string(src), p)
+		if e != nil {
+			return e
+		}
+		// Retrieve the target path in dst
+		t := filepath.Join(string(dst), rel)
+		// Retrieve FileInfo of p
+		fi, e := d.Info()
+		if e != nil {
+			return e
+		}
+		// Copy directory or regular file p
+		switch {
+		case fi.IsDir():
+			// Check source and target directory
+			if e := fsys.CheckDir(Directory(p)); e != nil {
+				return e
+			}
+			if e := fsys.CheckDir(Directory(t)); e != nil {
+				return e
+			}
+			// Retrieve whether the target directory exists
+			_, ex := fsys.backend().Stat(t)
+			// Create the target directory
+			if e := fsys.CreateDir(Directory(t)); e != nil {
+				return e
+			}
+			// Keep the target directory to remove it on cancellation, if it did not exist before
+			if ex != nil {
+				made = append(made, t)
+			}
+			// Keep the target directory to apply the metadata when the walk finished
+			dirs = append(dirs, dir{name: t, fi: fi})
+		case fi.Mode().IsRegular():
+			// Check source and target file
+			if e := fsys.CheckFile(Filename(p)); e != nil {
+				return e
+			}
+			if e := fsys.CheckFile(Filename(t)); e != nil {
+				return e
+			}
+			// Retrieve whether the target file exists. Since it is truncated, the copy is removed by truncating it to size zero.
+			u := fsys.appendUndo(Filename(t))
+			u.size = 0
+			// Copy the regular file
+			if e := fsys.copyFile(ctx, Filename(p), Filename(t), fi, o); e != nil {
+				return e
+			}
+			// Keep the undo of the target file to remove the copy on cancellation
+			files = append(files, u)
+		default:
+			// Return an error if p is neither a directory nor a regular file
+			return errNotRegular(p, "directory or regular file")
+		}
+		return nil
+	})
+	// Return an error if walkDir fails
+	if err != nil {
+		// Remove the partial copy in reverse order, if ctx is canceled
+		if ctx.Err() != nil {
+			for i := len(files) - 1; i >= 0; i-- {
+				files[i].revert()
+			}
+			for i := len(made) - 1; i >= 0; i-- {
+				fsys.backend().Remove(made[i])
+			}
+		}
+		return err
+	}
+	// Apply the metadata of the source directories in reverse order, since copying into a
+	// directory changes its modification time and a read-only directory cannot be written.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if e := fsys.copyMeta(dirs[i].name, dirs[i].fi, o); e != nil {
+			return e
+		}
+	}
+	// No error occurred, return nil
+	return nil
+}
+
+// copyMeta applies the permission bits and modification time from FileInfo si to the file or directory
+// named n as requested by the options o. It returns an error, if any.
+func (fsys *FS) copyMeta(n string, si fs.FileInfo, o CopyOptions) error {
+	// Apply the permission bits of si to n, if requested
+	if o.Mode {
+		if e := fsys.backend().Chmod(n, si.Mode().Perm()); e != nil {
+			return e
+		}
+	}
+	// Apply the modification time of si to n, if requested. The access time is set to the modification time.
+	if o.ModTime {
+		if e := fsys.backend().Chtimes(n, si.ModTime(), si.ModTime()); e != nil {
+			return e
+		}
+	}
+	// No error occurred, return nil
+	return nil
+}
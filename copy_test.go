@@ -0,0 +1,216 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio_test
+
+// Import standard library packages as well as tserr and tsfio
+import (
+	"fmt"           // fmt
+	"os"            // os
+	"path/filepath" // filepath
+	"runtime"       // runtime
+	"testing"       // testing
+	"time"          // time
+
+	"github.com/thorstenrie/tserr" // tserr
+	"github.com/thorstenrie/tsfio" // tsfio
+)
+
+// testTree holds the files of a test directory tree with their contents
+var testTree = map[string]string{
+	"a":     testcase,
+	"b/c":   testcase + testcase,
+	"b/d/e": "",
+}
+
+// TestCopyFile tests CopyFile to copy a temporary file to another temporary file with existing contents.
+// The test fails if CopyFile returns an error or if the contents of the copy do not equal the source.
+func TestCopyFile(t *testing.T) {
+	// Create temporary files src and dst
+	src, dst := tmpFile(t), tmpFile(t)
+	// Write testcase to src and testcase twice to dst
+	if e := os.WriteFile(string(src), []byte(testcase), 0644); e != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "WriteFile", Fn: string(src), Err: e}))
+	}
+	if e := os.WriteFile(string(dst), []byte(testcase+testcase), 0644); e != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "WriteFile", Fn: string(dst), Err: e}))
+	}
+	// Copy src to dst
+	if e := tsfio.CopyFile(src, dst); e != nil {
+		// If CopyFile returns an error, the test fails
+		t.Error(tserr.Op(&tserr.OpArgs{Op: fmt.Sprintf("CopyFile %v to", src), Fn: string(dst), Err: e}))
+	}
+	// Read dst in b
+	b, err := os.ReadFile(string(dst))
+	// If ReadFile returns an error, the test fails
+	if err != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "ReadFile", Fn: string(dst), Err: err}))
+	}
+	// If b does not equal testcase, the test fails
+	if string(b) != testcase {
+		t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: string(dst), Actual: string(b), Want: testcase}))
+	}
+	// Remove src and dst
+	rm(t, src)
+	rm(t, dst)
+}
+
+// TestCopyFileWith tests CopyFileWith to preserve the permission bits and modification time of the source.
+// The test fails if CopyFileWith returns an error or if the metadata of the copy differs from the source.
+func TestCopyFileWith(t *testing.T) {
+	// Create temporary file src and Filename dst, which does not exist
+	src, dst := tmpFile(t), tmpFile(t)
+	rm(t, dst)
+	// Set permission bits and modification time of src
+	mt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if e := os.Chmod(string(src), 0600); e != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "Chmod", Fn: string(src), Err: e}))
+	}
+	if e := os.Chtimes(string(src), mt, mt); e != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "Chtimes", Fn: string(src), Err: e}))
+	}
+	// Copy src to dst and preserve the metadata
+	if e := tsfio.CopyFileWith(src, dst, tsfio.CopyOptions{Mode: true, ModTime: true}); e != nil {
+		// If CopyFileWith returns an error, the test fails
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: fmt.Sprintf("CopyFileWith %v to", src), Fn: string(dst), Err: e}))
+	}
+	// If the modification time of dst differs from src, the test fails
+	if m := modTime(t, dst); !m.Equal(mt) {
+		t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: "modification time of " + string(dst), Actual: m.String(), Want: mt.String()}))
+	}
+	// If the permission bits of dst differ from src, the test fails. Permission bits are not tested on Windows.
+	if fi, e := os.Stat(string(dst)); (e == nil) && (runtime.GOOS != "windows") && (fi.Mode().Perm() != 0600) {
+		t.Error(tserr.Equal(&tserr.EqualArgs{Var: "permission bits of " + string(dst), Actual: int64(fi.Mode().Perm()), Want: 0600}))
+	}
+	// Remove src and dst
+	rm(t, src)
+	rm(t, dst)
+}
+
+// TestCopyFileErr tests CopyFile to return an error for an empty filename, a source which does not exist
+// and a copy of a file to itself. If CopyFile returns nil, the test fails.
+func TestCopyFileErr(t *testing.T) {
+	// Create temporary files fn and nf, nf does not exist
+	fn, nf := tmpFile(t), tmpFile(t)
+	rm(t, nf)
+	// Iterate over the source and destination pairs expected to fail
+	for _, c := range [][2]tsfio.Filename{{"", fn}, {fn, ""}, {nf, fn}, {fn, fn}} {
+		// If CopyFile returns nil, the test fails
+		if e := tsfio.CopyFile(c[0], c[1]); e == nil {
+			t.Error(tserr.NilFailed(fmt.Sprintf("CopyFile %v to %v", c[0], c[1])))
+		}
+	}
+	// Remove fn
+	rm(t, fn)
+}
+
+// TestCopyDir tests CopyDir to copy a temporary directory tree to a directory which does not exist.
+// The test fails if CopyDir returns an error or if the copied tree differs from the source.
+func TestCopyDir(t *testing.T) {
+	// Create temporary directories src and dst
+	src, dst := tmpDir(t), tmpDir(t)
+	// Create the test tree in src
+	writeTree(t, src, testTree)
+	// Copy src to a directory in dst, which does not exist
+	cp := tsfio.Directory(filepath.Join(string(dst), string(testfile)))
+	if e := tsfio.CopyDir(src, cp); e != nil {
+		// If CopyDir returns an error, the test fails
+		t.Error(tserr.Op(&tserr.OpArgs{Op: fmt.Sprintf("CopyDir %v to", src), Fn: string(cp), Err: e}))
+	}
+	// The test fails if the copied tree differs from the source
+	checkTree(t, cp, testTree)
+	// Remove src and dst
+	rmAll(t, src)
+	rmAll(t, dst)
+}
+
+// TestCopyDirWith tests CopyDirWith to preserve the modification time of a copied directory.
+// The test fails if CopyDirWith returns an error or if the modification time differs from the source.
+func TestCopyDirWith(t *testing.T) {
+	// Create temporary directories src and dst
+	src, dst := tmpDir(t), tmpDir(t)
+	// Create the test tree in src
+	writeTree(t, src, testTree)
+	// Set the modification time of the sub directory b
+	mt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	b := filepath.Join(string(src), "b")
+	if e := os.Chtimes(b, mt, mt); e != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "Chtimes", Fn: b, Err: e}))
+	}
+	// Copy src to dst and preserve the metadata
+	if e := tsfio.CopyDirWith(src, dst, tsfio.CopyOptions{Mode: true, ModTime: true}); e != nil {
+		// If CopyDirWith returns an error, the test fails
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: fmt.Sprintf("CopyDirWith %v to", src), Fn: string(dst), Err: e}))
+	}
+	// The test fails if the copied tree differs from the source
+	checkTree(t, dst, testTree)
+	// If the modification time of the copied sub directory differs, the test fails
+	cb := tsfio.Filename(filepath.Join(string(dst), "b"))
+	if m := modTime(t, cb); !m.Equal(mt) {
+		t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: "modification time of " + string(cb), Actual: m.String(), Want: mt.String()}))
+	}
+	// Remove src and dst
+	rmAll(t, src)
+	rmAll(t, dst)
+}
+
+// TestCopyDirItself tests CopyDir to return an error if a directory is copied into itself.
+// If CopyDir returns nil, the test fails.
+func TestCopyDirItself(t *testing.T) {
+	// Create temporary directory src
+	src := tmpDir(t)
+	// Iterate over destinations in src
+	for _, dst := range []tsfio.Directory{src, tsfio.Directory(filepath.Join(string(src), string(testfile)))} {
+		// If CopyDir returns nil, the test fails
+		if e := tsfio.CopyDir(src, dst); e == nil {
+			t.Error(tserr.NilFailed(fmt.Sprintf("CopyDir %v to %v", src, dst)))
+		}
+	}
+	// Remove src
+	rmAll(t, src)
+}
+
+// TestCopyDirItselfSymlink tests CopyDir to return an error if a directory is copied into itself through a
+// symbolic link to a sub directory of the source. If CopyDir returns nil, the test fails. The test is skipped if
+// symbolic links cannot be created.
+func TestCopyDirItselfSymlink(t *testing.T) {
+	// Create temporary directories src and d
+	src, d := tmpDir(t), tmpDir(t)
+	// Create the test tree in src
+	writeTree(t, src, testTree)
+	// Create symbolic link l in d pointing to the sub directory b of src
+	l := filepath.Join(string(d), string(testfile))
+	if e := os.Symlink(filepath.Join(string(src), "b"), l); e != nil {
+		rmAll(t, src)
+		rm(t, d)
+		t.Skip(e)
+	}
+	// Destination in src reached through l
+	dst := tsfio.Directory(filepath.Join(l, string(testfile)))
+	// If CopyDir returns nil, the test fails
+	if e := tsfio.CopyDir(src, dst); e == nil {
+		t.Error(tserr.NilFailed(fmt.Sprintf("CopyDir %v to %v", src, dst)))
+	}
+	// The test fails if the test tree in src has been changed
+	checkTree(t, src, testTree)
+	// Remove src and d
+	rmAll(t, src)
+	rmAll(t, d)
+}
+
+// TestCopyDirErr tests CopyDir to return an error for an empty directory name and a source which
+// does not exist. If CopyDir returns nil, the test fails.
+func TestCopyDirErr(t *testing.T) {
+	// Create temporary directories d and nd, nd does not exist
+	d, nd := tmpDir(t), tmpDir(t)
+	rm(t, nd)
+	// Iterate over the source and destination pairs expected to fail
+	for _, c := range [][2]tsfio.Directory{{"", d}, {d, ""}, {nd, d}} {
+		// If CopyDir returns nil, the test fails
+		if e := tsfio.CopyDir(c[0], c[1]); e == nil {
+			t.Error(tserr.NilFailed(fmt.Sprintf("CopyDir %v to %v", c[0], c[1])))
+		}
+	}
+	// Remove d
+	rm(t, d)
+}
@@ -0,0 +1,55 @@
+//go:build linux
+
+package tsfio
+
+// Import standard library packages io and os
+import (
+	"io" // io
+	"os" // os
+)
+
+// appendRange appends the first n bytes of src to dst with copy_file_range, if dst and src are regular files of the
+// operating system. If appendMode is true, dst is opened with O_APPEND, which copy_file_range does not support.
+// Therefore, dst is opened again without O_APPEND and the contents are written at its end. Since seeking the end and
+// writing is not atomic, the opened dst is locked exclusively with flock, unless locked is true, i.e., dst is already
+// locked by the caller. Concurrent writers are excluded, if they lock dst, e.g., with WriteStrLocked. It returns true,
+// if the contents are copied, and an error, if any. It returns false, if the contents need to be copied otherwise.
+func appendRange(dst, src File, n int64, appendMode, locked bool) (bool, error) {
+	// Fall back, if dst or src are not files of the operating system or n is unknown
+	d, ok1 := dst.(*os.File)
+	s, ok2 := src.(*os.File)
+	if !ok1 || !ok2 || (n < 0) {
+		return false, nil
+	}
+	// Fall back, if dst is not a regular file
+	di, err := d.Stat()
+	if (err != nil) || !di.Mode().IsRegular() {
+		return false, nil
+	}
+	w := d
+	if appendMode {
+		// Open dst again without O_APPEND
+		w, err = os.OpenFile(d.Name(), os.O_WRONLY, 0)
+		if err != nil {
+			return false, nil
+		}
+		defer w.Close()
+		// Fall back, if the opened file is not dst
+		if wi, e := w.Stat(); (e != nil) || !os.SameFile(di, wi) {
+			return false, nil
+		}
+		// Lock the opened dst exclusively, if dst is not locked by the caller. The lock is released by Close.
+		if !locked {
+			if ok, e := lockFd(w.Fd(), false, true); !ok || (e != nil) {
+				return false, nil
+			}
+		}
+		// Write at the end of dst
+		if _, e := w.Seek(0, io.SeekEnd); e != nil {
+			return false, nil
+		}
+	}
+	// ReadFrom copies with copy_file_range and falls back to a buffered copy, if the kernel does not support it
+	_, err = w.ReadFrom(&io.LimitedReader{R: s, N: n})
+	return true, err
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package tsfio
+
+// appendRange always returns false, since copy_file_range is only available on Linux. The contents of src
+// are streamed to dst by the caller.
+func appendRange(dst, src File, n int64, appendMode, locked bool) (bool, error) {
+	return false, nil
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio_test
+
+// Import standard library packages as well as tserr and tsfio
+import (
+	"context"       // context
+	"fmt"           // fmt
+	"path/filepath" // filepath
+	"strings"       // strings
+	"testing"       // testing
+
+	"github.com/thorstenrie/tserr" // tserr
+	"github.com/thorstenrie/tsfio" // tsfio
+)
+
+// ctxChunks holds the number of chunks after which a countCtx is canceled
+const ctxChunks int = 2
+
+// A countCtx is a context, which is canceled after its Err method returned nil n times. Therefore, an operation
+// checking the context before each chunk is canceled in the middle of a large file.
+type countCtx struct {
+	context.Context     // Context provides a Done channel, which is not nil
+	n               int // n holds the number of remaining calls of Err returning nil
+}
+
+// Err returns nil for the first n calls and context.Canceled afterwards.
+func (c *countCtx) Err() error {
+	if c.n <= 0 {
+		return context.Canceled
+	}
+	c.n--
+	return nil
+}
+
+// cancelCtx returns a countCtx canceled after ctxChunks chunks.
+func cancelCtx(t *testing.T) context.Context {
+	// Panic if t is nil
+	if t == nil {
+		panic("nil pointer")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return &countCtx{Context: ctx, n: ctxChunks}
+}
+
+// ctxLarge returns contents larger than ctxChunks chunks.
+func ctxLarge() string {
+	return strings.Repeat(testcase, 256*1024/len(testcase))
+}
+
+// TestCtx tests the context-aware variants with a context, which is not canceled, to read, write, append and
+// copy large contents in chunks. The test fails if any of them returns an error or if the contents differ.
+func TestCtx(t *testing.T) {
+	// Create temporary directory d and context ctx
+	d := tmpDir(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fn := func(n string) tsfio.Filename { return tsfio.Filename(filepath.Join(string(d), n)) }
+	l := ctxLarge()
+	// Write, append and copy files
+	if e := tsfio.WriteStrCtx(ctx, fn("a"), l); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "WriteStrCtx", Fn: string(fn("a")), Err: e}))
+	}
+	if e := tsfio.WriteSingleStrCtx(ctx, fn("b"), testcase); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "WriteSingleStrCtx", Fn: string(fn("b")), Err: e}))
+	}
+	if e := tsfio.AppendFileCtx(ctx, &tsfio.Append{FileA: fn("b"), FileI: fn("a")}); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "AppendFileCtx", Fn: string(fn("b")), Err: e}))
+	}
+	if e := tsfio.CopyFileCtx(ctx, fn("b"), fn("c")); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "CopyFileCtx", Fn: string(fn("c")), Err: e}))
+	}
+	// The test fails if the contents differ
+	checkTree(t, d, map[string]string{"a": l, "b": testcase + l, "c": testcase + l})
+	// The test fails if ReadFileCtx returns an error or other contents
+	if b, e := tsfio.ReadFileCtx(ctx, fn("c")); (e != nil) || (string(b) != testcase+l) {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "ReadFileCtx", Fn: string(fn("c")), Err: e}))
+	}
+	// Append files, copy and move the directory tree in e
+	if e := tsfio.CreateDir(tsfio.Directory(fn("e"))); e != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "CreateDir", Fn: string(fn("e")), Err: e}))
+	}
+	if e := tsfio.AppendFilesCtx(ctx, fn("e/a"), []tsfio.Filename{fn("a"), fn("b")}); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "AppendFilesCtx", Fn: string(fn("e/a")), Err: e}))
+	}
+	if e := tsfio.MoveFileCtx(ctx, fn("c"), fn("e/c")); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "MoveFileCtx", Fn: string(fn("e/c")), Err: e}))
+	}
+	if e := tsfio.CopyDirCtx(ctx, tsfio.Directory(fn("e")), tsfio.Directory(fn("f"))); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "CopyDirCtx", Fn: string(fn("f")), Err: e}))
+	}
+	if e := tsfio.MoveDirCtx(ctx, tsfio.Directory(fn("f")), tsfio.Directory(fn("g"))); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "MoveDirCtx", Fn: string(fn("g")), Err: e}))
+	}
+	// The test fails if the contents differ
+	checkTree(t, d, map[string]string{"e/a": l + testcase + l, "e/c": testcase + l, "g/a": l + testcase + l, "g/c": testcase + l})
+	testEntries(t, d, 4)
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestCtxCanceled tests the context-aware variants to stop in the middle of large contents when the context
+// is canceled and to remove partial output. The test fails if any of them returns nil or if partial output remains.
+func TestCtxCanceled(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	fn := func(n string) tsfio.Filename { return tsfio.Filename(filepath.Join(string(d), n)) }
+	l := ctxLarge()
+	// Write existing files a and l
+	writeTree(t, d, map[string]string{"a": testcase, "l": l})
+	// The test fails if any of the context-aware variants returns nil
+	for n, f := range map[string]func(ctx context.Context) error{
+		"WriteStrCtx to a": func(ctx context.Context) error { return tsfio.WriteStrCtx(ctx, fn("a"), l) },
+		"WriteStrCtx to b": func(ctx context.Context) error { return tsfio.WriteStrCtx(ctx, fn("b"), l) },
+		"WriteSingleStrCtx to a": func(ctx context.Context) error {
+			return tsfio.WriteSingleStrCtx(ctx, fn("a"), l)
+		},
+		"AppendFileCtx to a": func(ctx context.Context) error {
+			return tsfio.AppendFileCtx(ctx, &tsfio.Append{FileA: fn("a"), FileI: fn("l")})
+		},
+		"AppendFileCtx to c": func(ctx context.Context) error {
+			return tsfio.AppendFileCtx(ctx, &tsfio.Append{FileA: fn("c"), FileI: fn("l")})
+		},
+		"CopyFileCtx to c": func(ctx context.Context) error { return tsfio.CopyFileCtx(ctx, fn("l"), fn("c")) },
+		"AppendFilesCtx to a": func(ctx context.Context) error {
+			return tsfio.AppendFilesCtx(ctx, fn("a"), []tsfio.Filename{fn("a"), fn("l")})
+		},
+		"AppendFilesCtx to c": func(ctx context.Context) error {
+			return tsfio.AppendFilesCtx(ctx, fn("c"), []tsfio.Filename{fn("a"), fn("l")})
+		},
+		"ReadFileCtx of l": func(ctx context.Context) error {
+			_, e := tsfio.ReadFileCtx(ctx, fn("l"))
+			return e
+		},
+	} {
+		if e := f(cancelCtx(t)); e == nil {
+			t.Error(tserr.NilFailed(n))
+		}
+	}
+	// The test fails if a or l changed or if other files, e.g., temporary files, remain
+	checkTree(t, d, map[string]string{"a": testcase, "l": l})
+	testEntries(t, d, 2)
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestCtxCanceledDir tests CopyDirCtx, MoveFileCtx and MoveDirCtx to stop when the context is canceled and to remove
+// partial output. The test fails if any of them returns nil, if the source changed or if partial output remains.
+func TestCtxCanceledDir(t *testing.T) {
+	// Create temporary directories src and dst
+	src, dst := tmpDir(t), tmpDir(t)
+	fn := func(d tsfio.Directory, n string) string { return filepath.Join(string(d), n) }
+	// Write the test tree with a large file in src and an existing file a in dst
+	m := map[string]string{"a": testcase, "b/c": testcase, "b/d/e": "", "b/l": ctxLarge(), "f/g": testcase}
+	writeTree(t, src, m)
+	writeTree(t, dst, map[string]string{"a": testcase})
+	// The test fails if CopyDirCtx returns nil for a context canceled after some files are copied
+	for _, d := range []tsfio.Directory{tsfio.Directory(fn(dst, "x")), dst} {
+		ctx, cancel := context.WithCancel(context.Background())
+		if e := tsfio.CopyDirCtx(&countCtx{Context: ctx, n: 4 * ctxChunks}, src, d); e == nil {
+			t.Error(tserr.NilFailed("CopyDirCtx to " + string(d)))
+		}
+		cancel()
+	}
+	// The test fails if MoveFileCtx or MoveDirCtx return nil for a canceled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if e := tsfio.MoveFileCtx(ctx, tsfio.Filename(fn(src, "a")), tsfio.Filename(fn(dst, "y"))); e == nil {
+		t.Error(tserr.NilFailed("MoveFileCtx"))
+	}
+	if e := tsfio.MoveDirCtx(ctx, src, tsfio.Directory(fn(dst, "y"))); e == nil {
+		t.Error(tserr.NilFailed("MoveDirCtx"))
+	}
+	// The test fails if src changed, the copied directories remain or a in dst is not truncated to size zero
+	checkTree(t, src, m)
+	checkTree(t, dst, map[string]string{"a": ""})
+	testEntries(t, dst, 1)
+	// Remove src and dst
+	rmAll(t, src)
+	rmAll(t, dst)
+}
+
+// TestCtxCanceledMem tests AppendFileCtx and CopyFileCtx of an FS with a MemBackend to remove partial output,
+// if the context is canceled. The test fails if they return nil or if partial output remains.
+func TestCtxCanceledMem(t *testing.T) {
+	// Create FS with MemBackend and Directory d
+	fsys, _, d := memFS(t)
+	fn := func(n string) tsfio.Filename { return tsfio.Filename(filepath.Join(string(d), n)) }
+	l := ctxLarge()
+	// Write existing files a and l
+	memTree(t, fsys, d, map[string]string{"a": testcase, "l": l})
+	// The test fails if AppendFileCtx or CopyFileCtx return nil
+	if e := fsys.AppendFileCtx(cancelCtx(t), &tsfio.Append{FileA: fn("a"), FileI: fn("l")}); e == nil {
+		t.Error(tserr.NilFailed("AppendFileCtx"))
+	}
+	if e := fsys.CopyFileCtx(cancelCtx(t), fn("l"), fn("c")); e == nil {
+		t.Error(tserr.NilFailed("CopyFileCtx"))
+	}
+	// The test fails if a changed or c exists
+	memCheckTree(t, fsys, d, map[string]string{"a": testcase})
+	if ok, e := fsys.ExistsFile(fn("c")); ok || (e != nil) {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "ExistsFile " + string(fn("c")), Actual: fmt.Sprint(ok, e), Want: "false <nil>"}))
+	}
+	// The test fails if d exists in the file system
+	memNotOnDisk(t, d)
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio
+
+// Import standard library packages
+import (
+	"fmt"     // fmt
+	"slices"  // slices
+	"strings" // strings
+)
+
+// diffContext holds the default number of context lines of a unified diff
+const diffContext int = 3
+
+// maxDiffTrace holds the maximum number of furthest x values kept by myers to backtrack the edit script.
+// It limits the memory of a diff to about 32 MiB, which allows for about 2,000 removed and added lines.
+const maxDiffTrace int = 1 << 22
+
+// DiffOptions holds the options for DiffWith. The zero value uses the names a and b and three context lines.
+type DiffOptions struct {
+	NameA   string // NameA holds the name of a in the header of the diff. If empty, a is used.
+	NameB   string // NameB holds the name of b in the header of the diff. If empty, b is used.
+	Context int    // Context holds the number of unchanged lines around a change. If zero, 3 is used. If negative, none.
+}
+
+// Diff returns the line-based differences between a and b in the unified diff format. It is a shortcut for DiffWith
+// with the zero value of DiffOptions. It returns an empty string, if a equals b.
+func Diff(a, b string) string {
+	return DiffWith(a, b, DiffOptions{})
+}
+
+// DiffWith returns the line-based differences between a and b in the unified diff format with the options o. The
+// differences are computed with the Myers algorithm, so the diff holds a minimal number of removed and added lines.
+// The diff starts with a header holding the names of a and b. Each hunk starts with the line numbers and lengths of
+// its lines in a and b, e.g., @@ -3,4 +3,5 @@, followed by the unchanged context lines prefixed with a space, the
+// removed lines of a prefixed with - and the added lines of b prefixed with +. A last line without new line is
+// marked with \ No newline at end of file. If a and b differ in too many lines to compute the diff with bounded memory,
+// the diff only holds the line Files a and b differ with the names of a and b. It returns an empty string, if a equals b.
+func DiffWith(a, b string, o DiffOptions) string {
+	// Return an empty string, if a equals b
+	if a == b {
+		return ""
+	}
+	// Use the default names and number of context lines, if not set
+	if o.NameA == "" {
+		o.NameA = "a"
+	}
+	if o.NameB == "" {
+		o.NameB = "b"
+	}
+	if o.Context == 0 {
+		o.Context = diffContext
+	}
+	o.Context = max(o.Context, 0)
+	// Compute the edit script of the lines of a and b
+	la, lb := diffLines(a), diffLines(b)
+	ops, ok := myers(la, lb)
+	// Return a plain message, if the edit script exceeds maxDiffTrace
+	if !ok {
+		return fmt.Sprintf("Files %s and %s differ\n", o.NameA, o.NameB)
+	}
+	// Write the header and the hunks
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", o.NameA, o.NameB)
+	for _, h := range diffHunks(ops, o.Context) {
+		writeHunk(&sb, la, lb, ops, h)
+	}
+	return sb.String()
+}
+
+// A diffOp is an operation of an edit script, which transforms the lines of a into the lines of b
+type diffOp struct {
+	kind byte // kind holds a space for an unchanged line, - for a removed line of a and + for an added line of b
+	i, j int  // i and j hold the indices of the line in a and b. An added line has no index in a, a removed none in b.
+}
+
+// diffLines splits s into lines. Each line holds its new line, except a last line without new line.
+func diffLines(s string) []string {
+	l := strings.SplitAfter(s, "\n")
+	// Remove the empty string after the last new line
+	if l[len(l)-1] == "" {
+		l = l[:len(l)-1]
+	}
+	return l
+}
+
+// myers returns the shortest edit script transforming lines a into lines b computed with the Myers algorithm.
+// The script holds one operation for each unchanged, removed and added line in the order of the lines.
+// It returns nil and false, if backtracking the edit script requires more than maxDiffTrace values.
+func myers(a, b []string) ([]diffOp, bool) {
+	n, m := len(a), len(b)
+	// v holds the furthest x for each diagonal k = x - y at offset off. trace holds the diagonals -d-1 to d+1
+	// of v before each step d, since step d only reads and writes these diagonals.
+	off := n + m + 1
+	v := make([]int, 2*off+1)
+	var trace [][]int
+	// size holds the number of values in trace
+	size := 0
+	// Advance d until the end of a and b is reached
+	for d := 0; d <= n+m; d++ {
+		// Return false, if trace exceeds maxDiffTrace
+		if size += 2*d + 3; size > maxDiffTrace {
+			return nil, false
+		}
+		trace = append(trace, slices.Clone(v[off-d-1:off+d+2]))
+		done := false
+		for k := -d; k <= d; k += 2 {
+			// Move down from diagonal k+1 or right from diagonal k-1, whichever reaches further
+			var x int
+			if (k == -d) || ((k != d) && (v[off+k-1] < v[off+k+1])) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			// Follow the diagonal of unchanged lines
+			y := x - k
+			for (x < n) && (y < m) && (a[x] == b[y]) {
+				x, y = x+1, y+1
+			}
+			v[off+k] = x
+			if (x >= n) && (y >= m) {
+				done = true
+				break
+			}
+		}
+		if done {
+			break
+		}
+	}
+	// Backtrack the trace from the end of a and b to collect the operations in reverse order
+	var ops []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		// The diagonals of step d start at -d-1 in trace
+		v, off := trace[d], d+1
+		k := x - y
+		// Retrieve the diagonal of the previous step
+		pk := k - 1
+		if (k == -d) || ((k != d) && (v[off+k-1] < v[off+k+1])) {
+			pk = k + 1
+		}
+		px := v[off+pk]
+		py := px - pk
+		// Collect the unchanged lines of the diagonal
+		for (x > px) && (y > py) {
+			x, y = x-1, y-1
+			ops = append(ops, diffOp{kind: ' ', i: x, j: y})
+		}
+		// Collect the added or removed line of step d
+		if d > 0 {
+			if x == px {
+				ops = append(ops, diffOp{kind: '+', i: -1, j: py})
+			} else {
+				ops = append(ops, diffOp{kind: '-', i: px, j: -1})
+			}
+			x, y = px, py
+		}
+	}
+	slices.Reverse(ops)
+	return ops, true
+}
+
+// diffHunks returns the ranges of ops forming the hunks of a unified diff with c context lines. Changes separated
+// by at most 2*c unchanged lines are in the same hunk.
+func diffHunks(ops []diffOp, c int) [][2]int {
+	var hs [][2]int
+	last := -1
+	for i, op := range ops {
+		// Skip unchanged lines
+		if op.kind == ' ' {
+			continue
+		}
+		// Extend the current hunk, if the change is close to its last change. Otherwise, start a new hunk.
+		if (len(hs) > 0) && (i-last-1 <= 2*c) {
+			hs[len(hs)-1][1] = min(i+1+c, len(ops))
+		} else {
+			hs = append(hs, [2]int{max(i-c, 0), min(i+1+c, len(ops))})
+		}
+		last = i
+	}
+	return hs
+}
+
+// writeHunk writes hunk h of ops with the lines la and lb to w.
+func writeHunk(w *strings.Builder, la, lb []string, ops []diffOp, h [2]int) {
+	// Count the lines of a and b before the hunk, sa and sb, and in the hunk, na and nb
+	var sa, sb, na, nb int
+	for i, op := range ops[:h[1]] {
+		ca, cb := &sa, &sb
+		if i >= h[0] {
+			ca, cb = &na, &nb
+		}
+		if op.kind != '+' {
+			*ca++
+		}
+		if op.kind != '-' {
+			*cb++
+		}
+	}
+	fmt.Fprintf(w, "@@ -%s +%s @@\n", hunkRange(sa, na), hunkRange(sb, nb))
+	// Write the lines of the hunk
+	for _, op := range ops[h[0]:h[1]] {
+		var l string
+		if op.kind == '+' {
+			l = lb[op.j]
+		} else {
+			l = la[op.i]
+		}
+		w.WriteByte(op.kind)
+		w.WriteString(l)
+		// Mark a last line without new line
+		if !strings.HasSuffix(l, "\n") {
+			w.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange returns the range of a hunk with n lines after s lines in the unified diff format. The first line
+// has number 1. A range of one line omits its length. An empty range starts at the line before.
+func hunkRange(s, n int) string {
+	switch n {
+	case 0:
+		return fmt.Sprintf("%d,0", s)
+	case 1:
+		return fmt.Sprint(s + 1)
+	default:
+		return fmt.Sprintf("%d,%d", s+1, n)
+	}
+}
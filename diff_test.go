@@ -0,0 +1,122 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio_test
+
+// Import standard library packages as well as tserr and tsfio
+import (
+	"fmt"     // fmt
+	"strings" // strings
+	"testing" // testing
+
+	"github.com/thorstenrie/tserr" // tserr
+	"github.com/thorstenrie/tsfio" // tsfio
+)
+
+// TestDiff tests Diff and DiffWith to return the unified diff of two strings. The test fails if the diff differs from
+// the diff expected in the unified diff format of GNU diff.
+func TestDiff(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		a, b string
+		o    tsfio.DiffOptions
+		want string
+	}{
+		{"equal strings", testcase, testcase, tsfio.DiffOptions{}, ""},
+		{"empty a", "", "a\nb\nc\n", tsfio.DiffOptions{}, "--- a\n+++ b\n@@ -0,0 +1,3 @@\n+a\n+b\n+c\n"},
+		{"empty b", "a\nb\n", "", tsfio.DiffOptions{}, "--- a\n+++ b\n@@ -1,2 +0,0 @@\n-a\n-b\n"},
+		{"two hunks", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n", "1\n2\nx\n4\n5\n6\n7\n8\n9\n10\n11\ny", tsfio.DiffOptions{},
+			"--- a\n+++ b\n@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+x\n 4\n 5\n 6\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+y\n\\ No newline at end of file\n"},
+		{"merged hunk", "1\n2\n3\n4\n5\n", "x\n2\n3\ny\n5\n", tsfio.DiffOptions{Context: 1},
+			"--- a\n+++ b\n@@ -1,5 +1,5 @@\n-1\n+x\n 2\n 3\n-4\n+y\n 5\n"},
+		{"names without context", "a\nb\nc\n", "a\nx\nc\n", tsfio.DiffOptions{NameA: "old", NameB: "new", Context: -1},
+			"--- old\n+++ new\n@@ -2 +2 @@\n-b\n+x\n"},
+		{"missing new line", "a", "a\n", tsfio.DiffOptions{},
+			"--- a\n+++ b\n@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+a\n"},
+	} {
+		// The test fails if the diff differs
+		if d := tsfio.DiffWith(c.a, c.b, c.o); d != c.want {
+			t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: "diff of " + c.name, Actual: d, Want: c.want}))
+		}
+	}
+	// The test fails if Diff differs from DiffWith with the zero value of DiffOptions
+	a, b := "a\nb\nc\n", "a\nc\nd\n"
+	if d, w := tsfio.Diff(a, b), tsfio.DiffWith(a, b, tsfio.DiffOptions{}); d != w {
+		t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: "Diff", Actual: d, Want: w}))
+	}
+}
+
+// TestDiffMinimal tests Diff to return a minimal number of removed and added lines for strings with many
+// equal lines. The test fails if the diff holds more changed lines than expected.
+func TestDiffMinimal(t *testing.T) {
+	// Each third line of b differs from a
+	var a, b strings.Builder
+	for i := 0; i < 300; i++ {
+		a.WriteString("line\n")
+		if i%3 == 0 {
+			b.WriteString("other\n")
+		} else {
+			b.WriteString("line\n")
+		}
+	}
+	// Count the lines of the diff
+	n := make(map[string]int64)
+	for _, l := range strings.Split(tsfio.Diff(a.String(), b.String()), "\n") {
+		n[l]++
+	}
+	// The test fails if the number of removed or added lines differs
+	for _, l := range []string{"-line", "+other"} {
+		if n[l] != 100 {
+			t.Error(tserr.Equal(&tserr.EqualArgs{Var: "lines " + l, Actual: n[l], Want: 100}))
+		}
+	}
+}
+
+// TestDiffLarge tests Diff to return a plain message for strings with too many different lines and a unified diff
+// for large strings with few different lines. The test fails if the diff differs from the expected diff.
+func TestDiffLarge(t *testing.T) {
+	// Each line of b differs from a
+	var a, b strings.Builder
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&a, "a%d\n", i)
+		fmt.Fprintf(&b, "b%d\n", i)
+	}
+	// The test fails if the diff is not the plain message
+	if d, w := tsfio.Diff(a.String(), b.String()), "Files a and b differ\n"; d != w {
+		t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: "diff of different lines", Actual: d, Want: w}))
+	}
+	// The test fails if the diff of a and a with one changed line differs
+	c := strings.Replace(a.String(), "a5000\n", "c5000\n", 1)
+	w := "--- a\n+++ b\n@@ -4998,7 +4998,7 @@\n a4997\n a4998\n a4999\n-a5000\n+c5000\n a5001\n a5002\n a5003\n"
+	if d := tsfio.Diff(a.String(), c); d != w {
+		t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: "diff of one changed line", Actual: d, Want: w}))
+	}
+}
+
+// TestGoldenFileDiff tests EvalGoldenFile to return an error holding the unified diff of the golden file and the
+// test data. The test fails if EvalGoldenFile returns nil or if the error does not hold the diff.
+func TestGoldenFileDiff(t *testing.T) {
+	// Create the golden file
+	tc := &tsfio.Testcase{Name: testcase, Data: "a\nb\nc\n"}
+	if e := tsfio.CreateGoldenFile(tc); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "CreateGoldenFile", Fn: tc.Name, Err: e}))
+	}
+	fn, e := tsfio.GoldenFilePath(tc.Name)
+	if e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "GoldenFilePath", Fn: tc.Name, Err: e}))
+	}
+	// Change line b of the test data with Windows new lines, which are normalized
+	tc.Data = "a\r\nx\r\nc\r\n"
+	e = tsfio.EvalGoldenFile(tc)
+	// The test fails if EvalGoldenFile returns nil
+	if e == nil {
+		t.Error(tserr.NilFailed("EvalGoldenFile"))
+	} else if w := "--- " + string(fn) + "\n+++ " + tc.Name + "\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n"; !strings.Contains(e.Error(), w) {
+		// The test fails if the error does not hold the diff
+		t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: "error of EvalGoldenFile", Actual: e.Error(), Want: w}))
+	}
+	// Remove the golden file
+	if e := tsfio.RemoveFile(fn); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "RemoveFile", Fn: string(fn), Err: e}))
+	}
+}
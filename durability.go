@@ -0,0 +1,159 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio
+
+// Import standard library packages and tserr
+import (
+	"errors"        // errors
+	"io/fs"         // fs
+	"os"            // os
+	"path/filepath" // filepath
+	"sync/atomic"   // atomic
+
+	"github.com/thorstenrie/tserr" // tserr
+)
+
+// A Durability defines how writing functions commit changes to stable storage before they return. Without syncing,
+// the operating system commits changes later and data can be lost on power failure. The package durability is set
+// with SetDurability and an FS can use its own Durability. The zero value of Durability is not set and the package
+// durability is used. Atomic writes, e.g., WriteAtomic, WriteSingleStr and a Transaction, always sync the file and
+// its directory regardless of the durability.
+type Durability int
+
+// Durabilities of writing functions
+const (
+	DurabilityNone Durability = iota + 1 // DurabilityNone leaves committing changes to the operating system, which is the default
+	DurabilityFile                       // DurabilityFile syncs written files to stable storage before closing them
+	DurabilityDir                        // DurabilityDir syncs written files and the directories holding their directory entries
+)
+
+// durability holds the package durability. If zero, DurabilityNone is used.
+var durability atomic.Int64
+
+// SetDurability sets the package durability of writing functions to d. It applies to the package functions and to
+// each FS without Durability. If d is zero, DurabilityNone is used. It returns the previous package durability.
+// The previous durability can be restored by calling SetDurability again, e.g.,
+//
+//	defer tsfio.SetDurability(tsfio.SetDurability(tsfio.DurabilityDir))
+//
+// sets the durability for the scope of the calling function.
+func SetDurability(d Durability) Durability {
+	return orNone(Durability(durability.Swap(int64(d))))
+}
+
+// orNone returns d or DurabilityNone, if d is zero.
+func orNone(d Durability) Durability {
+	if d == 0 {
+		return DurabilityNone
+	}
+	return d
+}
+
+// SyncDir commits the directory entries of directory d to stable storage, e.g., after creating, renaming or removing
+// files in d. On Windows, directories cannot be synced and SyncDir does nothing. It returns an error, if d does not
+// exist or if any other error occurs.
+func SyncDir(d Directory) error {
+	return std.SyncDir(d)
+}
+
+// SyncDir performs the package function SyncDir with the settings of fsys.
+func (fsys *FS) SyncDir(d Directory) error {
+	// Resolve d against the root directory of fsys
+	d = rooted(fsys, d)
+	// Return an error in case d contains a blocked directory or filename
+	if e := fsys.CheckDir(d); e != nil {
+		return tserr.Check(&tserr.CheckArgs{F: string(d), Err: e})
+	}
+	// Check if d exists, also on Windows
+	ok, e := fsys.ExistsDir(d)
+	// Return an error if ExistsDir fails
+	if e != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "ExistsDir", Fn: string(d), Err: e})
+	}
+	// Return an error, if d does not exist
+	if !ok {
+		return errNotExist("directory " + string(d))
+	}
+	// Sync directory d
+	if e := fsys.backend().SyncDir(string(d)); e != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "sync directory", Fn: string(d), Err: e})
+	}
+	// No error occurred, return nil
+	return nil
+}
+
+// syncFile syncs the open file f to stable storage, if the durability of fsys is at least DurabilityFile.
+// It returns an error, if any.
+func (fsys *FS) syncFile(f File) error {
+	// Return nil, if files are not synced
+	if fsys.durability() < DurabilityFile {
+		return nil
+	}
+	// Sync f
+	if e := f.Sync(); e != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "Sync", Fn: f.Name(), Err: e})
+	}
+	// No error occurred, return nil
+	return nil
+}
+
+// syncParents syncs the parent directories of names to stable storage, if the durability of fsys is DurabilityDir.
+// Each directory is synced once. It returns an error, if any.
+func (fsys *FS) syncParents(names ...string) error {
+	// Return nil, if directories are not synced
+	if fsys.durability() < DurabilityDir {
+		return nil
+	}
+	// Sync each parent directory once
+	done := make(map[string]bool, len(names))
+	for _, n := range names {
+		d := filepath.Dir(n)
+		if done[d] {
+			continue
+		}
+		done[d] = true
+		if e := fsys.backend().SyncDir(d); e != nil {
+			return tserr.Op(&tserr.OpArgs{Op: "sync directory", Fn: d, Err: e})
+		}
+	}
+	// No error occurred, return nil
+	return nil
+}
+
+// closeDurable closes the written file f. Before, f is synced as requested by the durability of fsys. Afterwards,
+// its parent directory is synced as requested by the durability of fsys. It always closes f and returns an error,
+// if any.
+func (fsys *FS) closeDurable(f File) error {
+	// Sync f, if requested
+	if e := fsys.syncFile(f); e != nil {
+		f.Close()
+		return e
+	}
+	// Close f
+	if e := f.Close(); e != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "Close", Fn: f.Name(), Err: e})
+	}
+	// Sync the parent directory of f, if requested
+	return fsys.syncParents(f.Name())
+}
+
+// syncName syncs the existing file fn and its parent directory as requested by the durability of fsys, e.g., after
+// changing fn without opening it. It returns an error, if any.
+func (fsys *FS) syncName(fn Filename) error {
+	// Return nil, if files are not synced
+	if fsys.durability() < DurabilityFile {
+		return nil
+	}
+	// Open fn with syncFlag, which is read-only where syncing a file does not require write access. If fn cannot be
+	// read, retry write-only.
+	f, err := fsys.backend().OpenFile(string(fn), syncFlag, 0)
+	if (err != nil) && (syncFlag != os.O_WRONLY) && errors.Is(err, fs.ErrPermission) {
+		f, err = fsys.backend().OpenFile(string(fn), os.O_WRONLY, 0)
+	}
+	if err != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "OpenFile", Fn: string(fn), Err: err})
+	}
+	// Sync and close fn
+	return fsys.closeDurable(f)
+}
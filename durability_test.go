@@ -0,0 +1,293 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio_test
+
+// Import standard library packages as well as tserr and tsfio
+import (
+	"errors"        // errors
+	"fmt"           // fmt
+	"io/fs"         // fs
+	"os"            // os
+	"path/filepath" // filepath
+	"runtime"       // runtime
+	"testing"       // testing
+
+	"github.com/thorstenrie/tserr" // tserr
+	"github.com/thorstenrie/tsfio" // tsfio
+)
+
+// A syncBackend is a MemBackend, which counts the synced files and directories
+type syncBackend struct {
+	*tsfio.MemBackend           // MemBackend holds the files
+	files, dirs       *int      // files and dirs hold the number of synced files and directories
+	synced            *[]string // synced holds the synced directories
+}
+
+// OpenFile opens name with the MemBackend and returns a syncFile.
+func (b syncBackend) OpenFile(name string, flag int, perm fs.FileMode) (tsfio.File, error) {
+	f, err := b.MemBackend.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return syncFile{File: f, n: b.files}, nil
+}
+
+// SyncDir syncs directory name with the MemBackend and counts it.
+func (b syncBackend) SyncDir(name string) error {
+	*b.dirs++
+	*b.synced = append(*b.synced, name)
+	return b.MemBackend.SyncDir(name)
+}
+
+// A syncFile is a File, which counts calls of Sync
+type syncFile struct {
+	tsfio.File      // File holds the open file
+	n          *int // n holds the number of calls of Sync
+}
+
+// Sync syncs the File and counts it.
+func (f syncFile) Sync() error {
+	*f.n++
+	return f.File.Sync()
+}
+
+// A readOnlyBackend is a syncBackend, which refuses to open files without write permission for writing
+type readOnlyBackend struct {
+	syncBackend // syncBackend holds the files and counts the synced files
+}
+
+// OpenFile returns an error matching fs.ErrPermission, if name is opened for writing and has no write permission.
+// Otherwise, it opens name with the syncBackend.
+func (b readOnlyBackend) OpenFile(name string, flag int, perm fs.FileMode) (tsfio.File, error) {
+	if fi, e := b.Stat(name); (e == nil) && (flag&(os.O_WRONLY|os.O_RDWR) != 0) && (fi.Mode().Perm()&0200 == 0) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return b.syncBackend.OpenFile(name, flag, perm)
+}
+
+// A statBackend is a MemBackend, which fails Stat with fs.ErrPermission after a budget of calls. A negative budget
+// never fails. It counts the calls of Stat.
+type statBackend struct {
+	*tsfio.MemBackend      // MemBackend holds the files
+	calls, budget     *int // calls counts the calls of Stat and Stat fails after budget calls
+}
+
+// Stat returns an error matching fs.ErrPermission, if the budget of calls is exceeded. Otherwise, it returns the
+// FileInfo of name with the MemBackend.
+func (b statBackend) Stat(name string) (fs.FileInfo, error) {
+	if *b.calls++; (*b.budget >= 0) && (*b.calls > *b.budget) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrPermission}
+	}
+	return b.MemBackend.Stat(name)
+}
+
+// syncFS returns an FS with a syncBackend with durability d, its Directory and the counters of synced files and
+// directories as well as the synced directories.
+func syncFS(t *testing.T, d tsfio.Durability) (*tsfio.FS, tsfio.Directory, *int, *int, *[]string) {
+	// Panic if t is nil
+	if t == nil {
+		panic("nil pointer")
+	}
+	// Create FS with MemBackend and Directory d
+	fsys, m, dn := memFS(t)
+	// Count the synced files and directories
+	files, dirs, synced := new(int), new(int), new([]string)
+	fsys.Backend, fsys.Durability = syncBackend{MemBackend: m, files: files, dirs: dirs, synced: synced}, d
+	return fsys, dn, files, dirs, synced
+}
+
+// TestDurability tests the writing functions of an FS to sync files and directories as requested by its
+// Durability. The test fails if any of them returns an error or if the number of synced files or directories
+// differs.
+func TestDurability(t *testing.T) {
+	// Expected number of synced files and directories for each durability
+	for d, w := range map[tsfio.Durability][2]int{
+		tsfio.DurabilityNone: {0, 0},
+		tsfio.DurabilityFile: {4, 0},
+		tsfio.DurabilityDir:  {4, 4},
+	} {
+		// Create FS with durability d
+		fsys, dn, files, dirs, _ := syncFS(t, d)
+		fn := func(n string) tsfio.Filename { return tsfio.Filename(filepath.Join(string(dn), n)) }
+		// Write, append, change and copy files
+		if e := fsys.WriteStr(fn("a"), testcase); e != nil {
+			t.Error(tserr.Op(&tserr.OpArgs{Op: "WriteStr", Fn: string(fn("a")), Err: e}))
+		}
+		if e := fsys.AppendFile(&tsfio.Append{FileA: fn("b"), FileI: fn("a")}); e != nil {
+			t.Error(tserr.Op(&tserr.OpArgs{Op: "AppendFile", Fn: string(fn("b")), Err: e}))
+		}
+		if _, e := fsys.WriteAt(fn("b"), 0, []byte(testcase_unix)); e != nil {
+			t.Error(tserr.Op(&tserr.OpArgs{Op: "WriteAt", Fn: string(fn("b")), Err: e}))
+		}
+		if e := fsys.CopyFile(fn("b"), fn("c")); e != nil {
+			t.Error(tserr.Op(&tserr.OpArgs{Op: "CopyFile", Fn: string(fn("c")), Err: e}))
+		}
+		// The test fails if the number of synced files or directories differs
+		if *files != w[0] {
+			t.Error(tserr.Equal(&tserr.EqualArgs{Var: fmt.Sprintf("synced files with durability %d", d), Actual: int64(*files), Want: int64(w[0])}))
+		}
+		if *dirs != w[1] {
+			t.Error(tserr.Equal(&tserr.EqualArgs{Var: fmt.Sprintf("synced directories with durability %d", d), Actual: int64(*dirs), Want: int64(w[1])}))
+		}
+		// The test fails if d exists in the file system
+		memNotOnDisk(t, dn)
+	}
+}
+
+// TestDurabilityDir tests CreateDir, MoveFile and RemoveFile of an FS with DurabilityDir to sync the parent
+// directories of created, moved and removed entries. The test fails if any of them returns an error or if the
+// synced directories differ.
+func TestDurabilityDir(t *testing.T) {
+	// Create FS with DurabilityDir
+	fsys, dn, _, _, synced := syncFS(t, tsfio.DurabilityDir)
+	p := func(n ...string) string { return filepath.Join(append([]string{string(dn)}, n...)...) }
+	// Create directories x and x/y
+	if e := fsys.CreateDir(tsfio.Directory(p("x", "y"))); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "CreateDir", Fn: p("x", "y"), Err: e}))
+	}
+	// The test fails if CreateDir of an existing directory syncs
+	if e := fsys.CreateDir(tsfio.Directory(p("x"))); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "CreateDir", Fn: p("x"), Err: e}))
+	}
+	testSynced(t, synced, p("x"), p())
+	// Create file x/a, move it to x/y/a and remove it
+	memTree(t, fsys, tsfio.Directory(p("x")), map[string]string{"a": testcase})
+	// Ignore the directory synced by the atomic write of memTree
+	*synced = nil
+	if e := fsys.MoveFile(tsfio.Filename(p("x", "a")), tsfio.Filename(p("x", "y", "a"))); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "MoveFile", Fn: p("x", "y", "a"), Err: e}))
+	}
+	testSynced(t, synced, p("x"), p("x", "y"))
+	if e := fsys.RemoveFile(tsfio.Filename(p("x", "y", "a"))); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "RemoveFile", Fn: p("x", "y", "a"), Err: e}))
+	}
+	testSynced(t, synced, p("x", "y"))
+	// The test fails if d exists in the file system
+	memNotOnDisk(t, dn)
+}
+
+// testSynced tests the synced directories to equal w and resets them. The test fails if they differ.
+func testSynced(t *testing.T, synced *[]string, w ...string) {
+	// Panic if t is nil
+	if t == nil {
+		panic("nil pointer")
+	}
+	// The test fails if the synced directories differ
+	if fmt.Sprint(*synced) != fmt.Sprint(w) {
+		t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: "synced directories", Actual: fmt.Sprint(*synced), Want: fmt.Sprint(w)}))
+	}
+	*synced = nil
+}
+
+// TestDurabilityReadOnly tests TouchFile of an FS with DurabilityFile to sync a read-only file without write access.
+// The test fails if TouchFile returns an error or if the file is not synced. The test is skipped on Windows, since
+// syncing a file on Windows requires write access.
+func TestDurabilityReadOnly(t *testing.T) {
+	// Skip the test on Windows
+	if runtime.GOOS == "windows" {
+		t.Skip("syncing a file requires write access on Windows")
+	}
+	// Create FS with DurabilityFile
+	fsys, dn, files, _, _ := syncFS(t, tsfio.DurabilityFile)
+	fn := tsfio.Filename(filepath.Join(string(dn), "a"))
+	// Write read-only file a
+	memTree(t, fsys, dn, map[string]string{"a": testcase})
+	if e := fsys.Backend.Chmod(string(fn), 0444); e != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "Chmod", Fn: string(fn), Err: e}))
+	}
+	*files = 0
+	// Refuse to open files without write permission for writing
+	fsys.Backend = readOnlyBackend{syncBackend: fsys.Backend.(syncBackend)}
+	// The test fails if TouchFile returns an error
+	if e := fsys.TouchFile(fn); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "TouchFile", Fn: string(fn), Err: e}))
+	}
+	// The test fails if a is not synced
+	if *files != 1 {
+		t.Error(tserr.Equal(&tserr.EqualArgs{Var: "synced files", Actual: int64(*files), Want: 1}))
+	}
+	// The test fails if d exists in the file system
+	memNotOnDisk(t, dn)
+}
+
+// TestSetDurability tests SetDurability to set the package durability used by an FS without Durability.
+// The test fails if the previous durability differs or if the FS does not sync the file.
+func TestSetDurability(t *testing.T) {
+	// Create FS without durability
+	fsys, dn, files, _, _ := syncFS(t, 0)
+	fn := tsfio.Filename(filepath.Join(string(dn), "a"))
+	// Set the package durability to DurabilityFile. The test fails if the previous durability is not DurabilityNone.
+	if d := tsfio.SetDurability(tsfio.DurabilityFile); d != tsfio.DurabilityNone {
+		t.Error(tserr.Equal(&tserr.EqualArgs{Var: "previous durability", Actual: int64(d), Want: int64(tsfio.DurabilityNone)}))
+	}
+	// Write file a
+	if e := fsys.WriteStr(fn, testcase); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "WriteStr", Fn: string(fn), Err: e}))
+	}
+	// Restore the package durability. The test fails if the previous durability is not DurabilityFile.
+	if d := tsfio.SetDurability(0); d != tsfio.DurabilityFile {
+		t.Error(tserr.Equal(&tserr.EqualArgs{Var: "previous durability", Actual: int64(d), Want: int64(tsfio.DurabilityFile)}))
+	}
+	// The test fails if a is not synced
+	if *files != 1 {
+		t.Error(tserr.Equal(&tserr.EqualArgs{Var: "synced files", Actual: int64(*files), Want: 1}))
+	}
+	// The test fails if d exists in the file system
+	memNotOnDisk(t, dn)
+}
+
+// TestSyncDir tests SyncDir to sync a directory of the file system and to return an error for a directory,
+// which does not exist, and a file. The test fails if SyncDir returns an error for the directory or nil otherwise.
+func TestSyncDir(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write file a to d
+	writeTree(t, d, map[string]string{"a": testcase})
+	// The test fails if SyncDir returns an error
+	if e := tsfio.SyncDir(d); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "SyncDir", Fn: string(d), Err: e}))
+	}
+	// The test fails if SyncDir returns nil
+	for _, n := range []string{"a", "b"} {
+		if e := tsfio.SyncDir(tsfio.Directory(filepath.Join(string(d), n))); e == nil {
+			t.Error(tserr.NilFailed("SyncDir of " + n))
+		}
+	}
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestSyncDirStatErr tests SyncDir and AppendFiles to return the error of Stat, if Stat fails after the checks,
+// instead of ErrNotExist. The test fails if an error is nil, matches ErrNotExist or does not match the error of Stat.
+func TestSyncDirStatErr(t *testing.T) {
+	// Create FS with a statBackend and Directory d
+	fsys, m, d := memFS(t)
+	b := statBackend{MemBackend: m, calls: new(int), budget: new(int)}
+	*b.budget = -1
+	fsys.Backend = b
+	// Write file a to d
+	fa, fi := tsfio.Filename(filepath.Join(string(d), "a")), tsfio.Filename(filepath.Join(string(d), "i"))
+	if e := fsys.WriteStr(fi, testcase); e != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "WriteStr", Fn: string(fi), Err: e}))
+	}
+	for _, c := range []struct {
+		name  string
+		check func() error
+		f     func() error
+	}{
+		{"SyncDir", func() error { return fsys.CheckDir(d) }, func() error { return fsys.SyncDir(d) }},
+		{"AppendFiles", func() error { return errors.Join(fsys.CheckFile(fa), fsys.CheckFile(fi)) }, func() error { return fsys.AppendFiles(fa, []tsfio.Filename{fi}) }},
+	} {
+		// Count the calls of Stat by the checks and let Stat fail afterwards
+		*b.calls, *b.budget = 0, -1
+		if e := c.check(); e != nil {
+			t.Fatal(tserr.Op(&tserr.OpArgs{Op: "check of " + c.name, Fn: string(d), Err: e}))
+		}
+		*b.budget, *b.calls = *b.calls, 0
+		// The test fails if the error is nil, matches ErrNotExist or does not match the error of Stat
+		if e := c.f(); (e == nil) || errors.Is(e, tsfio.ErrNotExist) || !errors.Is(e, fs.ErrPermission) {
+			t.Error(tserr.Return(&tserr.ReturnArgs{Op: c.name, Actual: fmt.Sprint(e), Want: fs.ErrPermission.Error()}))
+		}
+	}
+}
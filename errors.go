@@ -0,0 +1,77 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio
+
+// Import standard library packages and tserr
+import (
+	"errors" // errors
+	"io/fs"  // fs
+
+	"github.com/thorstenrie/tserr" // tserr
+)
+
+// Sentinel errors of tsfio. The errors returned by tsfio functions keep the tserr JSON format, but can be tested
+// with errors.Is for the sentinel errors, e.g.,
+//
+//	if errors.Is(err, tsfio.ErrBlocked) {
+//		// fn is blocked by the policy
+//	}
+//
+// ErrNotExist is fs.ErrNotExist, so errors of a Backend reporting a file or directory, which does not exist, match
+// ErrNotExist as well.
+var (
+	ErrBlocked    = errors.New("blocked by policy")            // ErrBlocked is matched, if a file or directory is blocked by the policy
+	ErrNotExist   = fs.ErrNotExist                             // ErrNotExist is matched, if a file or directory does not exist
+	ErrNotRegular = errors.New("not a regular file")           // ErrNotRegular is matched, if a file is not a regular file
+	ErrNotDir     = errors.New("not a directory")              // ErrNotDir is matched, if a directory is not a directory
+	ErrEmptyName  = errors.New("empty file or directory name") // ErrEmptyName is matched, if a filename or directory name is empty
+	ErrTooLarge   = errors.New("file too large")               // ErrTooLarge is matched, if a file exceeds the maximum read size
+)
+
+// A sentinelError is an error of tserr, which matches a sentinel error with errors.Is. Its message is the message
+// of the tserr error.
+type sentinelError struct {
+	err error // err holds the error of tserr
+	is  error // is holds the sentinel error
+}
+
+// Error returns the message of the tserr error.
+func (e *sentinelError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the tserr error and the sentinel error.
+func (e *sentinelError) Unwrap() []error {
+	return []error{e.err, e.is}
+}
+
+// errBlocked returns an error of tserr.Forbidden for s matching ErrBlocked.
+func errBlocked(s string) error {
+	return &sentinelError{err: tserr.Forbidden(s), is: ErrBlocked}
+}
+
+// errNotExist returns an error of tserr.NotExistent for s matching ErrNotExist.
+func errNotExist(s string) error {
+	return &sentinelError{err: tserr.NotExistent(s), is: ErrNotExist}
+}
+
+// errEmptyName returns an error of tserr.Empty for s matching ErrEmptyName.
+func errEmptyName(s string) error {
+	return &sentinelError{err: tserr.Empty(s), is: ErrEmptyName}
+}
+
+// errNotDir returns an error of tserr.TypeNotMatching for s, which is not a directory, matching ErrNotDir.
+func errNotDir(s string) error {
+	return &sentinelError{err: tserr.TypeNotMatching(&tserr.TypeNotMatchingArgs{Actual: s, Want: "directory"}), is: ErrNotDir}
+}
+
+// errNotRegular returns an error of tserr.TypeNotMatching for s, which is not of type want, matching ErrNotRegular.
+func errNotRegular(s, want string) error {
+	return &sentinelError{err: tserr.TypeNotMatching(&tserr.TypeNotMatchingArgs{Actual: s, Want: want}), is: ErrNotRegular}
+}
+
+// errTooLarge returns an error of tserr.Lower for file fn with size n exceeding limit bytes, matching ErrTooLarge.
+func errTooLarge(fn Filename, n, limit int64) error {
+	return &sentinelError{err: tserr.Lower(&tserr.LowerArgs{Var: "size of " + string(fn), Actual: n, HigherBound: limit + 1}), is: ErrTooLarge}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio_test
+
+// Import standard library packages as well as tserr and tsfio
+import (
+	"errors"        // errors
+	"path/filepath" // filepath
+	"strings"       // strings
+	"testing"       // testing
+
+	"github.com/thorstenrie/tserr" // tserr
+	"github.com/thorstenrie/tsfio" // tsfio
+)
+
+// sentinels holds the sentinel errors of tsfio
+var sentinels = []error{tsfio.ErrBlocked, tsfio.ErrNotExist, tsfio.ErrNotRegular, tsfio.ErrNotDir, tsfio.ErrEmptyName, tsfio.ErrTooLarge}
+
+// TestSentinelErrors tests the errors returned by tsfio functions to match the expected sentinel error with errors.Is,
+// to not match any other sentinel error and to keep the JSON format of tserr. The test fails if an error is nil,
+// does not match the expected sentinel error, matches another sentinel error or is not in the JSON format of tserr.
+func TestSentinelErrors(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write file a and directory b to d
+	writeTree(t, d, map[string]string{"a": testcase, "b/c": testcase})
+	fn := func(n string) tsfio.Filename { return tsfio.Filename(filepath.Join(string(d), n)) }
+	// Block directory b with the package policy
+	p := tsfio.DefaultPolicy()
+	p.BlockedDirs = append(p.BlockedDirs, tsfio.Directory(fn("b")))
+	defer tsfio.SetPolicy(tsfio.SetPolicy(p))
+	// Each function returns an error matching the sentinel error
+	for _, c := range []struct {
+		name string
+		is   error
+		f    func() error
+	}{
+		{"CheckFile of empty name", tsfio.ErrEmptyName, func() error { return tsfio.CheckFile("") }},
+		{"RemoveFile of empty name", tsfio.ErrEmptyName, func() error { return tsfio.RemoveFile("") }},
+		{"CheckFile of blocked file", tsfio.ErrBlocked, func() error { return tsfio.CheckFile(fn("b/c")) }},
+		{"RemoveFile of blocked file", tsfio.ErrBlocked, func() error { return tsfio.RemoveFile(fn("b/c")) }},
+		{"OpenFile of blocked file", tsfio.ErrBlocked, func() error { _, e := tsfio.OpenFile(fn("b/c")); return e }},
+		{"RemoveFile of missing file", tsfio.ErrNotExist, func() error { return tsfio.RemoveFile(fn("x")) }},
+		{"OpenFile in missing directory", tsfio.ErrNotExist, func() error { _, e := tsfio.OpenFile(fn("x/a")); return e }},
+		{"ReadFile of missing file", tsfio.ErrNotExist, func() error { _, e := tsfio.ReadFile(fn("x")); return e }},
+		{"ReadFileLimit of large file", tsfio.ErrTooLarge, func() error { _, e := tsfio.ReadFileLimit(fn("a"), 1); return e }},
+		{"CheckFile of directory", tsfio.ErrNotRegular, func() error { return tsfio.CheckFile(tsfio.Filename(d)) }},
+		{"RemoveFile of directory", tsfio.ErrNotRegular, func() error { return tsfio.RemoveFile(tsfio.Filename(d)) }},
+		{"CheckDir of file", tsfio.ErrNotDir, func() error { return tsfio.CheckDir(tsfio.Directory(fn("a"))) }},
+		{"RemoveDir of file", tsfio.ErrNotDir, func() error {
+			return tsfio.RemoveDir(tsfio.Directory(fn("a")), tsfio.RemoveOptions{})
+		}},
+	} {
+		e := c.f()
+		// The test fails if the error is nil
+		if e == nil {
+			t.Error(tserr.NilFailed(c.name))
+			continue
+		}
+		// The test fails if the error does not match the sentinel error or matches another sentinel error
+		for _, s := range sentinels {
+			if errors.Is(e, s) != (s == c.is) {
+				t.Error(tserr.Return(&tserr.ReturnArgs{Op: "errors.Is of " + c.name + " and " + s.Error(), Actual: e.Error(), Want: c.is.Error()}))
+			}
+		}
+		// The test fails if the error does not keep the JSON format of tserr
+		if !strings.HasPrefix(e.Error(), `{"error":`) {
+			t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: "error of " + c.name, Actual: e.Error(), Want: "tserr JSON format"}))
+		}
+	}
+	// Remove d
+	rmAll(t, d)
+}
@@ -0,0 +1,9 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio
+
+// RangeAppends returns the number of appends copied with copy_file_range for tests in package tsfio_test
+func RangeAppends() int64 {
+	return rangeAppends.Load()
+}
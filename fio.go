@@ -4,6 +4,10 @@
 // functions for file input output operations, e.g., appending one file to another file.
 // Also, file input output operations on Linux and Windows system directories or
 // files are blocked (see inval_unix.go and inval_win.go) and an error is returned.
+// Paths are checked as absolute paths and with symbolic links evaluated. Blocked directories
+// are matched on path component boundaries.
+// The blocked directories and files are defined by the package policy, which can be replaced
+// by a custom Policy with SetPolicy.
 // All operations expect a directory or a regular file, return an error otherwise.
 // Default flags and file mode is used when opening files, creating files or directories
 // and when writing to files (with exceptions documented in the function descriptions)
@@ -14,15 +18,53 @@
 //   - File mode and permission bits are 0644.
 //   - Directory mode and permissions bits are 0755.
 //
+// With OpenFileWith, files are opened with OpenOptions instead, e.g., read-only or without creating them.
+//
+// Text files are read and written line by line with ReadLines, WriteLines, AppendLines and a LineReader.
+//
+// Ranges of a file are read with ReadAt and ReadTail and changed in place with WriteAt. The ranges are checked
+// against the size of the file.
+//
+// ReadFileLimit reads a file only up to a maximum size. A package maximum for ReadFile is set with SetMaxReadSize
+// or per FS with MaxRead. Files exceeding the maximum, also while being read, return an error.
+//
+// Functions with the suffix Ctx, e.g., ReadFileCtx, WriteStrCtx, AppendFileCtx and CopyFileCtx, accept a
+// context. They stream the contents in chunks, check the context for cancellation before each chunk and
+// remove partial output, if the context is canceled.
+//
+// Open files can be locked with advisory locks by LockFile and TryLockFile. WriteStrLocked and AppendFileLocked
+// hold an exclusive lock while writing, so concurrent writers in several processes do not interleave.
+//
+// Files are replaced atomically by WriteAtomic, WriteAtomicStr, WriteSingleStr and CreateGoldenFile.
+// The data is written to a temporary file in the same directory, synced to disk and renamed to the target file.
+// Other writing functions sync files and their directories as requested by a Durability, which is set for the
+// package with SetDurability or per FS. SyncDir commits the directory entries of a directory to stable storage.
+// Several files are changed together by a Transaction. Its changes are staged in temporary files and
+// committed with a journal, so an interrupted Commit is rolled forward or back by the next OpenTransaction.
+//
+// The package functions use a default FS. Different settings can be used with an FS, which holds
+// the file mode, directory mode, open flags, policy, root directory and the Backend for storage. Its methods
+// mirror the package functions for file input output. OSBackend uses the operating system and MemBackend
+// holds all files and directories in memory, e.g., for unit tests without a file system. With DirFS, a
+// directory is provided as read-only fs.FS of the standard library package io/fs.
+//
 // If an API call is not successful, a tserr error in JSON format is returned.
+// The errors match the sentinel errors ErrBlocked, ErrNotExist, ErrNotRegular, ErrNotDir, ErrEmptyName and
+// ErrTooLarge with errors.Is.
 //
 // With Printable functions, non-printable runes can be removed from strings and runes.
-// With golden file functions, golden files can be created and test cases evaluated.
+// With golden file functions, golden files can be created and test cases evaluated, also from an fs.FS.
 // Golden files can be used in unit tests. The expected output is stored in a golden file.
 // The actual output data will be compared with the golden file. The test fails if there
-// is a difference in actual output and golden file. With normalization functions, new lines
-// in byte slices or strings are normalized to the Unix representation of a new line as
-// line feed LF (0x0A). Therefore, Windows new lines CR LF (0x0D 0x0A) are replaced by Unix
+// is a difference in actual output and golden file. The error holds a unified diff of the golden file and the
+// test data, which is also computed for two strings with Diff. AssertGolden evaluates the golden file of a test
+// named after the test and reports a difference with t.Errorf. A Golden defines a different directory, file type
+// and permission bits of golden files. Its Scrubbers mask volatile content of test data, e.g., timestamps and UUIDs,
+// before comparing and writing it. In update mode, enabled with the environment variable
+// TSFIO_UPDATE_GOLDEN or a test flag -update, EvalGoldenFile replaces differing golden files instead.
+//
+// With normalization functions, new lines in byte slices or strings are normalized to the Unix representation
+// of a new line as line feed LF (0x0A). Therefore, Windows new lines CR LF (0x0D 0x0A) are replaced by Unix
 // new lines LF (0x0A). Also, Mac new lines CR (0x0D) are replaced by Unix new lines LF (0x0A).
 //
 // Copyright (c) 2023 thorstenrie.
@@ -32,10 +74,15 @@ package tsfio
 
 // Import standard library packages and tserr
 import (
+	"bytes"         // bytes
+	"context"       // context
 	"fmt"           // fmt
+	"io"            // io
 	"io/fs"         // fs
 	"os"            // os
 	"path/filepath" // filepath
+	"strings"       // strings
+	"sync/atomic"   // atomic
 	"time"          // time
 
 	"github.com/thorstenrie/tserr" // tserr
@@ -60,25 +107,138 @@ const (
 // If opened successfully, the file is returned and can be used for
 // file input output and error is nil.
 func OpenFile(fn Filename) (*os.File, error) {
+	f, err := std.OpenFile(fn)
+	// Return nil and the error, if OpenFile fails
+	if err != nil {
+		return nil, err
+	}
+	// The default FS uses OSBackend, which returns an *os.File
+	return f.(*os.File), nil
+}
+
+// OpenFile performs the package function OpenFile with the settings of fsys. It returns
+// the File opened by the Backend of fsys.
+func (fsys *FS) OpenFile(fn Filename) (File, error) {
+	return fsys.openFile(fn, fsys.flags(), fsys.fileMode())
+}
+
+// OpenOptions holds the options for opening files with OpenFileWith. The zero value opens a file read-write with the
+// default permission bits and creates it, if it does not exist. Other than OpenFile, the file is not opened for
+// appending, so it can be written at an offset, e.g., with WriteAt. Set Append to open it like OpenFile.
+type OpenOptions struct {
+	ReadOnly  bool        // ReadOnly opens the file read-only. The file is not created, if it does not exist.
+	WriteOnly bool        // WriteOnly opens the file write-only
+	Append    bool        // Append opens the file for appending, so each write is at its end (os.O_APPEND)
+	Truncate  bool        // Truncate truncates the file to size zero, if it exists
+	Exclusive bool        // Exclusive creates the file and returns an error, if it already exists (os.O_EXCL)
+	NoCreate  bool        // NoCreate returns an error, if the file does not exist, instead of creating it
+	Sync      bool        // Sync opens the file for synchronous I/O (os.O_SYNC)
+	Perm      fs.FileMode // Perm holds the permission bits of a created file. If zero, the default permission bits are used.
+}
+
+// OpenFileWith opens the named file fn with the options o. Other than OpenFile, it can open a file read-only
+// or write-only, open it with or without appending, truncate it, create it exclusively, open it without creating it,
+// create it with custom permission bits and open it for synchronous I/O. The Flags of the FS are not used. Like
+// OpenFile, fn is checked with CheckFile and OpenFileWith returns an error, if the directory to the file does not
+// exist. It returns an error, if ReadOnly and WriteOnly are both set, if ReadOnly is combined with Append, Truncate
+// or Exclusive, or if Exclusive is combined with NoCreate. If opened successfully, the file is returned and error is nil.
+func OpenFileWith(fn Filename, o OpenOptions) (*os.File, error) {
+	f, err := std.OpenFileWith(fn, o)
+	// Return nil and the error, if OpenFileWith fails
+	if err != nil {
+		return nil, err
+	}
+	// The default FS uses OSBackend, which returns an *os.File
+	return f.(*os.File), nil
+}
+
+// OpenFileWith performs the package function OpenFileWith with the settings of fsys. It returns
+// the File opened by the Backend of fsys.
+func (fsys *FS) OpenFileWith(fn Filename, o OpenOptions) (File, error) {
+	// Retrieve the flags for options o
+	flag, err := o.flags()
+	if err != nil {
+		return nil, err
+	}
+	// Use the default permission bits, if Perm is not set
+	perm := o.Perm.Perm()
+	if perm == 0 {
+		perm = fsys.fileMode()
+	}
+	// Open fn with flag and permission bits perm
+	return fsys.openFile(fn, flag, perm)
+}
+
+// flags returns the flags for opening a file with the options o. It returns an error, if options are conflicting.
+func (o OpenOptions) flags() (int, error) {
+	// Return an error for conflicting options
+	switch {
+	case o.ReadOnly && o.WriteOnly:
+		return 0, tserr.Forbidden("opening a file read-only and write-only")
+	case o.ReadOnly && o.Append:
+		return 0, tserr.Forbidden("appending to a file opened read-only")
+	case o.ReadOnly && (o.Truncate || o.Exclusive):
+		return 0, tserr.Forbidden("truncating or creating a file opened read-only")
+	case o.Exclusive && o.NoCreate:
+		return 0, tserr.Forbidden("creating a file exclusively without creating it")
+	}
+	// A read-only file is neither truncated nor created
+	if o.ReadOnly {
+		flag := os.O_RDONLY
+		if o.Sync {
+			flag |= os.O_SYNC
+		}
+		return flag, nil
+	}
+	// Set the access mode
+	flag := os.O_RDWR
+	if o.WriteOnly {
+		flag = os.O_WRONLY
+	}
+	// Set the optional flags
+	if o.Append {
+		flag |= os.O_APPEND
+	}
+	if !o.NoCreate {
+		flag |= os.O_CREATE
+	}
+	if o.Truncate {
+		flag |= os.O_TRUNC
+	}
+	if o.Exclusive {
+		flag |= os.O_EXCL
+	}
+	if o.Sync {
+		flag |= os.O_SYNC
+	}
+	// Return the flags
+	return flag, nil
+}
+
+// openFile opens fn with flag and permission bits perm. It checks fn with CheckFile and returns an error, if the
+// directory to the file does not exist. It returns the File opened by the Backend of fsys or an error, if any.
+func (fsys *FS) openFile(fn Filename, flag int, perm fs.FileMode) (File, error) {
+	// Resolve fn against the root directory of fsys
+	fn = rooted(fsys, fn)
 	// Return nil and error in case fn contains a blocked directory or filename
-	if e := CheckFile(fn); e != nil {
+	if e := fsys.CheckFile(fn); e != nil {
 		return nil, tserr.Check(&tserr.CheckArgs{F: string(fn), Err: e})
 	}
 	// Get directory of filename, if it contains a path
 	if dn := Directory(filepath.Dir(string(fn))); dn != "" {
 		// Check if directory exists
-		b, err := ExistsDir(dn)
+		b, err := fsys.ExistsDir(dn)
 		// Return nil and an error if ExistsDir fails
 		if err != nil {
 			return nil, tserr.Op(&tserr.OpArgs{Op: "ExistsDir", Fn: string(dn), Err: err})
 		}
 		// Return nil and an error if the directory does not exist
 		if !b {
-			return nil, tserr.NotExistent("directory " + string(dn))
+			return nil, errNotExist("directory " + string(dn))
 		}
 	}
-	// Open file with default flags and permission bits
-	f, err := os.OpenFile(string(fn), flags, fperm)
+	// Open file with flag and permission bits
+	f, err := fsys.backend().OpenFile(string(fn), flag, perm)
 	// In case of an error, return nil and error
 	if err != nil {
 		return nil, tserr.Op(&tserr.OpArgs{Op: "OpenFile", Fn: string(fn), Err: err})
@@ -90,6 +250,15 @@ func OpenFile(fn Filename) (*os.File, error) {
 // CloseFile closes f and f is unusable for file input output. An error is returned
 // if f has already been closed.
 func CloseFile(f *os.File) error {
+	// Pass a nil File instead of a nil *os.File
+	if f == nil {
+		return std.CloseFile(nil)
+	}
+	return std.CloseFile(f)
+}
+
+// CloseFile performs the package function CloseFile with the settings of fsys.
+func (fsys *FS) CloseFile(f File) error {
 	// Return error in case f is nil
 	if f == nil {
 		return tserr.NilPtr()
@@ -107,60 +276,238 @@ func CloseFile(f *os.File) error {
 // fn exists already, the string will be appended to the file. If fn does not exist,
 // it will create fn.
 func WriteStr(fn Filename, s string) error {
+	return std.WriteStr(fn, s)
+}
+
+// WriteStr performs the package function WriteStr with the settings of fsys.
+func (fsys *FS) WriteStr(fn Filename, s string) error {
+	return fsys.WriteStrCtx(context.Background(), fn, s)
+}
+
+// WriteStrCtx writes string s to file fn as done by WriteStr. The string is written in chunks and ctx is checked
+// for cancellation before each chunk. If ctx is canceled, the partially written string is removed: fn is truncated
+// to its previous size or removed, if it did not exist before. It returns an error, if any, also if ctx is canceled.
+func WriteStrCtx(ctx context.Context, fn Filename, s string) error {
+	return std.WriteStrCtx(ctx, fn, s)
+}
+
+// WriteStrCtx performs the package function WriteStrCtx with the settings of fsys.
+func (fsys *FS) WriteStrCtx(ctx context.Context, fn Filename, s string) error {
+	_, err := fsys.writeFrom(ctx, fn, strings.NewReader(s))
+	return err
+}
+
+// WriteBytes writes byte slice b to file fn as done by WriteStr. If fn exists already, b is appended to the
+// file. If fn does not exist, it is created. It returns the number of bytes written and an error, if any.
+func WriteBytes(fn Filename, b []byte) (int, error) {
+	return std.WriteBytes(fn, b)
+}
+
+// WriteBytes performs the package function WriteBytes with the settings of fsys.
+func (fsys *FS) WriteBytes(fn Filename, b []byte) (int, error) {
+	n, err := fsys.writeFrom(context.Background(), fn, bytes.NewReader(b))
+	return int(n), err
+}
+
+// WriteFrom writes the contents of r until io.EOF to file fn as done by WriteStr. The contents are streamed, so
+// they are not held in memory. If fn exists already, the contents are appended to the file. If fn does not exist,
+// it is created. If r returns an error, the contents read before are written to fn. It returns the number of bytes
+// written and an error, if any.
+func WriteFrom(fn Filename, r io.Reader) (int64, error) {
+	return std.WriteFrom(fn, r)
+}
+
+// WriteFrom performs the package function WriteFrom with the settings of fsys.
+func (fsys *FS) WriteFrom(fn Filename, r io.Reader) (int64, error) {
+	// Return an error in case r is nil
+	if r == nil {
+		return 0, tserr.NilPtr()
+	}
+	return fsys.writeFrom(context.Background(), fn, r)
+}
+
+// writeFrom appends the contents of r to file fn with copyCtx. If ctx is canceled, the partially written contents
+// are removed as described by WriteStrCtx. It returns the number of bytes written and an error, if any.
+func (fsys *FS) writeFrom(ctx context.Context, fn Filename, r io.Reader) (int64, error) {
+	// Resolve fn against the root directory of fsys
+	fn = rooted(fsys, fn)
 	// Return an error in case fn contains a blocked directory or filename
-	if e := CheckFile(fn); e != nil {
-		return tserr.Check(&tserr.CheckArgs{F: string(fn), Err: e})
+	if e := fsys.CheckFile(fn); e != nil {
+		return 0, tserr.Check(&tserr.CheckArgs{F: string(fn), Err: e})
 	}
+	// Retrieve the size of fn before writing
+	u := fsys.appendUndo(fn)
 	// Open file fn with default flags and permission bits. If the file does
 	// not exist, it is created.
-	f, err := OpenFile(fn)
+	f, err := fsys.OpenFile(fn)
 	// Return error, if OpenFile fails
 	if err != nil {
-		return tserr.Op(&tserr.OpArgs{Op: "OpenFile", Fn: string(fn), Err: err})
+		return 0, tserr.Op(&tserr.OpArgs{Op: "OpenFile", Fn: string(fn), Err: err})
+	}
+	// Write the contents of r to file fn
+	n, err := copyCtx(ctx, f, r)
+	if err != nil {
+		// On error, close file, remove the partial output if ctx is canceled and return error
+		u.close(ctx, f)
+		if ctx.Err() != nil {
+			n = 0
+		}
+		return n, tserr.Op(&tserr.OpArgs{Op: "write to", Fn: string(fn), Err: err})
+	}
+	// Sync and close file as requested by the durability of fsys
+	if e := fsys.closeDurable(f); e != nil {
+		return n, e
+	}
+	// No error occurred, return the number of bytes written
+	return n, nil
+}
+
+// An undo holds the size of a file before appending to it, so appended output can be removed on cancellation
+type undo struct {
+	fsys    *FS      // fsys holds the FS of the file
+	fn      Filename // fn holds the filename
+	existed bool     // existed is true, if the file existed before appending
+	size    int64    // size holds the size of the file before appending
+}
+
+// appendUndo returns the undo of fn with its current size.
+func (fsys *FS) appendUndo(fn Filename) *undo {
+	u := &undo{fsys: fsys, fn: fn}
+	// Retrieve the size of fn, if it exists
+	if fi, e := fsys.backend().Stat(string(fn)); e == nil {
+		u.existed, u.size = true, fi.Size()
+	}
+	return u
+}
+
+// close closes the open file f. If ctx is canceled, the appended output is removed: f is truncated to its size
+// before appending or removed, if it did not exist before. Errors are ignored, since the caller returns an error.
+func (u *undo) close(ctx context.Context, f File) {
+	// Close f only, if ctx is not canceled
+	if ctx.Err() == nil {
+		f.Close()
+		return
 	}
-	// Write string s to file fn
-	if _, e := f.WriteString(s); e != nil {
-		// On error, close file and return error
+	// Truncate f to its previous size, if it existed
+	if u.existed {
+		f.Truncate(u.size)
 		f.Close()
-		return tserr.Op(&tserr.OpArgs{Op: "write string to", Fn: string(fn), Err: e})
+		return
 	}
-	// Close file and return nil
+	// Otherwise, remove f after closing it
 	f.Close()
-	return nil
+	u.fsys.backend().Remove(string(u.fn))
 }
 
-// WriteSingleStr writes a single string s to file fn. If fn exists, it is
-// truncated to size zero first. If it does not exist, it is created. It
-// returns an error, if any.
+// revert removes the output written to the closed file of u: the file is truncated to its size before writing or
+// removed, if it did not exist before. Errors are ignored, since the caller returns an error.
+func (u *undo) revert() {
+	// Truncate the file to its previous size, if it existed
+	if u.existed {
+		u.fsys.backend().Truncate(string(u.fn), u.size)
+		return
+	}
+	// Otherwise, remove the file
+	u.fsys.backend().Remove(string(u.fn))
+}
+
+// WriteSingleStr writes a single string s to file fn. If fn exists, its contents are
+// replaced by s. If it does not exist, it is created. The file is replaced atomically
+// with WriteAtomicStr, so a concurrent reader or a reader after a crash never sees an empty
+// or partially written file. It returns an error, if any.
 func WriteSingleStr(fn Filename, s string) error {
+	return std.WriteSingleStr(fn, s)
+}
+
+// WriteSingleStr performs the package function WriteSingleStr with the settings of fsys.
+func (fsys *FS) WriteSingleStr(fn Filename, s string) error {
+	return fsys.WriteSingleStrCtx(context.Background(), fn, s)
+}
+
+// WriteSingleStrCtx writes a single string s to file fn as done by WriteSingleStr. The string is written in
+// chunks with WriteAtomicCtx and ctx is checked for cancellation before each chunk. If ctx is canceled, fn remains
+// unchanged. It returns an error, if any, also if ctx is canceled.
+func WriteSingleStrCtx(ctx context.Context, fn Filename, s string) error {
+	return std.WriteSingleStrCtx(ctx, fn, s)
+}
+
+// WriteSingleStrCtx performs the package function WriteSingleStrCtx with the settings of fsys.
+func (fsys *FS) WriteSingleStrCtx(ctx context.Context, fn Filename, s string) error {
+	// Resolve fn against the root directory of fsys
+	fn = rooted(fsys, fn)
 	// Return an error in case fn contains a blocked directory or filename
-	if e := CheckFile(fn); e != nil {
+	if e := fsys.CheckFile(fn); e != nil {
 		return tserr.Check(&tserr.CheckArgs{F: string(fn), Err: e})
 	}
-	// Truncate fn to size zero with ResetFile
-	if e := ResetFile(fn); e != nil {
-		// Return error if ResetFile fails
-		return tserr.Op(&tserr.OpArgs{Op: "ResetFile", Fn: string(fn), Err: e})
-	}
-	// Write string s to fn with WriteStr
-	if e := WriteStr(fn, s); e != nil {
-		// Return error if WriteStr fails
+	// Atomically replace fn with string s with WriteAtomicCtx
+	if e := fsys.WriteAtomicCtx(ctx, fn, []byte(s)); e != nil {
+		// Return error if WriteAtomicCtx fails
 		return tserr.Op(&tserr.OpArgs{Op: fmt.Sprintf("write string %v to", s), Fn: string(fn), Err: e})
 	}
 	// No error occurred, return nil
 	return nil
 }
 
+// WriteSingleBytes writes a single byte slice b to file fn as done by WriteSingleStr. If fn exists, its contents
+// are replaced by b atomically. If it does not exist, it is created. It returns the number of bytes written and
+// an error, if any.
+func WriteSingleBytes(fn Filename, b []byte) (int, error) {
+	return std.WriteSingleBytes(fn, b)
+}
+
+// WriteSingleBytes performs the package function WriteSingleBytes with the settings of fsys.
+func (fsys *FS) WriteSingleBytes(fn Filename, b []byte) (int, error) {
+	n, err := fsys.WriteSingleFrom(fn, bytes.NewReader(b))
+	return int(n), err
+}
+
+// WriteSingleFrom writes the contents of r until io.EOF to file fn as done by WriteSingleStr. The contents are
+// streamed to a temporary file, which replaces fn atomically. If fn exists, its contents are replaced. If it does
+// not exist, it is created. If r returns an error, fn remains unchanged. It returns the number of bytes written
+// and an error, if any.
+func WriteSingleFrom(fn Filename, r io.Reader) (int64, error) {
+	return std.WriteSingleFrom(fn, r)
+}
+
+// WriteSingleFrom performs the package function WriteSingleFrom with the settings of fsys.
+func (fsys *FS) WriteSingleFrom(fn Filename, r io.Reader) (int64, error) {
+	// Return an error in case r is nil
+	if r == nil {
+		return 0, tserr.NilPtr()
+	}
+	// Resolve fn against the root directory of fsys
+	fn = rooted(fsys, fn)
+	// Return an error in case fn contains a blocked directory or filename
+	if e := fsys.CheckFile(fn); e != nil {
+		return 0, tserr.Check(&tserr.CheckArgs{F: string(fn), Err: e})
+	}
+	// Atomically replace fn with the contents of r
+	n, err := fsys.writeAtomic(context.Background(), fn, r)
+	if err != nil {
+		// Return error if writeAtomic fails
+		return 0, tserr.Op(&tserr.OpArgs{Op: "write to", Fn: string(fn), Err: err})
+	}
+	// No error occurred, return the number of bytes written
+	return n, nil
+}
+
 // TouchFile updates the access and modification times of filename fn to the
 // current time. If fn does not exist, it is created as an empty file. It returns
 // an error, if any.
 func TouchFile(fn Filename) error {
+	return std.TouchFile(fn)
+}
+
+// TouchFile performs the package function TouchFile with the settings of fsys.
+func (fsys *FS) TouchFile(fn Filename) error {
+	// Resolve fn against the root directory of fsys
+	fn = rooted(fsys, fn)
 	// Return an error in case fn contains a blocked directory or filename
-	if e := CheckFile(fn); e != nil {
+	if e := fsys.CheckFile(fn); e != nil {
 		return tserr.Check(&tserr.CheckArgs{F: string(fn), Err: e})
 	}
 	// Check if file fn exists
-	b, erre := ExistsFile(fn)
+	b, erre := fsys.ExistsFile(fn)
 	// Return error if ExistsFile fails
 	if erre != nil {
 		return tserr.Op(&tserr.OpArgs{Op: "ExistsFile", Fn: string(fn), Err: erre})
@@ -170,20 +517,24 @@ func TouchFile(fn Filename) error {
 		// Get current time
 		t := time.Now().Local()
 		// Update access and modification times of fn. Return error, if any.
-		if e := os.Chtimes(string(fn), t, t); e != nil {
+		if e := fsys.backend().Chtimes(string(fn), t, t); e != nil {
 			return tserr.Op(&tserr.OpArgs{Op: "Chtimes", Fn: string(fn), Err: e})
 		}
+		// Sync fn as requested by the durability of fsys
+		if e := fsys.syncName(fn); e != nil {
+			return e
+		}
 	} else {
 		// If file does not exist, then create fn with OpenFile.
-		f, erro := OpenFile(fn)
+		f, erro := fsys.OpenFile(fn)
 		// Return error if OpenFile fails.
 		if erro != nil {
 			return tserr.Op(&tserr.OpArgs{Op: "OpenFile", Fn: string(fn), Err: erro})
 		}
-		// Close fn
-		if e := f.Close(); e != nil {
-			// Return error, if Close fails.
-			return tserr.Op(&tserr.OpArgs{Op: "Close", Fn: string(fn), Err: e})
+		// Sync and close fn as requested by the durability of fsys
+		if e := fsys.closeDurable(f); e != nil {
+			// Return error, if closeDurable fails.
+			return e
 		}
 	}
 	// No error occurred and return nil
@@ -191,14 +542,38 @@ func TouchFile(fn Filename) error {
 }
 
 // ReadFile reads f and and returns it contents. It returns an error, if any. If a file
-// does not exist, it returns an error. If successful, error will be nil.
+// does not exist, it returns an error. If a maximum read size is set with SetMaxReadSize or
+// MaxRead of an FS, it returns an error for files exceeding it as done by ReadFileLimit.
+// If successful, error will be nil.
 func ReadFile(f Filename) ([]byte, error) {
+	return std.ReadFile(f)
+}
+
+// ReadFile performs the package function ReadFile with the settings of fsys.
+func (fsys *FS) ReadFile(f Filename) ([]byte, error) {
+	return fsys.ReadFileCtx(context.Background(), f)
+}
+
+// ReadFileCtx reads f and returns its contents as done by ReadFile. The contents are read in chunks and ctx is
+// checked for cancellation before each chunk. If ctx is canceled, it returns nil and an error.
+func ReadFileCtx(ctx context.Context, f Filename) ([]byte, error) {
+	return std.ReadFileCtx(ctx, f)
+}
+
+// ReadFileCtx performs the package function ReadFileCtx with the settings of fsys.
+func (fsys *FS) ReadFileCtx(ctx context.Context, f Filename) ([]byte, error) {
+	// Resolve f against the root directory of fsys
+	f = rooted(fsys, f)
 	// Return an error in case f contains a blocked directory or filename
-	if e := CheckFile(f); e != nil {
+	if e := fsys.CheckFile(f); e != nil {
 		return nil, tserr.Check(&tserr.CheckArgs{F: string(f), Err: e})
 	}
-	// Read f and return its contents
-	b, err := os.ReadFile(string(f))
+	// Read f in chunks, if ctx can be canceled or the size is limited
+	if m := fsys.maxRead(); (ctx.Done() != nil) || (m > 0) {
+		return fsys.readLimit(ctx, f, m)
+	}
+	// Otherwise, read f at once
+	b, err := fsys.backend().ReadFile(string(f))
 	// Return b as nil and the retrieved error, if ReadFile fails
 	if err != nil {
 		return nil, tserr.Op(&tserr.OpArgs{Op: "ReadFile", Fn: string(f), Err: err})
@@ -220,74 +595,294 @@ type Append struct {
 // fileA holds its original content extended by the contents of fileI, and fileI
 // remains as before. If fileA does not exist, it is created as empty file and as
 // result will hold the contents of fileI. If fileI does not exist, it returns
-// an error. AppendFile returns an error, if any.
+// an error. The contents of fileI are streamed, so large files are not read into
+// memory at once. On Linux, regular files are copied by the kernel with copy_file_range.
+// Concurrent writers to fileA should use AppendFileLocked. AppendFile returns an error, if any.
 func AppendFile(a *Append) error {
+	return std.AppendFile(a)
+}
+
+// AppendFile performs the package function AppendFile with the settings of fsys.
+func (fsys *FS) AppendFile(a *Append) error {
+	return fsys.AppendFileCtx(context.Background(), a)
+}
+
+// AppendFileCtx appends a file to another file as done by AppendFile. The contents of fileI are streamed in chunks
+// and ctx is checked for cancellation before each chunk. If ctx is canceled, the partially appended contents are
+// removed: fileA is truncated to its previous size or removed, if it did not exist before. It returns an error, if
+// any, also if ctx is canceled.
+func AppendFileCtx(ctx context.Context, a *Append) error {
+	return std.AppendFileCtx(ctx, a)
+}
+
+// AppendFileCtx performs the package function AppendFileCtx with the settings of fsys.
+func (fsys *FS) AppendFileCtx(ctx context.Context, a *Append) error {
 	// Return error if pointer a is nil.
 	if a == nil {
 		return fmt.Errorf("nil pointer")
 	}
+	// Resolve fileA and fileI against the root directory of fsys
+	a = &Append{FileA: rooted(fsys, a.FileA), FileI: rooted(fsys, a.FileI)}
 	// Return an error in case fileA contains a blocked directory or filename
-	if e := CheckFile(a.FileA); e != nil {
+	if e := fsys.CheckFile(a.FileA); e != nil {
 		return tserr.Check(&tserr.CheckArgs{F: string(a.FileA), Err: e})
 	}
 	// Return an error in case fileI contains a blocked directory or filename
-	if e := CheckFile(a.FileI); e != nil {
+	if e := fsys.CheckFile(a.FileI); e != nil {
 		return tserr.Check(&tserr.CheckArgs{F: string(a.FileI), Err: e})
 	}
+	// Retrieve the size of fileA before appending
+	u := fsys.appendUndo(a.FileA)
 	// Open fileA. If it does not exist, then create fileA as empty file.
-	f, erro := OpenFile(a.FileA)
+	f, erro := fsys.OpenFile(a.FileA)
 	// Return error, if any
 	if erro != nil {
 		return tserr.Op(&tserr.OpArgs{Op: "OpenFile", Fn: string(a.FileA), Err: erro})
 	}
-	// Read contents of fileI
-	out, errr := ReadFile(a.FileI)
+	// Append the contents of fileI to fileA
+	if e := fsys.appendFile(ctx, f, a, false); e != nil {
+		// If appendFile fails, close fileA, remove the partial output if ctx is canceled and return error
+		u.close(ctx, f)
+		return e
+	}
+	// Sync and close fileA as requested by the durability of fsys
+	return fsys.closeDurable(f)
+}
+
+// copyBufSize holds the size of the buffer for streaming the contents of one file to another file
+const copyBufSize int = 32 * 1024
+
+// A ctxReader reads from r and returns the error of ctx, if ctx is canceled
+type ctxReader struct {
+	ctx context.Context // ctx holds the context checked before each read
+	r   io.Reader       // r holds the underlying reader
+}
+
+// Read returns the error of the context of r, if it is canceled. Otherwise, it reads from the underlying reader.
+func (r *ctxReader) Read(p []byte) (int, error) {
+	// Return the error of the context, if it is canceled
+	if e := r.ctx.Err(); e != nil {
+		return 0, e
+	}
+	return r.r.Read(p)
+}
+
+// copyCtx copies src to dst in chunks of at most copyBufSize and checks ctx for cancellation before each chunk.
+// If ctx can never be canceled, e.g., context.Background(), src is copied without checks and an io.WriterTo
+// implementation of src is used, e.g., to write a string with a single write. It returns the number of bytes
+// copied and an error, if any.
+func copyCtx(ctx context.Context, dst io.Writer, src io.Reader) (int64, error) {
+	// Copy without checks, if ctx can never be canceled. Wrapping dst hides an io.ReaderFrom implementation of dst,
+	// which would not use the buffer.
+	if ctx.Done() == nil {
+		return io.CopyBuffer(struct{ io.Writer }{dst}, src, make([]byte, copyBufSize))
+	}
+	// Copy in chunks with cancellation checks
+	return io.CopyBuffer(struct{ io.Writer }{dst}, &ctxReader{ctx: ctx, r: src}, make([]byte, copyBufSize))
+}
+
+// rangeAppends counts the appends copied with appendRange, e.g., to test that copy_file_range is used
+var rangeAppends atomic.Int64
+
+// appendFile writes the contents of fileI of a to the open fileA f. The contents are streamed with copyCtx, so fileI
+// is not held in memory. If ctx can never be canceled, the contents are copied by the kernel with copy_file_range on
+// Linux, if both are regular files of the operating system. Since copy_file_range does not support O_APPEND, it writes
+// at the end of f opened again while holding an exclusive lock, unless locked is true, i.e., f is already locked with
+// LockFile. Only the contents of a regular fileI at the start are appended, so fileI can be fileA. It returns an error, if any.
+func (fsys *FS) appendFile(ctx context.Context, f File, a *Append, locked bool) error {
+	// Open fileI read-only
+	in, err := fsys.backend().OpenFile(string(a.FileI), os.O_RDONLY, 0)
 	// Return error, if any
-	if errr != nil {
-		return tserr.Op(&tserr.OpArgs{Op: "ReadFile", Fn: string(a.FileI), Err: errr})
+	if err != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "open", Fn: string(a.FileI), Err: err})
 	}
-	// Write contents of fileI to fileA
-	if _, e := f.Write(out); e != nil {
-		// If Write filas, close fileA and return error
-		f.Close()
-		return tserr.Op(&tserr.OpArgs{Op: fmt.Sprintf("append file %v to", a.FileI), Fn: string(a.FileA), Err: e})
+	defer in.Close()
+	// Retrieve FileInfo of fileI
+	fi, err := in.Stat()
+	if err != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "Stat", Fn: string(a.FileI), Err: err})
 	}
-	// Close fileA
-	if e := f.Close(); e != nil {
-		// If Close fails, return error
-		return tserr.Op(&tserr.OpArgs{Op: "Close", Fn: string(a.FileA), Err: e})
+	// Limit the contents to the size of a regular fileI
+	n, r := int64(-1), io.Reader(in)
+	if fi.Mode().IsRegular() {
+		n = fi.Size()
+		r = io.LimitReader(in, n)
+	}
+	// Copy contents of fileI to fileA with copy_file_range, if possible and ctx can never be canceled
+	ok := false
+	if ctx.Done() == nil {
+		if ok, err = appendRange(f, in, n, fsys.flags()&os.O_APPEND != 0, locked); ok {
+			rangeAppends.Add(1)
+		}
+	}
+	if !ok {
+		// Otherwise, stream contents of fileI to fileA
+		_, err = copyCtx(ctx, f, r)
+	}
+	// Return error, if copying fails
+	if err != nil {
+		return tserr.Op(&tserr.OpArgs{Op: fmt.Sprintf("append file %v to", a.FileI), Fn: string(a.FileA), Err: err})
 	}
 	// No error occurred, return nil
 	return nil
 }
 
+// AppendOptions holds the options for appending files with AppendFilesWith. The zero value appends
+// the files without separator.
+type AppendOptions struct {
+	Separator string // Separator is written between the contents of two consecutive files
+}
+
+// AppendFiles appends the files in fileI in the given order to fileA. It is a shortcut for AppendFilesWith
+// with the zero value of AppendOptions. It returns an error, if any.
+func AppendFiles(fileA Filename, fileI []Filename) error {
+	return std.AppendFiles(fileA, fileI)
+}
+
+// AppendFiles performs the package function AppendFiles with the settings of fsys.
+func (fsys *FS) AppendFiles(fileA Filename, fileI []Filename) error {
+	return fsys.AppendFilesWith(fileA, fileI, AppendOptions{})
+}
+
+// AppendFilesWith appends the files in fileI in the given order to fileA as done by AppendFile. Separator
+// o.Separator is written between the contents of two consecutive files. If fileA does not exist, it is created.
+// All files are checked before fileA is opened. It returns an error, if fileI is empty, if any of the files
+// in fileI does not exist, or if any other error occurs. If an error occurs while appending, fileA holds
+// the contents appended up to the error.
+func AppendFilesWith(fileA Filename, fileI []Filename, o AppendOptions) error {
+	return std.AppendFilesWith(fileA, fileI, o)
+}
+
+// AppendFilesWith performs the package function AppendFilesWith with the settings of fsys.
+func (fsys *FS) AppendFilesWith(fileA Filename, fileI []Filename, o AppendOptions) error {
+	return fsys.AppendFilesWithCtx(context.Background(), fileA, fileI, o)
+}
+
+// AppendFilesCtx appends the files in fileI in the given order to fileA as done by AppendFiles. It is a shortcut for
+// AppendFilesWithCtx with the zero value of AppendOptions. It returns an error, if any, also if ctx is canceled.
+func AppendFilesCtx(ctx context.Context, fileA Filename, fileI []Filename) error {
+	return std.AppendFilesCtx(ctx, fileA, fileI)
+}
+
+// AppendFilesCtx performs the package function AppendFilesCtx with the settings of fsys.
+func (fsys *FS) AppendFilesCtx(ctx context.Context, fileA Filename, fileI []Filename) error {
+	return fsys.AppendFilesWithCtx(ctx, fileA, fileI, AppendOptions{})
+}
+
+// AppendFilesWithCtx appends the files in fileI in the given order to fileA with the options o as done by AppendFilesWith.
+// The files are appended as done by AppendFileCtx and ctx is checked for cancellation before each file. If ctx is canceled,
+// the appended contents of all files are removed: fileA is truncated to its previous size or removed, if it did not exist
+// before. It returns an error, if any, also if ctx is canceled.
+func AppendFilesWithCtx(ctx context.Context, fileA Filename, fileI []Filename, o AppendOptions) error {
+	return std.AppendFilesWithCtx(ctx, fileA, fileI, o)
+}
+
+// AppendFilesWithCtx performs the package function AppendFilesWithCtx with the settings of fsys.
+func (fsys *FS) AppendFilesWithCtx(ctx context.Context, fileA Filename, fileI []Filename, o AppendOptions) error {
+	// Return an error if fileI is empty
+	if len(fileI) == 0 {
+		return tserr.Empty("fileI")
+	}
+	// Resolve fileA against the root directory of fsys
+	fileA = rooted(fsys, fileA)
+	// Return an error in case fileA contains a blocked directory or filename
+	if e := fsys.CheckFile(fileA); e != nil {
+		return tserr.Check(&tserr.CheckArgs{F: string(fileA), Err: e})
+	}
+	// Resolve and check each input file
+	as := make([]*Append, len(fileI))
+	for i, fn := range fileI {
+		as[i] = &Append{FileA: fileA, FileI: rooted(fsys, fn)}
+		// Return an error in case the input file contains a blocked directory or filename
+		if e := fsys.CheckFile(as[i].FileI); e != nil {
+			return tserr.Check(&tserr.CheckArgs{F: string(as[i].FileI), Err: e})
+		}
+		// Check if the input file exists
+		ok, e := fsys.ExistsFile(as[i].FileI)
+		// Return an error if ExistsFile fails
+		if e != nil {
+			return tserr.Op(&tserr.OpArgs{Op: "ExistsFile", Fn: string(as[i].FileI), Err: e})
+		}
+		// Return an error in case the input file does not exist
+		if !ok {
+			return errNotExist(string(as[i].FileI))
+		}
+	}
+	// Retrieve the size of fileA before appending
+	u := fsys.appendUndo(fileA)
+	// Open fileA. If it does not exist, then create fileA as empty file.
+	f, err := fsys.OpenFile(fileA)
+	// Return error, if any
+	if err != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "OpenFile", Fn: string(fileA), Err: err})
+	}
+	// Append the input files in order
+	for i, a := range as {
+		// If ctx is canceled, close fileA, remove the appended contents and return error
+		if e := ctx.Err(); e != nil {
+			u.close(ctx, f)
+			return tserr.Op(&tserr.OpArgs{Op: fmt.Sprintf("append file %v to", a.FileI), Fn: string(fileA), Err: e})
+		}
+		// Write the separator between two consecutive files
+		if (i > 0) && (o.Separator != "") {
+			if _, e := io.WriteString(f, o.Separator); e != nil {
+				// On error, close fileA, remove the appended contents if ctx is canceled and return error
+				u.close(ctx, f)
+				return tserr.Op(&tserr.OpArgs{Op: "write separator to", Fn: string(fileA), Err: e})
+			}
+		}
+		// Append the contents of the input file to fileA
+		if e := fsys.appendFile(ctx, f, a, false); e != nil {
+			// If appendFile fails, close fileA, remove the appended contents if ctx is canceled and return error
+			u.close(ctx, f)
+			return e
+		}
+	}
+	// Sync and close fileA as requested by the durability of fsys
+	return fsys.closeDurable(f)
+}
+
 // ExistsFile returns true if file fn exists, returns false otherwise. It returns false and an error
 // if there is any. If fn is a directory, ExistsFile returns false and an error.
 func ExistsFile(fn Filename) (bool, error) {
+	return std.ExistsFile(fn)
+}
+
+// ExistsFile performs the package function ExistsFile with the settings of fsys.
+func (fsys *FS) ExistsFile(fn Filename) (bool, error) {
+	// Resolve fn against the root directory of fsys
+	fn = rooted(fsys, fn)
 	// Return an error in case fn is empty
 	if fn == "" {
-		return false, tserr.Empty("filename")
+		return false, errEmptyName("filename")
 	}
 	// Return exists on fn
-	return exists[Filename](fn)
+	return exists[Filename](fsys.backend(), fn)
 }
 
 // ExistsDir returns true if directory dn exists, returns false otherwise. It returns false and an error
 // if there is any. If dn is a file, ExistsDir returns false and an error.
 func ExistsDir(dn Directory) (bool, error) {
+	return std.ExistsDir(dn)
+}
+
+// ExistsDir performs the package function ExistsDir with the settings of fsys.
+func (fsys *FS) ExistsDir(dn Directory) (bool, error) {
+	// Resolve dn against the root directory of fsys
+	dn = rooted(fsys, dn)
 	// Return an error in case dn is empty
 	if dn == "" {
-		return false, tserr.Empty("directory name")
+		return false, errEmptyName("directory name")
 	}
 	// Return exists on dn
-	return exists[Directory](dn)
+	return exists[Directory](fsys.backend(), dn)
 }
 
-// exists retrieves FileInfo of fn using os.Stat. If os.Stat is successful, it returns true. If os.Stat returns an error reporting fn
-// does not exist, it returns false. It returns false and an error for any other error of os.Stat.
-func exists[T Fio](fn T) (bool, error) {
+// exists retrieves FileInfo of fn using Stat of Backend b. If Stat is successful, it returns true. If Stat returns an error reporting fn
+// does not exist, it returns false. It returns false and an error for any other error of Stat.
+func exists[T Fio](b Backend, fn T) (bool, error) {
 	// Retrieve FileInfo of fn
-	_, err := os.Stat(string(fn))
+	_, err := b.Stat(string(fn))
 	// If Stat is successful return true and error as nil
 	if err == nil {
 		return true, nil
@@ -303,87 +898,251 @@ func exists[T Fio](fn T) (bool, error) {
 // RemoveFile removes file f. It returns an error, if there is any. If f is a directory
 // it returns an error. If f does not exist, it also returns an error.
 func RemoveFile(f Filename) error {
+	return std.RemoveFile(f)
+}
+
+// RemoveFile performs the package function RemoveFile with the settings of fsys.
+func (fsys *FS) RemoveFile(f Filename) error {
+	// Resolve f against the root directory of fsys
+	f = rooted(fsys, f)
 	// Return an error in case f contains a blocked directory or filename
-	if e := CheckFile(f); e != nil {
+	if e := fsys.CheckFile(f); e != nil {
 		return tserr.Check(&tserr.CheckArgs{F: string(f), Err: e})
 	}
 	// Check if f exists
-	b, err := ExistsFile(f)
+	b, err := fsys.ExistsFile(f)
 	if err != nil {
 		// Return an error if ExistsFile fails
 		return tserr.Op(&tserr.OpArgs{Op: "check if exists", Fn: string(f), Err: err})
 	}
 	if b {
 		// Remove f, if it exists
-		e := os.Remove(string(f))
+		e := fsys.backend().Remove(string(f))
 		if e != nil {
 			// Return an error if Remove fails
-			return tserr.Op(&tserr.OpArgs{Op: "Remove", Fn: string(f), Err: err})
+			return tserr.Op(&tserr.OpArgs{Op: "Remove", Fn: string(f), Err: e})
 		}
 	} else {
 		// Return an error if f does not exist
-		return tserr.NotExistent(string(f))
+		return errNotExist(string(f))
+	}
+	// Sync the directory of f as requested by the durability of fsys
+	return fsys.syncParents(string(f))
+}
+
+// RemoveOptions holds the options for removing directories with RemoveDir.
+// The zero value only removes an empty directory.
+type RemoveOptions struct {
+	Recursive bool // Recursive removes the directory and any children it contains
+}
+
+// RemoveDir removes directory d with the options o. If o.Recursive is false, d must be empty.
+// If o.Recursive is true, d and any children it contains are removed. Before anything is removed,
+// each directory in d is checked with CheckDir and each regular file with CheckFile. The checks are
+// repeated for each entry immediately before it is removed. Symbolic links in d are removed without
+// following them, therefore files outside of d are never removed. It returns an error, if there is any.
+// If d does not exist or if d is a file or a symbolic link, it returns an error.
+func RemoveDir(d Directory, o RemoveOptions) error {
+	return std.RemoveDir(d, o)
+}
+
+// RemoveDir performs the package function RemoveDir with the settings of fsys.
+func (fsys *FS) RemoveDir(d Directory, o RemoveOptions) error {
+	// Resolve d against the root directory of fsys
+	d = rooted(fsys, d)
+	// Return an error in case d contains a blocked directory or filename
+	if e := fsys.CheckDir(d); e != nil {
+		return tserr.Check(&tserr.CheckArgs{F: string(d), Err: e})
+	}
+	// Check if d exists
+	b, err := fsys.ExistsDir(d)
+	if err != nil {
+		// Return an error if ExistsDir fails
+		return tserr.Op(&tserr.OpArgs{Op: "check if exists", Fn: string(d), Err: err})
+	}
+	if !b {
+		// Return an error if d does not exist
+		return errNotExist(string(d))
+	}
+	// Remove d and any children it contains, if o.Recursive is true
+	if o.Recursive {
+		if e := fsys.removeTree(d); e != nil {
+			// Return an error if removeTree fails
+			return tserr.Op(&tserr.OpArgs{Op: "remove directory tree", Fn: string(d), Err: e})
+		}
+		// Sync the parent directory of d as requested by the durability of fsys
+		return fsys.syncParents(string(d))
+	}
+	// Retrieve FileInfo of d without following a symbolic link
+	fi, err := fsys.backend().Lstat(string(d))
+	if err != nil {
+		// Return an error if Lstat fails
+		return tserr.Op(&tserr.OpArgs{Op: "FileInfo (Lstat) of", Fn: string(d), Err: err})
+	}
+	// Return an error if d is a symbolic link to a directory
+	if !fi.IsDir() {
+		return errNotDir(string(d))
+	}
+	// Remove empty directory d
+	if e := fsys.backend().Remove(string(d)); e != nil {
+		// Return an error if Remove fails, e.g., if d is not empty
+		return tserr.Op(&tserr.OpArgs{Op: "Remove", Fn: string(d), Err: e})
+	}
+	// Sync the parent directory of d as requested by the durability of fsys
+	return fsys.syncParents(string(d))
+}
+
+// removeTree removes directory d and any children it contains. Before anything is removed, each directory is checked
+// with CheckDir and each regular file with CheckFile. Symbolic links and other entries are checked for blocked names
+// and are removed without following them. The check of each entry is repeated immediately before it is removed.
+// It returns an error, if d is a symbolic link or if any check fails.
+func (fsys *FS) removeTree(d Directory) error {
+	// Retrieve FileInfo of d without following a symbolic link
+	fi, err := fsys.backend().Lstat(string(d))
+	if err != nil {
+		return err
+	}
+	// Return an error if d is not a directory, e.g., a symbolic link to a directory
+	if !fi.IsDir() {
+		return errNotDir(string(d))
+	}
+	// Collect all entries of d in walk order. Symbolic links are not followed by walkDir.
+	var es []fs.DirEntry
+	var ps []string
+	err = walkDir(fsys.backend(), string(d), func(p string, de fs.DirEntry, e error) error {
+		// Return an error if walkDir fails for p
+		if e != nil {
+			return e
+		}
+		// Return an error if the check of p fails
+		if e := fsys.checkEntry(p, de); e != nil {
+			return e
+		}
+		// Collect p
+		es, ps = append(es, de), append(ps, p)
+		return nil
+	})
+	// Return an error, if walkDir or any check fails
+	if err != nil {
+		return err
+	}
+	// Remove all entries in reverse walk order, so that children are removed before their parents
+	for i := len(es) - 1; i >= 0; i-- {
+		// Repeat the check of the entry
+		if e := fsys.checkEntry(ps[i], es[i]); e != nil {
+			return e
+		}
+		// Remove the entry without following a symbolic link
+		if e := fsys.backend().Remove(ps[i]); e != nil {
+			return e
+		}
 	}
 	// No error occurred, return nil
 	return nil
 }
 
+// checkEntry checks the path p of the directory entry de. A directory is checked with CheckDir and a regular file with
+// CheckFile. A symbolic link, retrieved with Lstat, is checked by its own name with checkLink, since it is removed without
+// following it. Other entries are checked for blocked names. It returns an error, if the check fails.
+func (fsys *FS) checkEntry(p string, de fs.DirEntry) error {
+	switch {
+	case de.IsDir():
+		return fsys.CheckDir(Directory(p))
+	case de.Type().IsRegular():
+		return fsys.CheckFile(Filename(p))
+	}
+	// Check a symbolic link by its own name without its target
+	if fi, e := fsys.backend().Lstat(p); (e == nil) && (fi.Mode()&fs.ModeSymlink != 0) {
+		return checkLink(fsys.backend(), fsys.policy(), Filename(p))
+	}
+	return checkInval(fsys.backend(), fsys.policy(), Filename(p))
+}
+
 // ResetFile truncates fn to size zero. If fn does not exist, it is created as empty file.
 // It returns an error, if there is any.
 func ResetFile(fn Filename) error {
+	return std.ResetFile(fn)
+}
+
+// ResetFile performs the package function ResetFile with the settings of fsys.
+func (fsys *FS) ResetFile(fn Filename) error {
+	// Resolve fn against the root directory of fsys
+	fn = rooted(fsys, fn)
 	// Return an error in case fn contains a blocked directory or filename
-	if e := CheckFile(fn); e != nil {
+	if e := fsys.CheckFile(fn); e != nil {
 		return tserr.Check(&tserr.CheckArgs{F: string(fn), Err: e})
 	}
 	// Check if fn exists
-	b, err := ExistsFile(fn)
+	b, err := fsys.ExistsFile(fn)
 	if err != nil {
 		// Return error, if ExistsFile fails
 		return tserr.Op(&tserr.OpArgs{Op: "check if exists", Fn: string(fn), Err: err})
 	}
 	if !b {
 		// If fn does not exist, it is created as an empty file with TouchFile
-		if e := TouchFile(fn); e != nil {
+		if e := fsys.TouchFile(fn); e != nil {
 			// Return error, if TouchFile fails
 			return tserr.Op(&tserr.OpArgs{Op: "TouchFile", Fn: string(fn), Err: e})
 		}
 	}
 	// Truncate file fn to size zero
-	err = os.Truncate(string(fn), 0)
+	err = fsys.backend().Truncate(string(fn), 0)
 	if err != nil {
 		// Return error if Truncate fails
 		return tserr.Op(&tserr.OpArgs{Op: "Truncate", Fn: string(fn), Err: err})
 	}
-	// No error occurred, return nil
-	return nil
+	// Sync fn as requested by the durability of fsys
+	return fsys.syncName(fn)
 }
 
 // CreateDir creates a directory named d with any necessary parents. If d already exists as
 // directory, it does nothing and returns nil. It returns an error, if there is any.
 func CreateDir(d Directory) error {
+	return std.CreateDir(d)
+}
+
+// CreateDir performs the package function CreateDir with the settings of fsys.
+func (fsys *FS) CreateDir(d Directory) error {
+	// Resolve d against the root directory of fsys
+	d = rooted(fsys, d)
 	// Return an error in case d contains a blocked directory or filename
-	if e := CheckDir(d); e != nil {
+	if e := fsys.CheckDir(d); e != nil {
 		return tserr.Check(&tserr.CheckArgs{F: string(d), Err: e})
 	}
+	// Collect the directories to be created, d and its parents, which do not exist
+	var created []string
+	for p := string(d); ; p = filepath.Dir(p) {
+		if _, e := fsys.backend().Stat(p); (e == nil) || (filepath.Dir(p) == p) {
+			break
+		}
+		created = append(created, p)
+	}
 	// Create directory named d with any necessary parents
-	err := os.MkdirAll(string(d), dperm)
+	err := fsys.backend().MkdirAll(string(d), fsys.dirMode())
 	if err != nil {
 		// Return an error, if MKdirAll fails
 		return tserr.Op(&tserr.OpArgs{Op: "make directory", Fn: string(d), Err: err})
 	}
-	// No error occurred, return nil
-	return nil
+	// Sync the parent directories of the created directories as requested by the durability of fsys
+	return fsys.syncParents(created...)
 }
 
 // FileSize returns the length in bytes for the regular file fn. If fn is a blocked filename,
 // a directory or if FileInfo for fn cannot be retrieved, it returns 0 and an error.
 func FileSize(fn Filename) (int64, error) {
+	return std.FileSize(fn)
+}
+
+// FileSize performs the package function FileSize with the settings of fsys.
+func (fsys *FS) FileSize(fn Filename) (int64, error) {
+	// Resolve fn against the root directory of fsys
+	fn = rooted(fsys, fn)
 	// Return an error in case fn contains a blocked directory or filename
-	if err := CheckFile(fn); err != nil {
+	if err := fsys.CheckFile(fn); err != nil {
 		return 0, tserr.Check(&tserr.CheckArgs{F: string(fn), Err: err})
 	}
 	// Retrieve FileInfo of fn
-	fi, e := os.Stat(string(fn))
+	fi, e := fsys.backend().Stat(string(fn))
 	if e != nil {
 		// For any error of Stat return 0 and the error
 		return 0, tserr.Op(&tserr.OpArgs{Op: "FileInfo (Stat) of", Fn: string(fn), Err: e})
@@ -5,11 +5,16 @@ package tsfio_test
 
 // Import standard library packages as well as tserr and tsfio
 import (
-	"fmt"           // fmt
-	"os"            // os
-	"path/filepath" // filepath
-	"testing"       // testing
-	"time"          // time
+	"fmt"            // fmt
+	"io"             // io
+	"io/fs"          // fs
+	"os"             // os
+	"path/filepath"  // filepath
+	"runtime"        // runtime
+	"strings"        // strings
+	"testing"        // testing
+	"testing/iotest" // iotest
+	"time"           // time
 
 	"github.com/thorstenrie/tserr" // tserr
 	"github.com/thorstenrie/tsfio" // tsfio
@@ -98,6 +103,115 @@ func TestOpenFileEmpty(t *testing.T) {
 	}
 }
 
+// TestOpenFileWithReadOnly tests OpenFileWith to open an existing file read-only and to neither create a file,
+// which does not exist, nor write to a file opened read-only. The test fails if OpenFileWith returns an error for
+// the existing file or nil for the file, which does not exist, if the contents differ or if Write returns nil.
+func TestOpenFileWithReadOnly(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write file a to d
+	writeTree(t, d, map[string]string{"a": testcase})
+	fn := func(n string) tsfio.Filename { return tsfio.Filename(filepath.Join(string(d), n)) }
+	// Open a read-only
+	f, err := tsfio.OpenFileWith(fn("a"), tsfio.OpenOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "OpenFileWith", Fn: string(fn("a")), Err: err}))
+	}
+	// The test fails if the contents differ
+	if b, e := io.ReadAll(f); (e != nil) || (string(b) != testcase) {
+		t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: string(fn("a")), Actual: string(b), Want: testcase}))
+	}
+	// The test fails if Write returns nil
+	if _, e := f.WriteString(testcase); e == nil {
+		t.Error(tserr.NilFailed("WriteString"))
+	}
+	f.Close()
+	// The test fails if OpenFileWith returns nil for b, which does not exist
+	if _, e := tsfio.OpenFileWith(fn("b"), tsfio.OpenOptions{ReadOnly: true}); e == nil {
+		t.Error(tserr.NilFailed("OpenFileWith"))
+	}
+	// The test fails if a changed or b is created
+	checkTree(t, d, map[string]string{"a": testcase})
+	testEntries(t, d, 1)
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestOpenFileWith tests OpenFileWith to truncate a file, to create a file exclusively with custom permission bits,
+// to open a file write-only for appending and synchronous I/O, to write a file at an offset and to return an error
+// for a file, which must not be created or must be created exclusively. The test fails if OpenFileWith returns an error or nil, or the contents or permission
+// bits differ.
+func TestOpenFileWith(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write files a and c to d
+	writeTree(t, d, map[string]string{"a": testcase, "c": testcase})
+	fn := func(n string) tsfio.Filename { return tsfio.Filename(filepath.Join(string(d), n)) }
+	// Open files with options and write testcase_unix
+	for n, o := range map[string]tsfio.OpenOptions{
+		"a": {Truncate: true},
+		"b": {Exclusive: true, Perm: 0600},
+		"c": {WriteOnly: true, Append: true, Sync: true, NoCreate: true},
+	} {
+		f, err := tsfio.OpenFileWith(fn(n), o)
+		if err != nil {
+			t.Error(tserr.Op(&tserr.OpArgs{Op: fmt.Sprintf("OpenFileWith %v", o), Fn: string(fn(n)), Err: err}))
+			continue
+		}
+		if _, e := f.WriteString(testcase_unix); e != nil {
+			t.Error(tserr.Op(&tserr.OpArgs{Op: "WriteString", Fn: string(fn(n)), Err: e}))
+		}
+		f.Close()
+	}
+	// Open b without appending. The test fails if OpenFileWith or WriteAt return an error.
+	if f, err := tsfio.OpenFileWith(fn("b"), tsfio.OpenOptions{NoCreate: true}); err != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "OpenFileWith", Fn: string(fn("b")), Err: err}))
+	} else {
+		if _, e := f.WriteAt([]byte(testcase_unix[:1]), 1); e != nil {
+			t.Error(tserr.Op(&tserr.OpArgs{Op: "WriteAt", Fn: string(fn("b")), Err: e}))
+		}
+		f.Close()
+	}
+	// The test fails if the contents differ
+	checkTree(t, d, map[string]string{"a": testcase_unix, "b": testcase_unix[:1] + testcase_unix[:1] + testcase_unix[2:], "c": testcase + testcase_unix})
+	// The test fails if the permission bits of b differ. Windows only supports read-only files.
+	if fi, e := os.Stat(string(fn("b"))); (e == nil) && (runtime.GOOS != "windows") && (fi.Mode().Perm() != 0600) {
+		t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: "permission bits of " + string(fn("b")), Actual: fi.Mode().Perm().String(), Want: fs.FileMode(0600).String()}))
+	}
+	// The test fails if OpenFileWith returns nil for an existing file created exclusively or a file, which does not
+	// exist and must not be created
+	for n, o := range map[string]tsfio.OpenOptions{"a": {Exclusive: true}, "d": {NoCreate: true}} {
+		if _, e := tsfio.OpenFileWith(fn(n), o); e == nil {
+			t.Error(tserr.NilFailed(fmt.Sprintf("OpenFileWith %v of %v", o, n)))
+		}
+	}
+	// The test fails if d is created
+	testEntries(t, d, 3)
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestOpenFileWithConflict tests OpenFileWith to return an error for conflicting options.
+// The test fails if OpenFileWith returns nil.
+func TestOpenFileWithConflict(t *testing.T) {
+	// Create a temporary file fn
+	fn := tmpFile(t)
+	// The test fails if OpenFileWith returns nil
+	for _, o := range []tsfio.OpenOptions{
+		{ReadOnly: true, WriteOnly: true},
+		{ReadOnly: true, Append: true},
+		{ReadOnly: true, Truncate: true},
+		{ReadOnly: true, Exclusive: true},
+		{Exclusive: true, NoCreate: true},
+	} {
+		if _, e := tsfio.OpenFileWith(fn, o); e == nil {
+			t.Error(tserr.NilFailed(fmt.Sprintf("OpenFileWith %v", o)))
+		}
+	}
+	// Remove fn
+	rm(t, fn)
+}
+
 // TestCreateDirEmpty tests CreateDor in case it retrieves the empty string "".
 // The test fails if CreateDir returns nil instead of an error.
 func TestCreateDirEmpty(t *testing.T) {
@@ -302,6 +416,87 @@ func TestWriteSingleStrErr(t *testing.T) {
 	}
 }
 
+// TestWriteBytes tests WriteBytes and WriteFrom to append to a temporary file and to return the number of bytes
+// written. The test fails if any of them returns an error, another number of bytes or if the contents differ.
+func TestWriteBytes(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	fn := tsfio.Filename(filepath.Join(string(d), "a"))
+	l := strings.Repeat(testcase, 16*1024)
+	// Write testcase with WriteBytes twice
+	for i := 0; i < 2; i++ {
+		if n, e := tsfio.WriteBytes(fn, []byte(testcase)); (e != nil) || (n != len(testcase)) {
+			t.Error(tserr.Return(&tserr.ReturnArgs{Op: "WriteBytes", Actual: fmt.Sprint(n, e), Want: fmt.Sprint(len(testcase), nil)}))
+		}
+	}
+	// Write l with WriteFrom
+	if n, e := tsfio.WriteFrom(fn, strings.NewReader(l)); (e != nil) || (n != int64(len(l))) {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "WriteFrom", Actual: fmt.Sprint(n, e), Want: fmt.Sprint(len(l), nil)}))
+	}
+	// The test fails if the contents differ
+	checkTree(t, d, map[string]string{"a": testcase + testcase + l})
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestWriteSingleBytes tests WriteSingleBytes and WriteSingleFrom to replace the contents of a temporary file and to
+// return the number of bytes written. The test fails if any of them returns an error, another number of bytes or if
+// the contents differ.
+func TestWriteSingleBytes(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write file a to d
+	writeTree(t, d, map[string]string{"a": testcase})
+	fn := tsfio.Filename(filepath.Join(string(d), "a"))
+	// Replace a with WriteSingleBytes
+	if n, e := tsfio.WriteSingleBytes(fn, []byte(testcase_unix)); (e != nil) || (n != len(testcase_unix)) {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "WriteSingleBytes", Actual: fmt.Sprint(n, e), Want: fmt.Sprint(len(testcase_unix), nil)}))
+	}
+	checkTree(t, d, map[string]string{"a": testcase_unix})
+	// Replace a with WriteSingleFrom
+	if n, e := tsfio.WriteSingleFrom(fn, strings.NewReader(testcase_win)); (e != nil) || (n != int64(len(testcase_win))) {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "WriteSingleFrom", Actual: fmt.Sprint(n, e), Want: fmt.Sprint(len(testcase_win), nil)}))
+	}
+	checkTree(t, d, map[string]string{"a": testcase_win})
+	// The test fails if temporary files remain
+	testEntries(t, d, 1)
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestWriteFromErr tests WriteFrom and WriteSingleFrom to return an error for a nil reader and a reader failing
+// after the first chunk. The test fails if they return nil, if WriteFrom does not write the contents read before
+// the error or if WriteSingleFrom changes the file.
+func TestWriteFromErr(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write files a and b to d
+	writeTree(t, d, map[string]string{"a": testcase, "b": testcase})
+	fa, fb := tsfio.Filename(filepath.Join(string(d), "a")), tsfio.Filename(filepath.Join(string(d), "b"))
+	// The test fails if WriteFrom or WriteSingleFrom return nil for a nil reader
+	if _, e := tsfio.WriteFrom(fa, nil); e == nil {
+		t.Error(tserr.NilFailed("WriteFrom"))
+	}
+	if _, e := tsfio.WriteSingleFrom(fa, nil); e == nil {
+		t.Error(tserr.NilFailed("WriteSingleFrom"))
+	}
+	// The test fails if WriteFrom or WriteSingleFrom return nil for a failing reader
+	fail := func() io.Reader {
+		return io.MultiReader(strings.NewReader(testcase_unix), iotest.ErrReader(io.ErrUnexpectedEOF))
+	}
+	if n, e := tsfio.WriteFrom(fa, fail()); (e == nil) || (n != int64(len(testcase_unix))) {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "WriteFrom", Actual: fmt.Sprint(n, e), Want: fmt.Sprint(len(testcase_unix), io.ErrUnexpectedEOF)}))
+	}
+	if _, e := tsfio.WriteSingleFrom(fb, fail()); e == nil {
+		t.Error(tserr.NilFailed("WriteSingleFrom"))
+	}
+	// The test fails if a does not hold the contents read before the error, b changed or temporary files remain
+	checkTree(t, d, map[string]string{"a": testcase + testcase_unix, "b": testcase})
+	testEntries(t, d, 2)
+	// Remove d
+	rmAll(t, d)
+}
+
 // TestTouchFileEmpty tests TouchFile to return an error for an empty filename.
 // If TouchFile returns nil, the test fails.
 func TestTouchFileEmpty(t *testing.T) {
@@ -528,6 +723,127 @@ func TestAppendFile(t *testing.T) {
 	}
 }
 
+// TestAppendFileLarge tests AppendFile to append a file larger than the copy buffer and with contents varying
+// across the buffer boundaries to another file. If the resulting file does not match, the test fails.
+func TestAppendFileLarge(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Create large contents from numbered lines
+	var sb strings.Builder
+	for i := 0; sb.Len() < 200*1024; i++ {
+		fmt.Fprintf(&sb, "%d %v\n", i, testcase)
+	}
+	// Write files a and i to d
+	writeTree(t, d, map[string]string{"a": testcase, "i": sb.String()})
+	// Append i to a
+	fa, fi := tsfio.Filename(filepath.Join(string(d), "a")), tsfio.Filename(filepath.Join(string(d), "i"))
+	if e := tsfio.AppendFile(&tsfio.Append{FileA: fa, FileI: fi}); e != nil {
+		// If AppendFile returns an error, the test fails
+		t.Error(tserr.Op(&tserr.OpArgs{Op: fmt.Sprintf("AppendFile %v to file", fi), Fn: string(fa), Err: e}))
+	}
+	// The test fails if a does not hold the contents of a and i or i changed
+	checkTree(t, d, map[string]string{"a": testcase + sb.String(), "i": sb.String()})
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestAppendFileRange tests AppendFile to append a file with copy_file_range on Linux, although the file is opened
+// with O_APPEND by default. If copy_file_range is not used or the resulting file does not match, the test fails.
+func TestAppendFileRange(t *testing.T) {
+	// Skip the test, if copy_file_range is not available
+	if runtime.GOOS != "linux" {
+		t.Skip("copy_file_range is only available on Linux")
+	}
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write files a and i to d
+	writeTree(t, d, map[string]string{"a": testcase, "i": testcase})
+	// Append i to a
+	fa, fi := tsfio.Filename(filepath.Join(string(d), "a")), tsfio.Filename(filepath.Join(string(d), "i"))
+	n := tsfio.RangeAppends()
+	if e := tsfio.AppendFile(&tsfio.Append{FileA: fa, FileI: fi}); e != nil {
+		// If AppendFile returns an error, the test fails
+		t.Error(tserr.Op(&tserr.OpArgs{Op: fmt.Sprintf("AppendFile %v to file", fi), Fn: string(fa), Err: e}))
+	}
+	// The test fails if AppendFile did not use copy_file_range
+	if m := tsfio.RangeAppends(); m != n+1 {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "AppendFile with copy_file_range", Actual: fmt.Sprint(m - n), Want: "1"}))
+	}
+	// The test fails if a does not hold the contents of a and i or i changed
+	checkTree(t, d, map[string]string{"a": testcase + testcase, "i": testcase})
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestAppendFileSelf tests AppendFile to append a file to itself. If the file does not hold its contents
+// twice, the test fails.
+func TestAppendFileSelf(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write file a to d
+	writeTree(t, d, map[string]string{"a": testcase})
+	// Append a to a
+	fa := tsfio.Filename(filepath.Join(string(d), "a"))
+	if e := tsfio.AppendFile(&tsfio.Append{FileA: fa, FileI: fa}); e != nil {
+		// If AppendFile returns an error, the test fails
+		t.Error(tserr.Op(&tserr.OpArgs{Op: fmt.Sprintf("AppendFile %v to file", fa), Fn: string(fa), Err: e}))
+	}
+	// The test fails if a does not hold testcase twice
+	checkTree(t, d, map[string]string{"a": testcase + testcase})
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestAppendFiles tests AppendFiles and AppendFilesWith to append files in order to a file, which does not exist,
+// with and without separator. If the resulting files do not match, the test fails.
+func TestAppendFiles(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write input files to d
+	writeTree(t, d, map[string]string{"1": "one", "2": "", "3": "three"})
+	fi := []tsfio.Filename{
+		tsfio.Filename(filepath.Join(string(d), "3")),
+		tsfio.Filename(filepath.Join(string(d), "1")),
+		tsfio.Filename(filepath.Join(string(d), "2")),
+	}
+	// Append the input files to a without separator
+	fa := tsfio.Filename(filepath.Join(string(d), "a"))
+	if e := tsfio.AppendFiles(fa, fi); e != nil {
+		// If AppendFiles returns an error, the test fails
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "AppendFiles", Fn: string(fa), Err: e}))
+	}
+	// Append the input files to b with separator
+	fb := tsfio.Filename(filepath.Join(string(d), "b"))
+	if e := tsfio.AppendFilesWith(fb, fi, tsfio.AppendOptions{Separator: "\n"}); e != nil {
+		// If AppendFilesWith returns an error, the test fails
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "AppendFilesWith", Fn: string(fb), Err: e}))
+	}
+	// The test fails if a or b do not hold the contents of the input files in order
+	checkTree(t, d, map[string]string{"1": "one", "2": "", "3": "three", "a": "threeone", "b": "three\none\n"})
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestAppendFilesErr tests AppendFiles to return an error for empty input files and an input file, which does
+// not exist. If AppendFiles returns nil or if the file to be extended is created, the test fails.
+func TestAppendFilesErr(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write input file 1 to d
+	writeTree(t, d, map[string]string{"1": "one"})
+	fa := tsfio.Filename(filepath.Join(string(d), "a"))
+	// The test fails if AppendFiles returns nil
+	for _, fi := range [][]tsfio.Filename{nil, {tsfio.Filename(filepath.Join(string(d), "1")), tsfio.Filename(filepath.Join(string(d), "2"))}} {
+		if e := tsfio.AppendFiles(fa, fi); e == nil {
+			t.Error(tserr.NilFailed(fmt.Sprintf("AppendFiles of %v", fi)))
+		}
+	}
+	// The test fails if a is created
+	testEntries(t, d, 1)
+	// Remove d
+	rmAll(t, d)
+}
+
 // TestExistsFileEmpty tests ExistsFile to return an error for an empty string as filename.
 // If ExistsFile returns the error to be nil, the test fails.
 func TestExistsFileEmpty(t *testing.T) {
@@ -721,6 +1037,134 @@ func TestRemoveFile2(t *testing.T) {
 	}
 }
 
+// TestRemoveDirEmpty tests RemoveDir to remove an empty temporary directory without the recursive option.
+// If RemoveDir returns an error or the directory still exists, the test fails.
+func TestRemoveDirEmpty(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Remove d
+	if e := tsfio.RemoveDir(d, tsfio.RemoveOptions{}); e != nil {
+		// If RemoveDir returns an error, the test fails
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "RemoveDir", Fn: string(d), Err: e}))
+	}
+	// If d still exists, the test fails
+	if b, _ := tsfio.ExistsDir(d); b {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: fmt.Sprintf("ExistsDir of %v", d), Actual: "true", Want: "false"}))
+	}
+}
+
+// TestRemoveDirRecursive tests RemoveDir to return an error for a non-empty temporary directory without the
+// recursive option and to remove it with the recursive option. The test fails if RemoveDir returns nil for the
+// non-empty directory, if RemoveDir returns an error with the recursive option or if the directory still exists.
+func TestRemoveDirRecursive(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Create the test tree in d
+	writeTree(t, d, testTree)
+	// If RemoveDir returns nil for the non-empty directory, the test fails
+	if e := tsfio.RemoveDir(d, tsfio.RemoveOptions{}); e == nil {
+		t.Error(tserr.NilFailed(fmt.Sprintf("RemoveDir of %v", d)))
+	}
+	// Remove d recursively
+	if e := tsfio.RemoveDir(d, tsfio.RemoveOptions{Recursive: true}); e != nil {
+		// If RemoveDir returns an error, the test fails
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "RemoveDir", Fn: string(d), Err: e}))
+	}
+	// If d still exists, the test fails
+	if b, _ := tsfio.ExistsDir(d); b {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: fmt.Sprintf("ExistsDir of %v", d), Actual: "true", Want: "false"}))
+	}
+}
+
+// TestRemoveDirSymlink tests RemoveDir to remove a symbolic link in a directory without following it and to
+// return an error for a symbolic link to a directory. The test fails if RemoveDir removes files outside of the
+// directory, returns an error for the directory or returns nil for the symbolic link. The test is skipped if
+// symbolic links cannot be created.
+func TestRemoveDirSymlink(t *testing.T) {
+	// Create temporary directories d and o
+	d, o := tmpDir(t), tmpDir(t)
+	// Create the test tree in o
+	writeTree(t, o, testTree)
+	// Create symbolic link l in d pointing to o
+	l := filepath.Join(string(d), string(testfile))
+	if e := os.Symlink(string(o), l); e != nil {
+		rm(t, d)
+		rmAll(t, o)
+		t.Skip(e)
+	}
+	// If RemoveDir returns nil for the symbolic link, the test fails
+	if e := tsfio.RemoveDir(tsfio.Directory(l), tsfio.RemoveOptions{Recursive: true}); e == nil {
+		t.Error(tserr.NilFailed(fmt.Sprintf("RemoveDir of %v", l)))
+	}
+	// Remove d recursively
+	if e := tsfio.RemoveDir(d, tsfio.RemoveOptions{Recursive: true}); e != nil {
+		// If RemoveDir returns an error, the test fails
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "RemoveDir", Fn: string(d), Err: e}))
+	}
+	// The test fails if the test tree in o has been changed
+	checkTree(t, o, testTree)
+	// Remove o
+	rmAll(t, o)
+}
+
+// TestRemoveDirSymlinkPolicy tests RemoveDir of an FS with a policy not allowing symbolic links and blocking the
+// target of a symbolic link to remove the symbolic link by its own name and to return an error for a symbolic link
+// with a blocked name. The test fails if RemoveDir returns an error for the directory, nil for the blocked
+// symbolic link or if the target has been changed. The test is skipped if symbolic links cannot be created.
+func TestRemoveDirSymlinkPolicy(t *testing.T) {
+	// Create temporary directories d and o
+	d, o := tmpDir(t), tmpDir(t)
+	// Create the test tree in o
+	writeTree(t, o, testTree)
+	// Create symbolic links l in d and in a sub directory of d pointing to o
+	ls := []string{filepath.Join(string(d), string(testfile)), filepath.Join(string(d), "b", string(testfile))}
+	writeTree(t, d, map[string]string{"b/a": testcase})
+	for _, l := range ls {
+		if e := os.Symlink(string(o), l); e != nil {
+			rmAll(t, d)
+			rmAll(t, o)
+			t.Skip(e)
+		}
+	}
+	// Create FS with a policy not allowing symbolic links, blocking o and the first symbolic link
+	p := tsfio.DefaultPolicy()
+	p.AllowSymlinks = false
+	p.BlockedDirs = append(p.BlockedDirs, o)
+	p.BlockedFiles = append(p.BlockedFiles, tsfio.Filename(ls[0]))
+	fsys := &tsfio.FS{Policy: &p}
+	// If RemoveDir returns nil for d containing the blocked symbolic link, the test fails
+	if e := fsys.RemoveDir(d, tsfio.RemoveOptions{Recursive: true}); e == nil {
+		t.Error(tserr.NilFailed(fmt.Sprintf("RemoveDir of %v", d)))
+	}
+	// Remove the blocked symbolic link and d recursively
+	rm(t, tsfio.Filename(ls[0]))
+	if e := fsys.RemoveDir(d, tsfio.RemoveOptions{Recursive: true}); e != nil {
+		// If RemoveDir returns an error, the test fails
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "RemoveDir", Fn: string(d), Err: e}))
+	}
+	// The test fails if the test tree in o has been changed
+	checkTree(t, o, testTree)
+	// Remove o
+	rmAll(t, o)
+}
+
+// TestRemoveDirErr tests RemoveDir to return an error for an empty string as directory, a directory which does not exist
+// and a file. If RemoveDir returns nil, the test fails.
+func TestRemoveDirErr(t *testing.T) {
+	// Create temporary file fn and temporary directory d, d does not exist
+	fn, d := tmpFile(t), tmpDir(t)
+	rm(t, d)
+	// Iterate over the directories expected to fail
+	for _, i := range []tsfio.Directory{"", d, tsfio.Directory(fn)} {
+		// If RemoveDir returns nil, the test fails
+		if e := tsfio.RemoveDir(i, tsfio.RemoveOptions{Recursive: true}); e == nil {
+			t.Error(tserr.NilFailed(fmt.Sprintf("RemoveDir of %v", i)))
+		}
+	}
+	// Remove fn
+	rm(t, fn)
+}
+
 // TestResetFileEmpty tests ResetFile with an empty string as filename.
 // If ResetFiles returns nil, it fails.
 func TestResetFileEmpty(t *testing.T) {
@@ -5,9 +5,11 @@ package tsfio_test
 
 // Import standard library packages as well as tserr and tsfio
 import (
-	"os"      // os
-	"testing" // testing
-	"time"    // time
+	"fmt"           // fmt
+	"os"            // os
+	"path/filepath" // filepath
+	"testing"       // testing
+	"time"          // time
 
 	"github.com/thorstenrie/tserr" // tserr
 	"github.com/thorstenrie/tsfio" // tsfio
@@ -105,3 +107,85 @@ func modTime(t *testing.T, fn tsfio.Filename) time.Time {
 	// Return the modification time of file with Filename fn
 	return t1
 }
+
+// rmAll removes Directory d and any children it contains. In case of an error
+// execution stops.
+func rmAll(t *testing.T, d tsfio.Directory) {
+	// Panic if t is nil
+	if t == nil {
+		panic("nil pointer")
+	}
+	// Remove d and any children it contains
+	if err := os.RemoveAll(string(d)); err != nil {
+		// Stop execution in case of an error
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "RemoveAll", Fn: string(d), Err: err}))
+	}
+}
+
+// writeTree creates the files of the map m in Directory d. The keys of m are the paths of the files
+// relative to d and the values are the contents of the files. Parent directories are created as needed.
+// In case of an error, execution stops.
+func writeTree(t *testing.T, d tsfio.Directory, m map[string]string) {
+	// Panic if t is nil
+	if t == nil {
+		panic("nil pointer")
+	}
+	// Iterate over the files in m
+	for n, c := range m {
+		// Retrieve the path of the file
+		p := filepath.Join(string(d), n)
+		// Create the parent directories of the file
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(tserr.Op(&tserr.OpArgs{Op: "MkdirAll", Fn: filepath.Dir(p), Err: err}))
+		}
+		// Write the contents to the file
+		if err := os.WriteFile(p, []byte(c), 0644); err != nil {
+			t.Fatal(tserr.Op(&tserr.OpArgs{Op: "WriteFile", Fn: p, Err: err}))
+		}
+	}
+}
+
+// checkTree tests if the files of the map m exist in Directory d with the expected contents. The keys of m
+// are the paths of the files relative to d and the values are the expected contents of the files.
+// The test fails if a file cannot be read or its contents differ.
+func checkTree(t *testing.T, d tsfio.Directory, m map[string]string) {
+	// Panic if t is nil
+	if t == nil {
+		panic("nil pointer")
+	}
+	// Iterate over the files in m
+	for n, c := range m {
+		// Retrieve the path of the file
+		p := filepath.Join(string(d), n)
+		// Read the file
+		b, err := os.ReadFile(p)
+		// The test fails if ReadFile returns an error
+		if err != nil {
+			t.Error(tserr.Op(&tserr.OpArgs{Op: "ReadFile", Fn: p, Err: err}))
+			continue
+		}
+		// The test fails if the contents differ
+		if string(b) != c {
+			t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: p, Actual: string(b), Want: c}))
+		}
+	}
+}
+
+// testEntries tests if Directory d contains n entries. The test fails if ReadDir returns an error or
+// if the number of entries does not equal n.
+func testEntries(t *testing.T, d tsfio.Directory, n int) {
+	// Panic if t is nil
+	if t == nil {
+		panic("nil pointer")
+	}
+	// Read the entries of d
+	de, err := os.ReadDir(string(d))
+	// If ReadDir returns an error, the test fails
+	if err != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "ReadDir", Fn: string(d), Err: err}))
+	}
+	// If the number of entries does not equal n, the test fails
+	if len(de) != n {
+		t.Error(tserr.Equal(&tserr.EqualArgs{Var: fmt.Sprintf("number of entries in %v", d), Actual: int64(len(de)), Want: int64(n)}))
+	}
+}
@@ -6,9 +6,11 @@ package tsfio
 // Import standard library packages and tserr
 import (
 	"fmt"           // fmt
+	"io/fs"         // fs
 	"os"            // os
 	"path/filepath" // path/filepath
 	"strings"       // strings
+	"unicode/utf8"  // utf8
 
 	"github.com/thorstenrie/tserr" // tserr
 )
@@ -26,44 +28,49 @@ type Directory string
 
 // CheckFile performs checks on file f and returns an error if
 //   - f is an empty string
-//   - f contains a blocked directory or filename
+//   - f contains a blocked directory or filename, also with symbolic links evaluated
 //   - f is an existing directory, not a file
 //   - os.Stat returns an error when retrieving FileInfo
 //
-// Otherwise it returns nil.
+// Otherwise it returns nil. The checks are performed with the package policy, see SetPolicy.
 func CheckFile(f Filename) error {
-	return checkWrapper(f, false)
+	return std.CheckFile(f)
+}
+
+// CheckFile performs the package function CheckFile with the settings of fsys.
+func (fsys *FS) CheckFile(f Filename) error {
+	return checkWrapper(fsys.backend(), fsys.policy(), rooted(fsys, f), false)
 }
 
 // CheckDir performs checks on directory d and returns an error if
 //   - d is an empty string
-//   - d contains a blocked directory or filename
+//   - d contains a blocked directory or filename, also with symbolic links evaluated
 //   - d is an existing file, not a directory
 //   - os.Stat returns an error when retrieving FileInfo
 //
-// Otherwise it returns nil.
+// Otherwise it returns nil. The checks are performed with the package policy, see SetPolicy.
 func CheckDir(d Directory) error {
-	return checkWrapper(d, true)
+	return std.CheckDir(d)
+}
+
+// CheckDir performs the package function CheckDir with the settings of fsys.
+func (fsys *FS) CheckDir(d Directory) error {
+	return checkWrapper(fsys.backend(), fsys.policy(), rooted(fsys, d), true)
 }
 
-// checkWrapper performs checks on a file or directory using fio as type parameter.
+// checkWrapper performs checks on a file or directory using fio as type parameter with Backend b and policy p.
 // It returns an error, if any check fails. Otherwise it returns nil.
-func checkWrapper[T Fio](f T, dir bool) error {
+func checkWrapper[T Fio](b Backend, p Policy, f T, dir bool) error {
 	// Return an error if f is an empty string
 	if f == "" {
-		return tserr.Empty(string(f))
+		return errEmptyName(string(f))
 	}
 	// Return an error if f contains a blocked directory or filename
-	if err := checkInval(f); err != nil {
+	if err := checkInval(b, p, f); err != nil {
 		return err
 	}
 	// Retrieve FileInfo of f
-	i, err := os.Stat(string(f))
-	// Set w to file or directory
-	w := "regular file"
-	if dir {
-		w = "directory"
-	}
+	i, err := b.Stat(string(f))
 	// If Stat returns no error, then check if expected type matches type of f
 	if err == nil {
 		// Return nil, if expected type matches
@@ -74,7 +81,10 @@ func checkWrapper[T Fio](f T, dir bool) error {
 			return nil
 		}
 		// Return an error otherwise
-		return tserr.TypeNotMatching(&tserr.TypeNotMatchingArgs{Actual: string(f), Want: w})
+		if dir {
+			return errNotDir(string(f))
+		}
+		return errNotRegular(string(f), "regular file")
 	}
 	// If Stat returns an error reporting f does not exist, return nil
 	if os.IsNotExist(err) {
@@ -84,34 +94,158 @@ func checkWrapper[T Fio](f T, dir bool) error {
 	return tserr.Check(&tserr.CheckArgs{F: string(f), Err: err})
 }
 
-// checkInval if f contains blocked directories or equals a blocked filename.
-// In case of a match with a blocked directory or filename it returns an error,
-// otherwise nil.
-func checkInval[T Fio](f T) error {
-	// Retrieve the shortest path name of f
-	fc := filepath.Clean(string(f))
-	// Iterate i over blocked filenames
-	for _, i := range InvalFile() {
-		// Retrieve the shortest path name of i
-		ic := filepath.Clean(string(i))
-		// If the blocked filename and f match, then return an error
-		if ic == fc {
-			return tserr.Forbidden(string(f))
+// maxLinks holds the maximum number of symbolic links evaluated in a path by resolve
+const maxLinks int = 255
+
+// checkInval checks if f contains blocked directories or equals a blocked filename of policy p. Both, the absolute
+// path of f and the absolute path of f with symbolic links evaluated with Backend b are checked. Directories are matched on
+// path component boundaries, e.g., /boot/foo matches the blocked directory /boot, but /bootstrap does not.
+// In case of a match with a blocked directory or filename it returns an error reporting the matched
+// blocked entry. If p defines allowed roots, it returns an error if the evaluated path of f does not reside
+// in one of them. If p does not allow symbolic links, it returns an error if the path of f contains a
+// symbolic link. Otherwise, it returns nil.
+func checkInval[T Fio](b Backend, p Policy, f T) error {
+	// Retrieve the absolute path of f
+	a, err := filepath.Abs(string(f))
+	if err != nil {
+		return tserr.Check(&tserr.CheckArgs{F: string(f), Err: err})
+	}
+	// Retrieve the absolute path of f with symbolic links evaluated
+	r, err := resolve(b, string(f))
+	if err != nil {
+		return tserr.Check(&tserr.CheckArgs{F: string(f), Err: err})
+	}
+	// Check the absolute path and the evaluated path
+	for _, x := range [2]string{a, r} {
+		// Return an error reporting the matched blocked entry, if any
+		if i := p.blocked(x); i != "" {
+			return tserr.Check(&tserr.CheckArgs{F: string(f), Err: errBlocked(i)})
 		}
 	}
-	// Iterate i over blocked directories
-	for _, i := range InvalDir() {
-		// Retrieve the shortest path name of i
-		ic := filepath.Clean(string(i))
-		// If f matches the blocked directory or one of its parents, then return an error
-		if strings.HasPrefix(fc, ic) {
-			return tserr.Forbidden(string(i))
+	// Return an error if the evaluated path does not reside in an allowed root
+	if ok, err := p.allowed(b, r); err != nil {
+		return tserr.Check(&tserr.CheckArgs{F: string(f), Err: err})
+	} else if !ok {
+		return tserr.Check(&tserr.CheckArgs{F: string(f), Err: errBlocked(r + " outside of allowed roots")})
+	}
+	// Return an error if symbolic links are not allowed and the path contains a symbolic link
+	if !p.AllowSymlinks && (normCase(a) != normCase(r)) {
+		return tserr.Check(&tserr.CheckArgs{F: string(f), Err: errBlocked("symbolic link in " + a)})
+	}
+	// No error occurred, return nil
+	return nil
+}
+
+// checkLink checks the symbolic link f of policy p by its own name without evaluating f itself, e.g., before
+// removing f. The parent directory of f is checked with checkInval. The path of f in the absolute path and in the
+// evaluated path of its parent directory is checked for blocked directories and filenames. Therefore, neither the
+// target of f nor f being a symbolic link result in an error. It returns an error, if the check fails.
+func checkLink(b Backend, p Policy, f Filename) error {
+	// Check the parent directory of f
+	d := filepath.Dir(string(f))
+	if e := checkInval(b, p, Directory(d)); e != nil {
+		return tserr.Check(&tserr.CheckArgs{F: string(f), Err: e})
+	}
+	// Retrieve the absolute path of f
+	a, err := filepath.Abs(string(f))
+	if err != nil {
+		return tserr.Check(&tserr.CheckArgs{F: string(f), Err: err})
+	}
+	// Retrieve the path of f in the evaluated parent directory
+	r, err := resolve(b, d)
+	if err != nil {
+		return tserr.Check(&tserr.CheckArgs{F: string(f), Err: err})
+	}
+	r = filepath.Join(r, filepath.Base(string(f)))
+	// Return an error reporting the matched blocked entry, if any
+	for _, x := range [2]string{a, r} {
+		if i := p.blocked(x); i != "" {
+			return tserr.Check(&tserr.CheckArgs{F: string(f), Err: errBlocked(i)})
 		}
 	}
 	// No error occurred, return nil
 	return nil
 }
 
+// resolve returns the absolute path of p with all symbolic links evaluated with Backend b. In contrast to filepath.EvalSymlinks,
+// p does not need to exist. The existing prefix of p is evaluated and the remaining path components which do not exist
+// are appended. The parent directory .. is applied to the evaluated path, as done by the operating system.
+// It returns an error, if the working directory cannot be retrieved, a symbolic link cannot be read or if more than
+// maxLinks symbolic links are evaluated.
+func resolve(b Backend, p string) (string, error) {
+	// Prepend the working directory to p, if p is relative
+	if !filepath.IsAbs(p) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		p = wd + string(filepath.Separator) + p
+	}
+	// Start with the root directory of the volume of p and the remaining path components of p
+	v := filepath.VolumeName(p)
+	r, c := v+string(filepath.Separator), split(p[len(v):])
+	// n holds the number of evaluated symbolic links and ex whether r exists
+	n, ex := 0, true
+	// Iterate over the remaining path components
+	for len(c) > 0 {
+		// Retrieve the next path component
+		e := c[0]
+		c = c[1:]
+		// Skip the current directory
+		if e == "." {
+			continue
+		}
+		// Apply the parent directory to the evaluated path, which may exist again
+		if e == ".." {
+			r, ex = filepath.Dir(r), true
+			continue
+		}
+		// Append the path component
+		next := filepath.Join(r, e)
+		// Append the remaining path components without evaluation, if the path does not exist
+		if !ex {
+			r = next
+			continue
+		}
+		// Retrieve FileInfo of the path without following a symbolic link
+		fi, err := b.Lstat(next)
+		// If the path does not exist or is not a symbolic link, continue with the next path component
+		if (err != nil) || (fi.Mode()&fs.ModeSymlink == 0) {
+			r, ex = next, err == nil
+			continue
+		}
+		// Return an error, if too many symbolic links are evaluated
+		if n++; n > maxLinks {
+			return "", tserr.Higher(&tserr.HigherArgs{Var: "number of symbolic links in " + p, Actual: int64(n), LowerBound: int64(maxLinks)})
+		}
+		// Read the target of the symbolic link
+		t, err := b.Readlink(next)
+		if err != nil {
+			return "", err
+		}
+		// Start over at the root directory of the volume of the target, if it is absolute
+		if filepath.IsAbs(t) {
+			v = filepath.VolumeName(t)
+			r, t = v+string(filepath.Separator), t[len(v):]
+		}
+		// Prepend the path components of the target to the remaining path components
+		c = append(split(t), c...)
+	}
+	// Return the evaluated path
+	return r, nil
+}
+
+// split returns the path components of p. Empty path components are dropped.
+func split(p string) []string {
+	return strings.FieldsFunc(p, isSeparator)
+}
+
+// isSeparator returns true, if rune r is a path separator. Path separators are ASCII characters, so a multi-byte
+// rune, e.g., U+012F, is never a path separator, even if its low byte is.
+func isSeparator(r rune) bool {
+	return (r < utf8.RuneSelf) && os.IsPathSeparator(uint8(r))
+}
+
 // Sprintf formats according to the format specifier and returns the resulting Filename or Directory
 func Sprintf[T Fio](f string, a ...any) T {
 	return T(fmt.Sprintf(f, a...))
@@ -120,18 +254,73 @@ func Sprintf[T Fio](f string, a ...any) T {
 // Path joins directory name d and a filename f into a single path p. It returns an empty string and an error if checks
 // on d, f and p fail. Path joins the path elements by using the Join function from the Go standard library package path/filepath.
 func Path(d Directory, f Filename) (Filename, error) {
+	return std.Path(d, f)
+}
+
+// Path performs the package function Path with the settings of fsys.
+func (fsys *FS) Path(d Directory, f Filename) (Filename, error) {
+	// Resolve d and f against the root directory of fsys
+	d, f = rooted(fsys, d), rooted(fsys, f)
 	// Return an error in case d contains a blocked directory or filename
-	if e := CheckDir(d); e != nil {
+	if e := fsys.CheckDir(d); e != nil {
 		return "", tserr.Check(&tserr.CheckArgs{F: string(d), Err: e})
 	}
 	// Return an error in case f contains a blocked directory or filename
-	if e := CheckFile(f); e != nil {
+	if e := fsys.CheckFile(f); e != nil {
 		return "", tserr.Check(&tserr.CheckArgs{F: string(f), Err: e})
 	}
 	p := Filename(filepath.Join(string(d), string(f)))
 	// Return an error in case p contains a blocked directory or filename
-	if e := CheckFile(p); e != nil {
+	if e := fsys.CheckFile(p); e != nil {
 		return "", tserr.Check(&tserr.CheckArgs{F: string(p), Err: e})
 	}
 	return p, nil
 }
+
+// inside returns true if Directory c equals Directory p or resides in p. Both, the absolute paths of p and c
+// and their paths with symbolic links evaluated with Backend b are compared on path component boundaries,
+// e.g., /foo/bar resides in /foo, but /foobar does not. It returns false and an error, if the absolute path
+// of p or c cannot be retrieved or if resolve fails.
+func inside(b Backend, p, c Directory) (bool, error) {
+	// Retrieve the absolute path of p
+	pa, err := filepath.Abs(string(p))
+	if err != nil {
+		return false, err
+	}
+	// Retrieve the absolute path of c
+	ca, err := filepath.Abs(string(c))
+	if err != nil {
+		return false, err
+	}
+	// Return true, if c resides in p on path component boundaries
+	if within(pa, ca) {
+		return true, nil
+	}
+	// Retrieve the path of p with symbolic links evaluated
+	pr, err := resolve(b, string(p))
+	if err != nil {
+		return false, err
+	}
+	// Retrieve the path of c with symbolic links evaluated
+	cr, err := resolve(b, string(c))
+	if err != nil {
+		return false, err
+	}
+	// Return the result of the comparison of the evaluated paths, since c may reach into p by a symbolic link
+	return within(normCase(pr), normCase(cr)), nil
+}
+
+// within returns true if the cleaned path c equals the cleaned path p or resides in p.
+// The paths are compared on path component boundaries.
+func within(p, c string) bool {
+	// Return true, if c equals p
+	if c == p {
+		return true
+	}
+	// Append a path separator to p, if it does not already end with a separator, e.g., for the root directory
+	if !strings.HasSuffix(p, string(filepath.Separator)) {
+		p += string(filepath.Separator)
+	}
+	// Return true, if c resides in p
+	return strings.HasPrefix(c, p)
+}
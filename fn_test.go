@@ -42,6 +42,117 @@ func TestBlockedFile(t *testing.T) {
 	}
 }
 
+// TestBlockedRelative tests if CheckFile and CheckDir return an error for relative paths to all blocked
+// directories in invalDir. If they return nil for a relative path to a blocked directory, the test fails.
+func TestBlockedRelative(t *testing.T) {
+	// Retrieve the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "Getwd", Fn: ".", Err: err}))
+	}
+	// Iterate test over all directories in invalDir
+	for _, d := range tsfio.InvalDir() {
+		// Retrieve the relative path from the working directory to the blocked directory
+		r, e := filepath.Rel(wd, string(d))
+		// Skip the blocked directory, if it is not reachable by a relative path, e.g., on another volume
+		if e != nil {
+			continue
+		}
+		// If CheckDir returns nil, then the test fails
+		if tsfio.CheckDir(tsfio.Directory(r)) == nil {
+			t.Error(tserr.NilFailed(fmt.Sprintf("CheckDir of %v", r)))
+		}
+		// If CheckFile returns nil for a file in the blocked directory, then the test fails
+		if f := tsfio.Filename(filepath.Join(r, string(testfile))); tsfio.CheckFile(f) == nil {
+			t.Error(tserr.NilFailed(fmt.Sprintf("CheckFile of %v", f)))
+		}
+	}
+}
+
+// TestBlockedSymlink tests if CheckFile and CheckDir return an error for symbolic links to all blocked
+// directories in invalDir. If they return nil for a symbolic link to a blocked directory, the test fails.
+// The test is skipped if symbolic links cannot be created.
+func TestBlockedSymlink(t *testing.T) {
+	// Create a temporary directory td
+	td := tmpDir(t)
+	// Iterate test over all directories in invalDir
+	for i, d := range tsfio.InvalDir() {
+		// Create symbolic link l in td pointing to the blocked directory
+		l := filepath.Join(string(td), fmt.Sprint(i))
+		if e := os.Symlink(string(d), l); e != nil {
+			rmAll(t, td)
+			t.Skip(e)
+		}
+		// If CheckDir returns nil, then the test fails
+		if tsfio.CheckDir(tsfio.Directory(l)) == nil {
+			t.Error(tserr.NilFailed(fmt.Sprintf("CheckDir of %v", l)))
+		}
+		// If CheckFile returns nil for a file in the linked blocked directory, then the test fails
+		if f := tsfio.Filename(filepath.Join(l, string(testfile))); tsfio.CheckFile(f) == nil {
+			t.Error(tserr.NilFailed(fmt.Sprintf("CheckFile of %v", f)))
+		}
+		// If CheckFile returns nil for a file reached by the parent directory of the symbolic link, then the test fails
+		if f := tsfio.Filename(filepath.Join(string(td), fmt.Sprint(i), string(testfile), "..", string(testfile))); tsfio.CheckFile(f) == nil {
+			t.Error(tserr.NilFailed(fmt.Sprintf("CheckFile of %v", f)))
+		}
+	}
+	// Remove td
+	rmAll(t, td)
+}
+
+// TestBlockedSymlinkNonASCII tests CheckFile to evaluate a symbolic link with a non-ASCII path component next to the
+// parent directory .., where the low byte of a rune equals a path separator, e.g., U+012F. The test fails if CheckFile
+// returns nil for a file reached by the symbolic link in a blocked directory or an error for a file in a directory,
+// which is not blocked and only differs by the rune. The test is skipped if symbolic links cannot be created.
+func TestBlockedSymlinkNonASCII(t *testing.T) {
+	// Create temporary directories d and o
+	d, o := tmpDir(t), tmpDir(t)
+	// Create directory aįb in d
+	if e := os.Mkdir(filepath.Join(string(d), "a\u012fb"), 0755); e != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "Mkdir", Fn: string(d), Err: e}))
+	}
+	// Create symbolic link l in d pointing to o by the parent directory of d. The target is not cleaned.
+	sep := string(filepath.Separator)
+	l := filepath.Join(string(d), string(testfile))
+	if e := os.Symlink("a\u012fb"+sep+".."+sep+".."+sep+filepath.Base(string(o)), l); e != nil {
+		rmAll(t, d)
+		rm(t, o)
+		t.Skip(e)
+	}
+	// Create a policy blocking o and the directory boot in d
+	p := tsfio.DefaultPolicy()
+	p.BlockedDirs = append(p.BlockedDirs, o, tsfio.Directory(filepath.Join(string(d), "boot")))
+	// The test fails if CheckFile returns nil for a file in o reached by l
+	if f := tsfio.Filename(filepath.Join(l, string(testfile))); p.CheckFile(f) == nil {
+		t.Error(tserr.NilFailed(fmt.Sprintf("CheckFile of %v", f)))
+	}
+	// The test fails if CheckFile returns an error for a file in bootį, which is not blocked
+	if f := tsfio.Filename(filepath.Join(string(d), "boot\u012f", string(testfile))); p.CheckFile(f) != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "CheckFile", Fn: string(f), Err: p.CheckFile(f)}))
+	}
+	// Remove d and o
+	rmAll(t, d)
+	rm(t, o)
+}
+
+// TestBlockedPrefix tests if CheckDir returns nil for directories, which share a prefix with a
+// blocked directory in invalDir but are not contained in it. If it returns an error, the test fails.
+func TestBlockedPrefix(t *testing.T) {
+	// Iterate test over all directories in invalDir
+	for _, d := range tsfio.InvalDir() {
+		// Create test Directory p sharing its prefix with the blocked directory
+		p := d + tsfio.Directory(testfile)
+		// If CheckDir returns an error, then the test fails
+		if err := tsfio.CheckDir(p); err != nil {
+			t.Error(tserr.Return(&tserr.ReturnArgs{
+				Op:     fmt.Sprintf("CheckDir of %v", p),
+				Actual: fmt.Sprint(err),
+				Want:   "nil",
+			}))
+		}
+	}
+}
+
 // TestEmptyDir tests if CheckDir returns an error for an empty string
 // as Directory. If it returns nil for the empty string as Directory,
 // the test fails.
@@ -0,0 +1,112 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio
+
+// Import standard library packages
+import (
+	"io/fs"         // fs
+	"path/filepath" // filepath
+)
+
+// An FS holds the settings for file input output with its methods. The methods of FS mirror the package
+// functions for file input output, e.g., OpenFile, WriteStr, AppendFile and CreateDir, and use the settings
+// of FS instead of the defaults. The package functions use a default FS with the zero value. Therefore, two
+// components in the same process can use different settings with their own FS. The zero value of FS uses
+// the default flags, file mode, directory mode, the package policy, maximum read size and durability, the working
+// directory and the operating system as Backend. With a MemBackend, code using an FS can be tested without a file
+// system.
+// An FS must not be changed while its methods are in use.
+type FS struct {
+	FileMode   fs.FileMode // FileMode holds the file mode and permission bits of created files. If zero, 0644 is used.
+	DirMode    fs.FileMode // DirMode holds the directory mode and permission bits of created directories. If zero, 0755 is used.
+	Flags      int         // Flags holds the flags for opening files with OpenFile. If zero, os.O_APPEND|os.O_CREATE|os.O_RDWR is used.
+	Policy     *Policy     // Policy holds the policy for checks of files and directories. If nil, the package policy is used.
+	Root       Directory   // Root holds the directory for relative filenames and directories. If empty, the working directory is used.
+	Backend    Backend     // Backend holds the storage of files and directories. If nil, OSBackend is used.
+	MaxRead    int64       // MaxRead holds the maximum size of files read by ReadFile. If zero, the package maximum is used.
+	Durability Durability  // Durability holds the durability of writing functions. If zero, the package durability is used.
+}
+
+// std holds the default FS used by the package functions
+var std = &FS{}
+
+// fileMode returns the file mode and permission bits of created files of fsys
+func (fsys *FS) fileMode() fs.FileMode {
+	// Return the default, if FileMode is not set
+	if fsys.FileMode == 0 {
+		return fperm
+	}
+	return fsys.FileMode
+}
+
+// dirMode returns the directory mode and permission bits of created directories of fsys
+func (fsys *FS) dirMode() fs.FileMode {
+	// Return the default, if DirMode is not set
+	if fsys.DirMode == 0 {
+		return dperm
+	}
+	return fsys.DirMode
+}
+
+// flags returns the flags for opening files of fsys
+func (fsys *FS) flags() int {
+	// Return the default, if Flags is not set
+	if fsys.Flags == 0 {
+		return flags
+	}
+	return fsys.Flags
+}
+
+// policy returns the policy of fsys
+func (fsys *FS) policy() Policy {
+	// Return the package policy, if Policy is not set
+	if fsys.Policy == nil {
+		return policy()
+	}
+	return *fsys.Policy
+}
+
+// maxRead returns the maximum size of files read by ReadFile of fsys. It returns zero, if the size is not limited.
+func (fsys *FS) maxRead() int64 {
+	// Return the package maximum, if MaxRead is not set
+	if fsys.MaxRead == 0 {
+		return maxRead.Load()
+	}
+	return fsys.MaxRead
+}
+
+// durability returns the durability of writing functions of fsys
+func (fsys *FS) durability() Durability {
+	// Return the package durability, if Durability is not set
+	if fsys.Durability == 0 {
+		return orNone(Durability(durability.Load()))
+	}
+	return fsys.Durability
+}
+
+// backend returns the Backend of fsys
+func (fsys *FS) backend() Backend {
+	// Return the operating system, if Backend is not set
+	if fsys.Backend == nil {
+		return OSBackend{}
+	}
+	return fsys.Backend
+}
+
+// rooted returns f resolved against the root directory of fsys. If f is empty or absolute or if fsys has no
+// root directory, it returns f unchanged. Otherwise, it returns the absolute path of f in the root directory.
+// Therefore, rooted can be applied multiple times to the same f.
+func rooted[T Fio](fsys *FS, f T) T {
+	// Return f unchanged, if f is empty or absolute or if the root directory is not set
+	if (fsys.Root == "") || (f == "") || filepath.IsAbs(string(f)) {
+		return f
+	}
+	// Retrieve the absolute path of the root directory. Use the root directory as is, if it fails.
+	r, err := filepath.Abs(string(fsys.Root))
+	if err != nil {
+		r = string(fsys.Root)
+	}
+	// Return f in the root directory
+	return T(filepath.Join(r, string(f)))
+}
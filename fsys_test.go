@@ -0,0 +1,126 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio_test
+
+// Import standard library packages as well as tserr and tsfio
+import (
+	"fmt"           // fmt
+	"io/fs"         // fs
+	"os"            // os
+	"path/filepath" // filepath
+	"runtime"       // runtime
+	"testing"       // testing
+
+	"github.com/thorstenrie/tserr" // tserr
+	"github.com/thorstenrie/tsfio" // tsfio
+)
+
+// TestFSRoot tests an FS with a root directory to write and read a relative filename in the root directory.
+// The test fails if WriteStr or ReadFile return an error or if the file does not reside in the root directory.
+func TestFSRoot(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Create FS with root directory d
+	fsys := &tsfio.FS{Root: d}
+	// Write testcase to the relative filename testfile
+	if e := fsys.WriteStr(testfile, testcase); e != nil {
+		// If WriteStr returns an error, the test fails
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "WriteStr", Fn: string(testfile), Err: e}))
+	}
+	// Read the relative filename testfile
+	b, err := fsys.ReadFile(testfile)
+	// If ReadFile returns an error, the test fails
+	if err != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "ReadFile", Fn: string(testfile), Err: err}))
+	}
+	// If b does not equal testcase, the test fails
+	if string(b) != testcase {
+		t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: string(testfile), Actual: string(b), Want: testcase}))
+	}
+	// The test fails if the file does not reside in d
+	checkTree(t, d, map[string]string{string(testfile): testcase})
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestFSMode tests an FS with a file mode and directory mode to create a directory and a file. The test fails if
+// CreateDir or TouchFile return an error or if the permission bits differ. The test is skipped on Windows.
+func TestFSMode(t *testing.T) {
+	// Skip test on Windows, which only supports the read-only permission bit
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not supported on Windows")
+	}
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Create FS with root directory d, file mode and directory mode
+	fsys := &tsfio.FS{Root: d, FileMode: 0600, DirMode: 0700}
+	// Create directory testdir and file testfile in testdir
+	fn := tsfio.Filename(filepath.Join(string(testdir), string(testfile)))
+	if e := fsys.CreateDir(testdir); e != nil {
+		// If CreateDir returns an error, the test fails
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "CreateDir", Fn: string(testdir), Err: e}))
+	}
+	if e := fsys.TouchFile(fn); e != nil {
+		// If TouchFile returns an error, the test fails
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "TouchFile", Fn: string(fn), Err: e}))
+	}
+	// The test fails if the permission bits differ
+	for n, m := range map[string]fs.FileMode{string(testdir): 0700, string(fn): 0600} {
+		// Retrieve FileInfo
+		fi, err := os.Stat(filepath.Join(string(d), n))
+		if err != nil {
+			t.Error(tserr.Op(&tserr.OpArgs{Op: "FileInfo (Stat) of", Fn: n, Err: err}))
+			continue
+		}
+		// The test fails if the permission bits differ
+		if fi.Mode().Perm() != m {
+			t.Error(tserr.Equal(&tserr.EqualArgs{Var: fmt.Sprintf("permission bits of %v", n), Actual: int64(fi.Mode().Perm()), Want: int64(m)}))
+		}
+	}
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestFSFlags tests an FS with flags truncating files when opened. The test fails if WriteStr returns an error
+// or if the file does not hold a single testcase after writing it twice.
+func TestFSFlags(t *testing.T) {
+	// Create temporary file fn
+	fn := tmpFile(t)
+	// Create FS with flags truncating files
+	fsys := &tsfio.FS{Flags: os.O_CREATE | os.O_TRUNC | os.O_WRONLY}
+	// Write testcase twice to fn
+	for i := 0; i < 2; i++ {
+		if e := fsys.WriteStr(fn, testcase); e != nil {
+			// If WriteStr returns an error, the test fails
+			t.Error(tserr.Op(&tserr.OpArgs{Op: "WriteStr", Fn: string(fn), Err: e}))
+		}
+	}
+	// The test fails if fn does not hold a single testcase
+	checkTree(t, tsfio.Directory(filepath.Dir(string(fn))), map[string]string{filepath.Base(string(fn)): testcase})
+	// Remove fn
+	rm(t, fn)
+}
+
+// TestFSPolicy tests an FS with a policy blocking a temporary directory. The test fails if WriteStr of the FS returns nil
+// for a file in the blocked directory or if the package function WriteStr returns an error.
+func TestFSPolicy(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Create a policy blocking d
+	p := tsfio.DefaultPolicy()
+	p.BlockedDirs = append(p.BlockedDirs, d)
+	// Create FS with root directory d and the policy
+	fsys := &tsfio.FS{Root: d, Policy: &p}
+	// The test fails if WriteStr of the FS returns nil
+	if e := fsys.WriteStr(testfile, testcase); e == nil {
+		t.Error(tserr.NilFailed("WriteStr"))
+	}
+	// The test fails if the package function WriteStr returns an error
+	fn := tsfio.Filename(filepath.Join(string(d), string(testfile)))
+	if e := tsfio.WriteStr(fn, testcase); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "WriteStr", Fn: string(fn), Err: e}))
+	}
+	// Remove d
+	rmAll(t, d)
+}
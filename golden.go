@@ -3,8 +3,21 @@
 // that can be found in the LICENSE file.
 package tsfio
 
-// Import package tserr
-import "github.com/thorstenrie/tserr" // tserr
+// Import standard library packages and tserr
+import (
+	"errors"        // errors
+	"flag"          // flag
+	"io/fs"         // fs
+	"os"            // os
+	"path"          // path
+	"path/filepath" // filepath
+	"slices"        // slices
+	"strconv"       // strconv
+	"sync"          // sync
+	"testing"       // testing
+
+	"github.com/thorstenrie/tserr" // tserr
+)
 
 // Default directory and file type of golden files
 const (
@@ -12,6 +25,62 @@ const (
 	goldenFileType string    = ".golden"   // Default golden file type
 )
 
+// Environment variable and test flag enabling the update mode of golden files
+const (
+	goldenUpdateEnv  string = "TSFIO_UPDATE_GOLDEN" // Environment variable enabling the update mode, e.g., TSFIO_UPDATE_GOLDEN=1
+	goldenUpdateFlag string = "update"              // Name of the boolean test flag enabling the update mode
+)
+
+// The golden files updated in update mode are protected by the mutex mupd
+var (
+	mupd    sync.Mutex // mupd protects updated
+	updated []Filename // updated holds the golden files updated by EvalGoldenFile
+)
+
+// init defines the boolean test flag -update enabling the update mode in test binaries, unless it is already defined.
+// The flag is not defined in other binaries, so their flags are not changed.
+func init() {
+	if testing.Testing() && (flag.Lookup(goldenUpdateFlag) == nil) {
+		flag.Bool(goldenUpdateFlag, false, "update golden files")
+	}
+}
+
+// A Golden defines the layout of golden files. The golden files are stored in directory Dir with file type Ext.
+// The package golden file functions use the zero value of Golden, so packages with a different layout of test
+// fixtures or with volatile content in test data, see Scrubber, use their own Golden with the methods mirroring the
+// package golden file functions, e.g.,
+//
+//	g := &tsfio.Golden{Dir: "fixtures", Ext: ".out", Perm: 0o600}
+//	err := g.EvalGoldenFile(tc)
+type Golden struct {
+	Dir       Directory   // Dir holds the golden files directory. If empty, testdata/ is used.
+	Ext       string      // Ext holds the golden file type. If empty, .golden is used.
+	Flat      bool        // Flat stores the golden files of AssertGolden in Dir with names joined by underscores instead of subdirectories per test
+	Perm      fs.FileMode // Perm holds the permission bits of created golden files. If zero, the default permission bits are used.
+	Scrubbers []Scrubber  // Scrubbers hold the Scrubbers applied in the given order to testcase data before writing and comparing it
+}
+
+// stdGolden holds the default Golden used by the package golden file functions
+var stdGolden = &Golden{}
+
+// dir returns the golden files directory of g
+func (g *Golden) dir() Directory {
+	// Return the default, if Dir is not set
+	if g.Dir == "" {
+		return goldenDir
+	}
+	return g.Dir
+}
+
+// ext returns the golden file type of g
+func (g *Golden) ext() string {
+	// Return the default, if Ext is not set
+	if g.Ext == "" {
+		return goldenFileType
+	}
+	return g.Ext
+}
+
 // A Testcase contains the name of a testcase and the corresponding data of the testcase.
 // The data can be reference data or test data.
 type Testcase struct {
@@ -21,33 +90,46 @@ type Testcase struct {
 
 // GoldenFilePath returns the path of the test data golden file for the provided name of a testcase.
 // The golden files are stored in the default golden files directory testdata/ and have the default
-// golden file type .golden.
+// golden file type .golden. A name with slashes resides in subdirectories of testdata/.
 func GoldenFilePath(name string) (Filename, error) {
-	return Path(goldenDir, Filename(name+goldenFileType))
+	return stdGolden.GoldenFilePath(name)
+}
+
+// GoldenFilePath performs the package function GoldenFilePath with the settings of g.
+func (g *Golden) GoldenFilePath(name string) (Filename, error) {
+	return Path(g.dir(), Filename(name+g.ext()))
 }
 
 // CreateGoldenFile creates a golden file provided by the testcase name. The data in the testcase is written to
-// the golden file. The golden file is stored in the default golden files directory testdata/ and has the default
-// golden file type .golden.
+// the golden file with normalized new lines. An existing golden file is replaced atomically. The golden file is stored in the default
+// golden files directory testdata/ and has the default golden file type .golden.
 func CreateGoldenFile(tc *Testcase) error {
+	return stdGolden.CreateGoldenFile(tc)
+}
+
+// CreateGoldenFile performs the package function CreateGoldenFile with the settings of g. The golden file is
+// created with the permission bits Perm of g.
+func (g *Golden) CreateGoldenFile(tc *Testcase) error {
 	// Return an error if tc is nil
 	if tc == nil {
 		return tserr.NilPtr()
 	}
-	if e := CreateDir(goldenDir); e != nil {
-		return tserr.Op(&tserr.OpArgs{Op: "CreateDir", Fn: string(goldenDir), Err: e})
-	}
 	// Retrieve golden file path for testcase name
-	fn, e := GoldenFilePath(tc.Name)
+	fn, e := g.GoldenFilePath(tc.Name)
 	// Return an error if goldenPath fails
 	if e != nil {
 		return tserr.Op(&tserr.OpArgs{Op: "goldenPath", Fn: tc.Name, Err: e})
 	}
-	// Write the data from the testcase to the golden file
-	e = WriteSingleStr(fn, tc.Data)
-	// Return an error if WriteSingleStr fails
+	// Create the directory of the golden file, which is a subdirectory of testdata/ for a testcase name with slashes
+	if e := CreateDir(Directory(filepath.Dir(string(fn)))); e != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "CreateDir", Fn: filepath.Dir(string(fn)), Err: e})
+	}
+	// Atomically write the data from the testcase with normalized new lines and scrubbed by the Scrubbers of g to
+	// the golden file with the permission bits of g
+	e = (&FS{FileMode: g.Perm}).WriteAtomicStr(fn, g.test(tc))
+	// Return an error if WriteAtomicStr fails
 	if e != nil {
-		return tserr.Op(&tserr.OpArgs{Op: "WriteSingleStr", Fn: string(fn), Err: e})
+		return tserr.Op(&tserr.OpArgs{Op: "WriteAtomicStr", Fn: string(fn), Err: e})
 	}
 	// Return nil
 	return nil
@@ -55,32 +137,150 @@ func CreateGoldenFile(tc *Testcase) error {
 
 // EvalGoldenFile evaluates the testcase if it equals the test data from the golden file provided by the testcase name.
 // It returns an error if the testcase data does not equal the contents of the golden file. The golden file must reside in the default golden files directory
-// testdata/ with the default golden file type .golden.
+// testdata/ with the default golden file type .golden. In update mode, see GoldenUpdate, a golden file, which does not
+// exist or does not equal the testcase data, is replaced with the testcase data by CreateGoldenFile instead of returning
+// an error. The replaced golden files are returned by UpdatedGoldenFiles.
 func EvalGoldenFile(tc *Testcase) error {
+	return stdGolden.EvalGoldenFile(tc)
+}
+
+// EvalGoldenFile performs the package function EvalGoldenFile with the settings of g.
+func (g *Golden) EvalGoldenFile(tc *Testcase) error {
+	_, e := g.evalGoldenFile(tc)
+	return e
+}
+
+// evalGoldenFile performs EvalGoldenFile with the settings of g and returns true, if the golden file is replaced
+// in update mode.
+func (g *Golden) evalGoldenFile(tc *Testcase) (bool, error) {
 	// Return an error if tc is nil
 	if tc == nil {
-		return tserr.NilPtr()
+		return false, tserr.NilPtr()
 	}
 	// Retrieve golden file path for testcase name
-	fn, e := GoldenFilePath(tc.Name)
+	fn, e := g.GoldenFilePath(tc.Name)
 	// Return an error if goldenPath fails
 	if e != nil {
-		return tserr.Op(&tserr.OpArgs{Op: "goldenPath", Fn: tc.Name, Err: e})
+		return false, tserr.Op(&tserr.OpArgs{Op: "goldenPath", Fn: tc.Name, Err: e})
 	}
 	// Retrieve the reference data from golden file provided by the testcase name
 	ref, e := ReadFile(fn)
+	// In update mode, replace a golden file, which does not exist or does not equal the testcase data
+	if GoldenUpdate() && (errors.Is(e, ErrNotExist) || ((e == nil) && !g.eqGolden(tc, ref))) {
+		return true, g.updateGolden(tc, fn)
+	}
 	// Return an error if ReadFile fails
 	if e != nil {
-		return tserr.Op(&tserr.OpArgs{Op: "ReadFile", Fn: string(fn), Err: e})
+		return false, tserr.Op(&tserr.OpArgs{Op: "ReadFile", Fn: string(fn), Err: e})
+	}
+	// Evaluate the testcase with the reference data
+	return false, g.evalGolden(tc, ref, fn)
+}
+
+// GoldenUpdate returns true, if the update mode of golden files is enabled. The update mode is enabled, if the
+// environment variable TSFIO_UPDATE_GOLDEN holds a true boolean value as accepted by strconv.ParseBool, e.g., 1 or true,
+// or if the boolean test flag -update is set. Tsfio defines the flag in test binaries, so test packages do not define
+// it. Then, all golden files of the package are regenerated with
+//
+//	go test -update
+//
+// and the golden files of all packages with
+//
+//	TSFIO_UPDATE_GOLDEN=1 go test ./...
+func GoldenUpdate() bool {
+	// Return true, if the environment variable holds a true boolean value
+	if b, e := strconv.ParseBool(os.Getenv(goldenUpdateEnv)); (e == nil) && b {
+		return true
 	}
-	// Normalize new lines in ref
-	refn := NormNewlinesStr(string(ref))
-	// Normalize new lines in test data
-	test := NormNewlinesStr(tc.Data)
-	// Return an error if the testcase data does not equal the contents of the golden file
-	if test != refn {
-		return tserr.EqualStr(&tserr.EqualStrArgs{Var: tc.Name, Actual: tc.Data, Want: string(ref)})
+	// Return the value of the boolean test flag, if it is defined
+	if f := flag.Lookup(goldenUpdateFlag); f != nil {
+		if g, ok := f.Value.(flag.Getter); ok {
+			b, _ := g.Get().(bool)
+			return b
+		}
 	}
-	// Return nil
+	// Otherwise, the update mode is not enabled
+	return false
+}
+
+// UpdatedGoldenFiles returns the sorted paths of the golden files replaced by EvalGoldenFile in update mode since
+// the start of the process, e.g., to report them in TestMain.
+func UpdatedGoldenFiles() []Filename {
+	// Lock the updated golden files
+	mupd.Lock()
+	defer mupd.Unlock()
+	// Return a sorted copy of the updated golden files
+	u := slices.Clone(updated)
+	slices.Sort(u)
+	return u
+}
+
+// updateGolden replaces golden file fn with the data of testcase tc by CreateGoldenFile of g and keeps fn in the
+// updated golden files. It returns an error, if any.
+func (g *Golden) updateGolden(tc *Testcase, fn Filename) error {
+	// Replace the golden file
+	if e := g.CreateGoldenFile(tc); e != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "CreateGoldenFile", Fn: string(fn), Err: e})
+	}
+	// Keep fn in the updated golden files, once
+	mupd.Lock()
+	defer mupd.Unlock()
+	if !slices.Contains(updated, fn) {
+		updated = append(updated, fn)
+	}
+	// No error occurred, return nil
 	return nil
 }
+
+// EvalGoldenFileFS evaluates the testcase if it equals the test data from the golden file provided by the testcase name
+// in the file system src. The golden file must reside in the golden files directory testdata/ of src with the default golden
+// file type .golden. Therefore, golden files can be embedded into the test binary, e.g., with an embed.FS holding testdata.
+// Since src is read-only, golden files are not replaced in update mode.
+// It returns an error if src or tc is nil, if the golden file cannot be read or if the testcase data does not equal the
+// contents of the golden file.
+func EvalGoldenFileFS(src fs.FS, tc *Testcase) error {
+	return stdGolden.EvalGoldenFileFS(src, tc)
+}
+
+// EvalGoldenFileFS performs the package function EvalGoldenFileFS with the settings of g. The golden file resides
+// in directory Dir of g in src.
+func (g *Golden) EvalGoldenFileFS(src fs.FS, tc *Testcase) error {
+	// Return an error if tc is nil
+	if tc == nil {
+		return tserr.NilPtr()
+	}
+	// Retrieve the slash-separated golden file path for testcase name
+	fn := Filename(path.Join(filepath.ToSlash(string(g.dir())), tc.Name+g.ext()))
+	// Retrieve the reference data from golden file in src
+	ref, e := ReadFileFS(src, fn)
+	// Return an error if ReadFileFS fails
+	if e != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "ReadFileFS", Fn: string(fn), Err: e})
+	}
+	// Evaluate the testcase with the reference data
+	return g.evalGolden(tc, ref, fn)
+}
+
+// test returns the test data of testcase tc with normalized new lines and scrubbed by the Scrubbers of g.
+func (g *Golden) test(tc *Testcase) string {
+	return scrub(NormNewlinesStr(tc.Data), g.Scrubbers)
+}
+
+// eqGolden returns true, if the data of testcase tc equals the reference data ref with normalized new lines. The
+// testcase data is scrubbed by the Scrubbers of g.
+func (g *Golden) eqGolden(tc *Testcase, ref []byte) bool {
+	return g.test(tc) == NormNewlinesStr(string(ref))
+}
+
+// evalGolden evaluates the testcase tc if it equals the reference data ref of golden file fn with normalized new lines.
+// The testcase data is scrubbed by the Scrubbers of g. It returns an error if the testcase data does not equal ref.
+// The error holds the unified diff of ref and the testcase data, see Diff.
+func (g *Golden) evalGolden(tc *Testcase, ref []byte, fn Filename) error {
+	// Return nil, if the testcase data equals the contents of the golden file
+	if g.eqGolden(tc, ref) {
+		return nil
+	}
+	// Return an error with the diff of the normalized contents of the golden file and the testcase data
+	d := DiffWith(NormNewlinesStr(string(ref)), g.test(tc), DiffOptions{NameA: string(fn), NameB: tc.Name})
+	return tserr.Op(&tserr.OpArgs{Op: "compare test data of " + tc.Name + " with golden file", Fn: string(fn), Err: errors.New("\n" + d)})
+}
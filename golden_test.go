@@ -5,7 +5,15 @@ package tsfio_test
 
 // Import standard library packages as well as tserr and tsfio
 import (
-	"testing" // testing
+	"flag"           // flag
+	"fmt"            // fmt
+	"os"             // os
+	"path/filepath"  // filepath
+	"runtime"        // runtime
+	"slices"         // slices
+	"strings"        // strings
+	"testing"        // testing
+	"testing/fstest" // fstest
 
 	"github.com/thorstenrie/tserr" // tserr
 	"github.com/thorstenrie/tsfio" // tsfio
@@ -88,3 +96,159 @@ func TestEvalGoldenFileNil(t *testing.T) {
 		t.Error(tserr.NilFailed("EvalGoldenFile"))
 	}
 }
+
+// TestEvalGoldenFileFS tests EvalGoldenFileFS to evaluate testcases with a golden file in an fstest.MapFS. The test fails
+// if EvalGoldenFileFS returns an error for an equal testcase or nil for a testcase, which is not equal.
+func TestEvalGoldenFileFS(t *testing.T) {
+	// Create the file system src holding the golden file of testcase
+	src := fstest.MapFS{"testdata/" + testcase + ".golden": &fstest.MapFile{Data: []byte(testcase_win)}}
+	// The test fails if EvalGoldenFileFS returns an error for an equal testcase
+	if e := tsfio.EvalGoldenFileFS(src, &tsfio.Testcase{Name: testcase, Data: testcase_unix}); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "EvalGoldenFileFS", Fn: testcase, Err: e}))
+	}
+	// The test fails if EvalGoldenFileFS returns nil for a testcase, which is not equal
+	if e := tsfio.EvalGoldenFileFS(src, &tsfio.Testcase{Name: testcase, Data: testcase}); e == nil {
+		t.Error(tserr.NilFailed("EvalGoldenFileFS"))
+	}
+}
+
+// TestEvalGoldenFileFSErr tests EvalGoldenFileFS to return an error if the testcase is nil or if the golden file does not
+// exist. The test fails if EvalGoldenFileFS returns nil instead of an error.
+func TestEvalGoldenFileFSErr(t *testing.T) {
+	if e := tsfio.EvalGoldenFileFS(fstest.MapFS{}, nil); e == nil {
+		t.Error(tserr.NilFailed("EvalGoldenFileFS"))
+	}
+	if e := tsfio.EvalGoldenFileFS(fstest.MapFS{}, &tsfio.Testcase{Name: testcase, Data: testcase}); e == nil {
+		t.Error(tserr.NilFailed("EvalGoldenFileFS"))
+	}
+}
+
+// TestGoldenUpdate tests EvalGoldenFile in update mode enabled by the environment variable TSFIO_UPDATE_GOLDEN to create
+// a missing golden file, to replace a golden file, which is not equal, and to report both with UpdatedGoldenFiles.
+// The test fails if EvalGoldenFile returns an error, if the golden files do not hold the testcase data or if the
+// updated golden files differ.
+func TestGoldenUpdate(t *testing.T) {
+	// The test fails if the update mode is enabled without the environment variable
+	t.Setenv("TSFIO_UPDATE_GOLDEN", "")
+	if tsfio.GoldenUpdate() {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "GoldenUpdate", Actual: "true", Want: "false"}))
+	}
+	// Enable the update mode
+	t.Setenv("TSFIO_UPDATE_GOLDEN", "1")
+	// Create golden file a and testcases a, b and c. Golden file b does not exist, c is equal.
+	tcs := []*tsfio.Testcase{{Name: testcase + "_a", Data: testcase}, {Name: testcase + "_b", Data: testcase}, {Name: testcase + "_c", Data: testcase}}
+	for _, tc := range []*tsfio.Testcase{{Name: tcs[0].Name, Data: testcase_unix}, tcs[2]} {
+		if e := tsfio.CreateGoldenFile(tc); e != nil {
+			t.Error(tserr.Op(&tserr.OpArgs{Op: "CreateGoldenFile", Fn: tc.Name, Err: e}))
+		}
+	}
+	// Retrieve the golden file paths
+	fns := make([]tsfio.Filename, len(tcs))
+	for i, tc := range tcs {
+		fns[i], _ = tsfio.GoldenFilePath(tc.Name)
+	}
+	// The test fails if EvalGoldenFile returns an error
+	for _, tc := range tcs {
+		if e := tsfio.EvalGoldenFile(tc); e != nil {
+			t.Error(tserr.Op(&tserr.OpArgs{Op: "EvalGoldenFile", Fn: tc.Name, Err: e}))
+		}
+	}
+	// The test fails if the updated golden files do not contain a and b or contain c
+	u := tsfio.UpdatedGoldenFiles()
+	if !slices.Contains(u, fns[0]) || !slices.Contains(u, fns[1]) || slices.Contains(u, fns[2]) {
+		t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: "updated golden files", Actual: fmt.Sprint(u), Want: fmt.Sprint(fns[:2])}))
+	}
+	// The test fails if the golden files do not hold the testcase data. Remove the golden files.
+	t.Setenv("TSFIO_UPDATE_GOLDEN", "")
+	for i, tc := range tcs {
+		if e := tsfio.EvalGoldenFile(tc); e != nil {
+			t.Error(tserr.Op(&tserr.OpArgs{Op: "EvalGoldenFile", Fn: tc.Name, Err: e}))
+		}
+		if e := tsfio.RemoveFile(fns[i]); e != nil {
+			t.Error(tserr.Op(&tserr.OpArgs{Op: "RemoveFile", Fn: string(fns[i]), Err: e}))
+		}
+	}
+}
+
+// TestGoldenConfig tests the methods of a Golden with directory, file type, flat names and permission bits to create
+// and evaluate golden files in its directory. The test fails if any of them returns an error, if the golden files do
+// not reside in the directory of the Golden with its file type or if the permission bits differ.
+func TestGoldenConfig(t *testing.T) {
+	// Create temporary directory d and the Golden g
+	d := tmpDir(t)
+	g := &tsfio.Golden{Dir: d, Ext: ".out", Flat: true, Perm: 0600}
+	tc := &tsfio.Testcase{Name: "a", Data: testcase}
+	// The test fails if CreateGoldenFile or EvalGoldenFile returns an error
+	if e := g.CreateGoldenFile(tc); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "CreateGoldenFile", Fn: tc.Name, Err: e}))
+	}
+	if e := g.EvalGoldenFile(tc); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "EvalGoldenFile", Fn: tc.Name, Err: e}))
+	}
+	// The test fails if the golden file path or the permission bits differ
+	fn, e := g.GoldenFilePath(tc.Name)
+	if w := tsfio.Filename(filepath.Join(string(d), "a.out")); (e != nil) || (fn != w) {
+		t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: "GoldenFilePath", Actual: string(fn), Want: string(w)}))
+	}
+	if fi, e := os.Stat(string(fn)); (e == nil) && (runtime.GOOS != "windows") && (fi.Mode().Perm() != 0600) {
+		t.Error(tserr.Equal(&tserr.EqualArgs{Var: "permission bits of " + string(fn), Actual: int64(fi.Mode().Perm()), Want: 0600}))
+	}
+	// The test fails if AssertGolden reports the flat golden file of the test or if it does not exist in d
+	if e := g.CreateGoldenFile(&tsfio.Testcase{Name: "TestGoldenConfig_b", Data: testcase}); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "CreateGoldenFile", Fn: "TestGoldenConfig_b", Err: e}))
+	}
+	r := &recTB{TB: t}
+	g.AssertGolden(r, "b", []byte(testcase))
+	testRec(t, r, [3]int{0, 0, 0})
+	// The test fails if EvalGoldenFileFS returns an error for the golden file in directory fixtures of src
+	src := fstest.MapFS{"fixtures/a.out": &fstest.MapFile{Data: []byte(testcase)}}
+	if e := (&tsfio.Golden{Dir: "fixtures", Ext: ".out"}).EvalGoldenFileFS(src, tc); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "EvalGoldenFileFS", Fn: tc.Name, Err: e}))
+	}
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestGoldenNorm tests CreateGoldenFile to normalize new lines before the Scrubbers are applied as done by
+// EvalGoldenFile. The test fails if CreateGoldenFile or EvalGoldenFile returns an error or if the golden file does
+// not hold the normalized and scrubbed testcase data.
+func TestGoldenNorm(t *testing.T) {
+	// Create temporary directory d and the Golden g with a Scrubber matching a Unix new line
+	d := tmpDir(t)
+	g := &tsfio.Golden{Dir: d, Scrubbers: []tsfio.Scrubber{func(s string) string { return strings.ReplaceAll(s, testcase_unix, "[X]\n") }}}
+	tc := &tsfio.Testcase{Name: "a", Data: testcase_win}
+	// The test fails if CreateGoldenFile or EvalGoldenFile returns an error
+	if e := g.CreateGoldenFile(tc); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "CreateGoldenFile", Fn: tc.Name, Err: e}))
+	}
+	if e := g.EvalGoldenFile(tc); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "EvalGoldenFile", Fn: tc.Name, Err: e}))
+	}
+	// The test fails if the golden file does not hold the normalized and scrubbed testcase data
+	checkTree(t, d, map[string]string{"a.golden": "[X]\n"})
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestGoldenUpdateFlag tests GoldenUpdate to report the boolean test flag -update defined by tsfio in the test binary.
+// The test fails if the flag is not defined or if GoldenUpdate does not return its value.
+func TestGoldenUpdateFlag(t *testing.T) {
+	// Disable the update mode by the environment variable
+	t.Setenv("TSFIO_UPDATE_GOLDEN", "")
+	// The test fails if the flag is not defined
+	f := flag.Lookup("update")
+	if f == nil {
+		t.Fatal(tserr.NilFailed("flag.Lookup of update"))
+	}
+	// Restore the value of the flag
+	defer flag.Set("update", f.Value.String())
+	// The test fails if GoldenUpdate does not return the value of the flag
+	for _, b := range []bool{true, false} {
+		if e := flag.Set("update", fmt.Sprint(b)); e != nil {
+			t.Fatal(tserr.Op(&tserr.OpArgs{Op: "flag.Set", Fn: "update", Err: e}))
+		}
+		if u := tsfio.GoldenUpdate(); u != b {
+			t.Error(tserr.Return(&tserr.ReturnArgs{Op: "GoldenUpdate", Actual: fmt.Sprint(u), Want: fmt.Sprint(b)}))
+		}
+	}
+}
@@ -33,14 +33,19 @@ var (
 	}
 )
 
-// InvalDir returns the array of blocked directories. If a directory or their parents match InvalDir, tsfio
-// functions will return an error.
+// InvalDir returns the array of blocked directories of the default policy. If a directory or their parents
+// match InvalDir, tsfio functions will return an error with the default policy (see DefaultPolicy).
 func InvalDir() [4]Directory {
 	return invalDir
 }
 
-// InvalFile returns the array of blocked filenames. If a Filename matches InvalFile, tsfio
-// functions will return an error.
+// InvalFile returns the array of blocked filenames of the default policy. If a Filename matches InvalFile,
+// tsfio functions will return an error with the default policy (see DefaultPolicy).
 func InvalFile() [14]Filename {
 	return invalFile
 }
+
+// normCase returns path p unchanged, since Linux paths are case-sensitive.
+func normCase(p string) string {
+	return p
+}
@@ -2,6 +2,9 @@
 
 package tsfio
 
+// Import standard library package strings
+import "strings" // strings
+
 // Windows blocked Directories and Filenames.
 // If a directory or their parents match invalDir,
 // tsfio functions will return an error. If a Filename
@@ -22,14 +25,19 @@ var (
 	}
 )
 
-// InvalDir returns the array of blocked directories. If a directory or their parents match InvalDir, tsfio
-// functions will return an error.
+// InvalDir returns the array of blocked directories of the default policy. If a directory or their parents
+// match InvalDir, tsfio functions will return an error with the default policy (see DefaultPolicy).
 func InvalDir() [4]Directory {
 	return invalDir
 }
 
-// InvalFile returns the array of blocked filenames. If a Filename matches InvalFile, tsfio
-// functions will return an error.
+// InvalFile returns the array of blocked filenames of the default policy. If a Filename matches InvalFile,
+// tsfio functions will return an error with the default policy (see DefaultPolicy).
 func InvalFile() [5]Filename {
 	return invalFile
 }
+
+// normCase returns path p in lower case, since Windows paths are case-insensitive.
+func normCase(p string) string {
+	return strings.ToLower(p)
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio
+
+// Import standard library packages and tserr
+import (
+	"io"            // io
+	"io/fs"         // fs
+	"os"            // os
+	"path/filepath" // filepath
+
+	"github.com/thorstenrie/tserr" // tserr
+)
+
+// An IOFS provides the files and directories in a directory of an FS as file system of the standard library package
+// io/fs. It implements fs.FS, fs.ReadDirFS, fs.ReadFileFS and fs.StatFS and can be used with functions of io/fs, e.g.,
+// fs.WalkDir, or with testing/fstest. Names are slash-separated paths relative to the directory and must be valid
+// according to fs.ValidPath. Each file is checked with CheckFile and each directory with CheckDir of the FS before it
+// is accessed. An IOFS is read-only. It is returned by DirFS.
+type IOFS struct {
+	fsys *FS       // fsys holds the FS of the files and directories
+	dir  Directory // dir holds the directory of the files and directories
+}
+
+// Assert that IOFS implements the interfaces of io/fs
+var (
+	_ fs.ReadDirFS  = (*IOFS)(nil) // IOFS implements fs.ReadDirFS
+	_ fs.ReadFileFS = (*IOFS)(nil) // IOFS implements fs.ReadFileFS
+	_ fs.StatFS     = (*IOFS)(nil) // IOFS implements fs.StatFS
+)
+
+// DirFS returns the files and directories in directory d as IOFS. Directory d is not checked until files or directories
+// of the IOFS are accessed.
+func DirFS(d Directory) *IOFS {
+	return std.DirFS(d)
+}
+
+// DirFS performs the package function DirFS with the settings of fsys.
+func (fsys *FS) DirFS(d Directory) *IOFS {
+	return &IOFS{fsys: fsys, dir: rooted(fsys, d)}
+}
+
+// path returns the path in the directory of f for name. It returns an error of type *fs.PathError for operation op,
+// if name is not valid according to fs.ValidPath.
+func (f *IOFS) path(op, name string) (string, error) {
+	// Return an error, if name is not valid
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	// Return the path of name in the directory of f
+	return filepath.Join(string(f.dir), filepath.FromSlash(name)), nil
+}
+
+// check retrieves FileInfo of path p and checks p with CheckDir, if it is a directory, or with CheckFile otherwise.
+// It returns the FileInfo. It returns an error of type *fs.PathError for operation op on name, if any.
+func (f *IOFS) check(op, name, p string) (fs.FileInfo, error) {
+	// Retrieve FileInfo of p
+	fi, err := f.fsys.backend().Stat(p)
+	if err != nil {
+		return nil, ioErr(op, name, err)
+	}
+	// Check p as directory or file
+	if fi.IsDir() {
+		err = f.fsys.CheckDir(Directory(p))
+	} else {
+		err = f.fsys.CheckFile(Filename(p))
+	}
+	// Return an error, if the check fails
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	// Return the FileInfo of p
+	return fi, nil
+}
+
+// ioErr returns an error of type *fs.PathError for operation op on name with the underlying error of err.
+func ioErr(op, name string, err error) error {
+	// Retrieve the underlying error of a *fs.PathError
+	if pe, ok := err.(*fs.PathError); ok {
+		err = pe.Err
+	}
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// Open opens the file or directory name. A directory implements fs.ReadDirFile. It returns an error of type
+// *fs.PathError, if any.
+func (f *IOFS) Open(name string) (fs.File, error) {
+	// Retrieve the path of name
+	p, err := f.path("open", name)
+	if err != nil {
+		return nil, err
+	}
+	// Check the path
+	fi, err := f.check("open", name, p)
+	if err != nil {
+		return nil, err
+	}
+	// Return a directory with its entries
+	if fi.IsDir() {
+		des, e := f.fsys.backend().ReadDir(p)
+		if e != nil {
+			return nil, ioErr("open", name, e)
+		}
+		return &ioDir{fi: fi, des: des}, nil
+	}
+	// Open the file read-only
+	h, err := f.fsys.backend().OpenFile(p, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, ioErr("open", name, err)
+	}
+	return h, nil
+}
+
+// ReadDir returns the entries of directory name sorted by filename. It returns an error of type *fs.PathError, if any.
+func (f *IOFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	// Retrieve the path of name
+	p, err := f.path("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	// Check the path
+	if _, e := f.check("readdir", name, p); e != nil {
+		return nil, e
+	}
+	// Read the entries of the directory
+	des, err := f.fsys.backend().ReadDir(p)
+	if err != nil {
+		return nil, ioErr("readdir", name, err)
+	}
+	return des, nil
+}
+
+// ReadFile reads file name and returns its contents. It returns an error of type *fs.PathError, if any.
+func (f *IOFS) ReadFile(name string) ([]byte, error) {
+	// Retrieve the path of name
+	p, err := f.path("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+	// Check the path
+	if _, e := f.check("readfile", name, p); e != nil {
+		return nil, e
+	}
+	// Read the file
+	b, err := f.fsys.backend().ReadFile(p)
+	if err != nil {
+		return nil, ioErr("readfile", name, err)
+	}
+	return b, nil
+}
+
+// Stat returns the FileInfo of file or directory name. It returns an error of type *fs.PathError, if any.
+func (f *IOFS) Stat(name string) (fs.FileInfo, error) {
+	// Retrieve the path of name
+	p, err := f.path("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	// Check the path and return its FileInfo
+	return f.check("stat", name, p)
+}
+
+// An ioDir is an open directory of an IOFS
+type ioDir struct {
+	fi  fs.FileInfo   // fi holds the FileInfo of the directory
+	des []fs.DirEntry // des holds the remaining entries of the directory
+}
+
+// Stat returns the FileInfo of d.
+func (d *ioDir) Stat() (fs.FileInfo, error) {
+	return d.fi, nil
+}
+
+// Read returns an error, since d is a directory.
+func (d *ioDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.fi.Name(), Err: fs.ErrInvalid}
+}
+
+// Close closes d.
+func (d *ioDir) Close() error {
+	return nil
+}
+
+// ReadDir returns the next n entries of d as described by fs.ReadDirFile. If n <= 0, it returns all remaining entries.
+func (d *ioDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	// Return all remaining entries, if n <= 0
+	if n <= 0 {
+		des := d.des
+		d.des = nil
+		return des, nil
+	}
+	// Return io.EOF, if no entries remain
+	if len(d.des) == 0 {
+		return nil, io.EOF
+	}
+	// Return the next n entries
+	n = min(n, len(d.des))
+	des := d.des[:n]
+	d.des = d.des[n:]
+	return des, nil
+}
+
+// ReadFileFS reads file f from the file system src and returns its contents. The file system src can be any fs.FS,
+// e.g., an embed.FS, an fstest.MapFS or an IOFS. The filename f is converted to a slash-separated path. Since src
+// does not need to be the file system of the operating system, f is not checked with CheckFile. It returns an error,
+// if src is nil, if f is empty or if the file cannot be read.
+func ReadFileFS(src fs.FS, f Filename) ([]byte, error) {
+	// Return an error if src is nil
+	if src == nil {
+		return nil, tserr.NilPtr()
+	}
+	// Return an error if f is empty
+	if f == "" {
+		return nil, errEmptyName("filename")
+	}
+	// Read f from src
+	b, err := fs.ReadFile(src, filepath.ToSlash(string(f)))
+	// Return nil and an error if ReadFile fails
+	if err != nil {
+		return nil, tserr.Op(&tserr.OpArgs{Op: "ReadFile", Fn: string(f), Err: err})
+	}
+	// No error occurred, return the contents
+	return b, nil
+}
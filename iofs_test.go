@@ -0,0 +1,120 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio_test
+
+// Import standard library packages as well as tserr and tsfio
+import (
+	"errors"         // errors
+	"fmt"            // fmt
+	"io/fs"          // fs
+	"path/filepath"  // filepath
+	"testing"        // testing
+	"testing/fstest" // fstest
+
+	"github.com/thorstenrie/tserr" // tserr
+	"github.com/thorstenrie/tsfio" // tsfio
+)
+
+// TestDirFS tests DirFS with testing/fstest on a temporary directory holding testTree.
+// The test fails if fstest.TestFS returns an error.
+func TestDirFS(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write testTree to d
+	writeTree(t, d, testTree)
+	// The test fails if TestFS returns an error
+	if e := fstest.TestFS(tsfio.DirFS(d), "a", "b/c", "b/d/e"); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "TestFS", Fn: string(d), Err: e}))
+	}
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestDirFSMem tests DirFS of an FS with a MemBackend with testing/fstest on a directory holding testTree.
+// The test fails if fstest.TestFS returns an error.
+func TestDirFSMem(t *testing.T) {
+	// Create FS with MemBackend and Directory d
+	fsys, _, d := memFS(t)
+	// Write testTree to d
+	memTree(t, fsys, d, testTree)
+	// The test fails if TestFS returns an error
+	if e := fstest.TestFS(fsys.DirFS(d), "a", "b/c", "b/d/e"); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "TestFS", Fn: string(d), Err: e}))
+	}
+}
+
+// TestDirFSBlocked tests DirFS of an FS with a policy blocking a directory in a temporary directory holding testTree.
+// The test fails if ReadFile, ReadDir or Open return nil for an entry of the blocked directory.
+func TestDirFSBlocked(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write testTree to d
+	writeTree(t, d, testTree)
+	// Create a policy blocking directory b in d
+	p := tsfio.DefaultPolicy()
+	p.BlockedDirs = append(p.BlockedDirs, tsfio.Directory(filepath.Join(string(d), "b")))
+	// Retrieve the IOFS of d
+	f := (&tsfio.FS{Policy: &p}).DirFS(d)
+	// The test fails if ReadFile, ReadDir or Open return nil
+	if _, e := f.ReadFile("b/c"); e == nil {
+		t.Error(tserr.NilFailed("ReadFile"))
+	}
+	if _, e := f.ReadDir("b"); e == nil {
+		t.Error(tserr.NilFailed("ReadDir"))
+	}
+	if _, e := f.Open("b/d/e"); e == nil {
+		t.Error(tserr.NilFailed("Open"))
+	}
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestDirFSInvalid tests DirFS to return fs.ErrInvalid for a name, which is not a valid path, and fs.ErrNotExist for
+// a name, which does not exist. The test fails if Open returns another error.
+func TestDirFSInvalid(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Retrieve the IOFS of d
+	f := tsfio.DirFS(d)
+	// The test fails if Open returns another error than fs.ErrInvalid or fs.ErrNotExist
+	for n, w := range map[string]error{"../" + string(testfile): fs.ErrInvalid, string(testfile): fs.ErrNotExist} {
+		if _, e := f.Open(n); !errors.Is(e, w) {
+			t.Error(tserr.Return(&tserr.ReturnArgs{Op: fmt.Sprintf("Open %v", n), Actual: fmt.Sprint(e), Want: w.Error()}))
+		}
+	}
+	// Remove d
+	rm(t, d)
+}
+
+// TestReadFileFS tests ReadFileFS to read a file from an fstest.MapFS. The test fails if ReadFileFS returns an error
+// or if the contents differ.
+func TestReadFileFS(t *testing.T) {
+	// Create the file system src holding testfile
+	src := fstest.MapFS{string(testfile): &fstest.MapFile{Data: []byte(testcase)}}
+	// Read testfile from src
+	b, err := tsfio.ReadFileFS(src, testfile)
+	// The test fails if ReadFileFS returns an error
+	if err != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "ReadFileFS", Fn: string(testfile), Err: err}))
+	}
+	// The test fails if the contents differ
+	if string(b) != testcase {
+		t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: string(testfile), Actual: string(b), Want: testcase}))
+	}
+}
+
+// TestReadFileFSErr tests ReadFileFS to return an error for a nil file system, an empty filename and a file, which
+// does not exist. The test fails if ReadFileFS returns nil.
+func TestReadFileFSErr(t *testing.T) {
+	// The test fails if ReadFileFS returns nil for a nil file system
+	if _, e := tsfio.ReadFileFS(nil, testfile); e == nil {
+		t.Error(tserr.NilFailed("ReadFileFS"))
+	}
+	// The test fails if ReadFileFS returns nil for an empty filename or a file, which does not exist
+	for _, f := range []tsfio.Filename{"", testfile} {
+		if _, e := tsfio.ReadFileFS(fstest.MapFS{}, f); e == nil {
+			t.Error(tserr.NilFailed(fmt.Sprintf("ReadFileFS of %v", f)))
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio
+
+// Import standard library packages and tserr
+import (
+	"bytes"       // bytes
+	"context"     // context
+	"io"          // io
+	"os"          // os
+	"sync/atomic" // atomic
+
+	"github.com/thorstenrie/tserr" // tserr
+)
+
+// maxRead holds the package maximum size of files read by ReadFile. If zero, the size is not limited.
+var maxRead atomic.Int64
+
+// SetMaxReadSize sets the package maximum size in bytes of files read by ReadFile and ReadFileCtx to n. It applies
+// to the package functions and to each FS without MaxRead. If n is zero or negative, the size is not limited, which
+// is the default. It returns the previous package maximum. The previous maximum can be restored by calling
+// SetMaxReadSize again, e.g.,
+//
+//	defer tsfio.SetMaxReadSize(tsfio.SetMaxReadSize(n))
+//
+// limits the size for the scope of the calling function.
+func SetMaxReadSize(n int64) int64 {
+	return maxRead.Swap(max(n, 0))
+}
+
+// ReadFileLimit reads file fn and returns its contents, if fn holds at most limit bytes. Otherwise, it returns
+// an error matching ErrTooLarge without reading fn. Since the file may grow while it is read, at most limit bytes are
+// read and it returns an error, if more contents remain. Use ReadFileLimit for files with filenames provided by
+// users. It returns an error, if limit is not greater than zero or if any other error occurs.
+func ReadFileLimit(fn Filename, limit int64) ([]byte, error) {
+	return std.ReadFileLimit(fn, limit)
+}
+
+// ReadFileLimit performs the package function ReadFileLimit with the settings of fsys. The maximum read size of
+// fsys does not apply.
+func (fsys *FS) ReadFileLimit(fn Filename, limit int64) ([]byte, error) {
+	// Return an error, if limit is not greater than zero
+	if limit <= 0 {
+		return nil, tserr.Higher(&tserr.HigherArgs{Var: "limit", Actual: limit, LowerBound: 0})
+	}
+	// Resolve fn against the root directory of fsys
+	fn = rooted(fsys, fn)
+	// Return an error in case fn contains a blocked directory or filename
+	if e := fsys.CheckFile(fn); e != nil {
+		return nil, tserr.Check(&tserr.CheckArgs{F: string(fn), Err: e})
+	}
+	// Read at most limit bytes of fn
+	return fsys.readLimit(context.Background(), fn, limit)
+}
+
+// readLimit reads file fn in chunks with copyCtx and returns its contents. If limit is greater than zero, it returns
+// an error matching ErrTooLarge, if fn holds more than limit bytes before or while it is read. It returns an error, if any.
+func (fsys *FS) readLimit(ctx context.Context, fn Filename, limit int64) ([]byte, error) {
+	// Open fn read-only
+	in, err := fsys.backend().OpenFile(string(fn), os.O_RDONLY, 0)
+	if err != nil {
+		return nil, tserr.Op(&tserr.OpArgs{Op: "ReadFile", Fn: string(fn), Err: err})
+	}
+	defer in.Close()
+	// Retrieve the size of fn, if available
+	var buf bytes.Buffer
+	var r io.Reader = in
+	if fi, e := in.Stat(); e == nil {
+		// Return an error, if fn is larger than limit
+		if (limit > 0) && (fi.Size() > limit) {
+			return nil, errTooLarge(fn, fi.Size(), limit)
+		}
+		// Allocate the buffer with the size of fn
+		buf.Grow(int(fi.Size()))
+	}
+	// Read at most one byte more than limit to detect a file growing while it is read
+	if limit > 0 {
+		r = io.LimitReader(in, limit+1)
+	}
+	// Read fn in chunks
+	if _, e := copyCtx(ctx, &buf, r); e != nil {
+		return nil, tserr.Op(&tserr.OpArgs{Op: "ReadFile", Fn: string(fn), Err: e})
+	}
+	// Return an error, if fn grew larger than limit
+	if (limit > 0) && (int64(buf.Len()) > limit) {
+		return nil, errTooLarge(fn, int64(buf.Len()), limit)
+	}
+	// No error occurred, return contents and error is nil
+	return buf.Bytes(), nil
+}
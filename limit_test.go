@@ -0,0 +1,131 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio_test
+
+// Import standard library packages as well as tserr and tsfio
+import (
+	"errors"        // errors
+	"fmt"           // fmt
+	"io/fs"         // fs
+	"path/filepath" // filepath
+	"testing"       // testing
+
+	"github.com/thorstenrie/tserr" // tserr
+	"github.com/thorstenrie/tsfio" // tsfio
+)
+
+// A growBackend is a MemBackend, which opens files reporting size zero with Stat. It simulates files growing
+// after Stat while they are read.
+type growBackend struct {
+	*tsfio.MemBackend // MemBackend holds the files
+}
+
+// OpenFile opens name with the MemBackend and returns a growFile.
+func (b growBackend) OpenFile(name string, flag int, perm fs.FileMode) (tsfio.File, error) {
+	f, err := b.MemBackend.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return growFile{File: f}, nil
+}
+
+// A growFile is a File reporting size zero with Stat
+type growFile struct {
+	tsfio.File // File holds the open file
+}
+
+// Stat returns the FileInfo of the File with size zero.
+func (f growFile) Stat() (fs.FileInfo, error) {
+	fi, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return zeroInfo{FileInfo: fi}, nil
+}
+
+// A zeroInfo is a FileInfo with size zero
+type zeroInfo struct {
+	fs.FileInfo // FileInfo holds the underlying FileInfo
+}
+
+// Size returns zero.
+func (zeroInfo) Size() int64 {
+	return 0
+}
+
+// TestReadFileLimit tests ReadFileLimit to read a file of at most limit bytes and to return an error matching
+// ErrTooLarge for a file exceeding limit bytes and another error for limit not greater than zero. The test fails if
+// ReadFileLimit returns an error, nil, other contents or an error, which does not match as expected.
+func TestReadFileLimit(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write file a to d
+	writeTree(t, d, map[string]string{"a": testcase})
+	fn := tsfio.Filename(filepath.Join(string(d), "a"))
+	n := int64(len(testcase))
+	// The test fails if ReadFileLimit returns an error or other contents
+	for _, m := range []int64{n, n + 1} {
+		if b, e := tsfio.ReadFileLimit(fn, m); (e != nil) || (string(b) != testcase) {
+			t.Error(tserr.Return(&tserr.ReturnArgs{Op: fmt.Sprintf("ReadFileLimit with %d", m), Actual: fmt.Sprint(string(b), e), Want: fmt.Sprint(testcase, nil)}))
+		}
+	}
+	// The test fails if ReadFileLimit returns nil or an error, which does not match ErrTooLarge as expected
+	for _, m := range []int64{n - 1, 0, -1} {
+		if _, e := tsfio.ReadFileLimit(fn, m); e == nil {
+			t.Error(tserr.NilFailed(fmt.Sprintf("ReadFileLimit with %d", m)))
+		} else if errors.Is(e, tsfio.ErrTooLarge) != (m > 0) {
+			t.Error(tserr.Return(&tserr.ReturnArgs{Op: fmt.Sprintf("errors.Is of ReadFileLimit with %d", m), Actual: e.Error(), Want: fmt.Sprint(m > 0)}))
+		}
+	}
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestReadFileLimitGrow tests ReadFileLimit to return an error matching ErrTooLarge for a file exceeding limit bytes
+// while it is read. The test fails if ReadFileLimit returns nil or an error, which does not match ErrTooLarge.
+func TestReadFileLimitGrow(t *testing.T) {
+	// Create FS with MemBackend and Directory d
+	fsys, m, d := memFS(t)
+	// Write file a to d
+	memTree(t, fsys, d, map[string]string{"a": testcase})
+	fn := tsfio.Filename(filepath.Join(string(d), "a"))
+	// Use a backend reporting size zero for open files
+	fsys.Backend = growBackend{MemBackend: m}
+	// The test fails if ReadFileLimit returns nil or an error, which does not match ErrTooLarge
+	if _, e := fsys.ReadFileLimit(fn, int64(len(testcase)-1)); !errors.Is(e, tsfio.ErrTooLarge) {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "ReadFileLimit", Actual: fmt.Sprint(e), Want: tsfio.ErrTooLarge.Error()}))
+	}
+}
+
+// TestMaxReadSize tests ReadFile to return an error for a file exceeding the package maximum set with
+// SetMaxReadSize or MaxRead of an FS. The test fails if ReadFile returns nil for a file exceeding the maximum
+// or an error otherwise.
+func TestMaxReadSize(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write file a to d
+	writeTree(t, d, map[string]string{"a": testcase})
+	fn := tsfio.Filename(filepath.Join(string(d), "a"))
+	n := int64(len(testcase))
+	// Set the package maximum below the size of a
+	prev := tsfio.SetMaxReadSize(n - 1)
+	// The test fails if ReadFile returns nil
+	if _, e := tsfio.ReadFile(fn); e == nil {
+		t.Error(tserr.NilFailed("ReadFile"))
+	}
+	// The test fails if ReadFile of an FS with a larger maximum returns an error
+	if _, e := (&tsfio.FS{MaxRead: n}).ReadFile(fn); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "ReadFile", Fn: string(fn), Err: e}))
+	}
+	// Restore the package maximum. The test fails if it differs.
+	if m := tsfio.SetMaxReadSize(prev); m != n-1 {
+		t.Error(tserr.Equal(&tserr.EqualArgs{Var: "package maximum", Actual: m, Want: n - 1}))
+	}
+	// The test fails if ReadFile returns an error
+	if _, e := tsfio.ReadFile(fn); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "ReadFile", Fn: string(fn), Err: e}))
+	}
+	// Remove d
+	rmAll(t, d)
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio
+
+// Import standard library packages and tserr
+import (
+	"bufio"   // bufio
+	"context" // context
+	"fmt"     // fmt
+	"io"      // io
+	"os"      // os
+
+	"github.com/thorstenrie/tserr" // tserr
+)
+
+// A LineReader reads the lines of a file one by one without reading the whole file into memory. New lines are
+// normalized with NormNewlinesBytes, so Unix (LF), Windows (CR LF) and Mac (CR) new lines end a line. The new line
+// is not part of a line. Lines can be of any length. A LineReader is returned by OpenLines and must be closed with
+// Close. Its usage is similar to bufio.Scanner:
+//
+//	l, err := tsfio.OpenLines(fn)
+//	if err != nil {
+//		return err
+//	}
+//	defer l.Close()
+//	for l.Next() {
+//		fmt.Println(l.Number(), l.Line())
+//	}
+//	return l.Err()
+type LineReader struct {
+	fn   Filename      // fn holds the filename
+	f    File          // f holds the open file
+	r    *bufio.Reader // r holds the buffered reader of the normalized contents of f
+	line string        // line holds the current line
+	n    int           // n holds the number of the current line
+	err  error         // err holds the first error
+	done bool          // done is true, if all lines are read or an error occurred
+}
+
+// OpenLines opens file fn read-only and returns a LineReader for its lines. It returns an error, if any.
+func OpenLines(fn Filename) (*LineReader, error) {
+	return std.OpenLines(fn)
+}
+
+// OpenLines performs the package function OpenLines with the settings of fsys.
+func (fsys *FS) OpenLines(fn Filename) (*LineReader, error) {
+	// Resolve fn against the root directory of fsys
+	fn = rooted(fsys, fn)
+	// Return an error in case fn contains a blocked directory or filename
+	if e := fsys.CheckFile(fn); e != nil {
+		return nil, tserr.Check(&tserr.CheckArgs{F: string(fn), Err: e})
+	}
+	// Open fn read-only
+	f, err := fsys.backend().OpenFile(string(fn), os.O_RDONLY, 0)
+	if err != nil {
+		return nil, tserr.Op(&tserr.OpArgs{Op: "open", Fn: string(fn), Err: err})
+	}
+	// Return the LineReader of the normalized contents of fn
+	return &LineReader{fn: fn, f: f, r: bufio.NewReaderSize(&normReader{r: f}, copyBufSize)}, nil
+}
+
+// Next advances to the next line, which is then available with Line. It returns false, if no lines remain or
+// an error occurred. After Next returns false, Err returns the error, if any.
+func (l *LineReader) Next() bool {
+	// Return false, if all lines are read or an error occurred
+	if l.done {
+		return false
+	}
+	// Read the next line of any length
+	b, err := l.r.ReadBytes('\n')
+	// Return false, if the contents end without a further line
+	if (err == io.EOF) && (len(b) == 0) {
+		l.done, l.line = true, ""
+		return false
+	}
+	// Return false and keep the error with the line number, if reading fails
+	if (err != nil) && (err != io.EOF) {
+		l.done, l.line = true, ""
+		l.err = tserr.Op(&tserr.OpArgs{Op: fmt.Sprintf("read line %d of", l.n+1), Fn: string(l.fn), Err: err})
+		return false
+	}
+	// Remove the new line and advance to the line
+	if b[len(b)-1] == '\n' {
+		b = b[:len(b)-1]
+	}
+	l.line = string(b)
+	l.n++
+	return true
+}
+
+// Line returns the current line without new line.
+func (l *LineReader) Line() string {
+	return l.line
+}
+
+// Number returns the number of the current line. The first line has number 1.
+func (l *LineReader) Number() int {
+	return l.n
+}
+
+// Err returns the first error, which occurred while reading the lines, or nil. The error reports the number
+// of the line, which could not be read.
+func (l *LineReader) Err() error {
+	return l.err
+}
+
+// Close closes the file of l. It returns an error, if any.
+func (l *LineReader) Close() error {
+	l.done = true
+	// Close the file
+	if e := l.f.Close(); e != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "Close", Fn: string(l.fn), Err: e})
+	}
+	// No error occurred, return nil
+	return nil
+}
+
+// ReadLines reads file fn and returns its lines as read by a LineReader. New lines are normalized and not part of
+// the lines. It returns an error, if any. The error reports the number of the line, which could not be read.
+func ReadLines(fn Filename) ([]string, error) {
+	return std.ReadLines(fn)
+}
+
+// ReadLines performs the package function ReadLines with the settings of fsys.
+func (fsys *FS) ReadLines(fn Filename) ([]string, error) {
+	// Open the lines of fn
+	l, err := fsys.OpenLines(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Close()
+	// Collect the lines
+	var ls []string
+	for l.Next() {
+		ls = append(ls, l.Line())
+	}
+	// Return nil and an error, if any
+	if e := l.Err(); e != nil {
+		return nil, e
+	}
+	// No error occurred, return the lines
+	return ls, nil
+}
+
+// WriteLines writes the lines ls to file fn. Each line is followed by a Unix new line LF (0x0A). If fn exists, its
+// contents are replaced atomically as done by WriteSingleStr. If fn does not exist, it is created. It returns an
+// error, if any.
+func WriteLines(fn Filename, ls []string) error {
+	return std.WriteLines(fn, ls)
+}
+
+// WriteLines performs the package function WriteLines with the settings of fsys.
+func (fsys *FS) WriteLines(fn Filename, ls []string) error {
+	_, err := fsys.WriteSingleFrom(fn, &joinReader{ls: ls})
+	return err
+}
+
+// AppendLines appends the lines ls to file fn. Each line is followed by a Unix new line LF (0x0A). If fn exists,
+// the lines are appended as done by WriteStr. If fn does not exist, it is created. It returns an error, if any.
+func AppendLines(fn Filename, ls []string) error {
+	return std.AppendLines(fn, ls)
+}
+
+// AppendLines performs the package function AppendLines with the settings of fsys.
+func (fsys *FS) AppendLines(fn Filename, ls []string) error {
+	_, err := fsys.writeFrom(context.Background(), fn, &joinReader{ls: ls})
+	return err
+}
+
+// A normReader reads from r and normalizes new lines with NormNewlinesBytes. A Windows new line split between two
+// reads is normalized to a single Unix new line.
+type normReader struct {
+	r  io.Reader // r holds the underlying reader
+	cr bool      // cr is true, if the previous read ended with a carriage return CR (0x0D)
+}
+
+// Read reads from the underlying reader into p and normalizes the new lines in place. Since normalizing
+// never extends the contents, the normalized contents fit into p.
+func (n *normReader) Read(p []byte) (int, error) {
+	for {
+		c, err := n.r.Read(p)
+		b := p[:c]
+		if c > 0 {
+			// Skip a line feed LF (0x0A) following a carriage return CR (0x0D) of the previous read,
+			// since the CR is already normalized to LF
+			if n.cr && (b[0] == '\n') {
+				b = b[1:]
+			}
+			n.cr = (len(b) > 0) && (b[len(b)-1] == '\r')
+		}
+		// Normalize the new lines and copy them to p
+		nb, _ := NormNewlinesBytes(b)
+		k := copy(p, nb)
+		// Return, if contents remain or an error occurred
+		if (k > 0) || (err != nil) || (len(p) == 0) {
+			return k, err
+		}
+	}
+}
+
+// A joinReader reads lines each followed by a Unix new line LF (0x0A)
+type joinReader struct {
+	ls  []string // ls holds the lines
+	i   int      // i holds the index of the current line
+	off int      // off holds the offset in the current line
+}
+
+// Read reads the remaining lines into p. It returns io.EOF, if all lines are read.
+func (j *joinReader) Read(p []byte) (int, error) {
+	n := 0
+	for (n < len(p)) && (j.i < len(j.ls)) {
+		// Copy the remaining current line
+		if l := j.ls[j.i]; j.off < len(l) {
+			k := copy(p[n:], l[j.off:])
+			j.off += k
+			n += k
+			continue
+		}
+		// Write the new line and advance to the next line
+		p[n] = '\n'
+		n++
+		j.i, j.off = j.i+1, 0
+	}
+	// Return io.EOF, if all lines are read
+	if (n == 0) && (j.i >= len(j.ls)) {
+		return 0, io.EOF
+	}
+	return n, nil
+}
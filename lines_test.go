@@ -0,0 +1,131 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio_test
+
+// Import standard library packages as well as tserr and tsfio
+import (
+	"fmt"           // fmt
+	"path/filepath" // filepath
+	"slices"        // slices
+	"strings"       // strings
+	"testing"       // testing
+
+	"github.com/thorstenrie/tserr" // tserr
+	"github.com/thorstenrie/tsfio" // tsfio
+)
+
+// testLines tests ReadLines to return lines ls for file fn. The test fails if ReadLines returns an error or
+// other lines.
+func testLines(t *testing.T, fn tsfio.Filename, ls []string) {
+	// Panic if t is nil
+	if t == nil {
+		panic("nil pointer")
+	}
+	// Read the lines of fn
+	rs, err := tsfio.ReadLines(fn)
+	// The test fails if ReadLines returns an error
+	if err != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "ReadLines", Fn: string(fn), Err: err}))
+	}
+	// The test fails if the lines differ
+	if !slices.Equal(rs, ls) {
+		t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: "lines of " + string(fn), Actual: fmt.Sprintf("%q", rs), Want: fmt.Sprintf("%q", ls)}))
+	}
+}
+
+// TestReadLines tests ReadLines to read lines ending with Unix, Windows and Mac new lines, an empty line and a last
+// line without new line. The test fails if ReadLines returns an error or other lines.
+func TestReadLines(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write files to d
+	writeTree(t, d, map[string]string{"a": "a\r\nb\rc\n\nd", "e": ""})
+	// The test fails if the lines differ
+	testLines(t, tsfio.Filename(filepath.Join(string(d), "a")), []string{"a", "b", "c", "", "d"})
+	testLines(t, tsfio.Filename(filepath.Join(string(d), "e")), nil)
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestReadLinesLong tests ReadLines to read lines longer than the token limit of bufio.Scanner and a Windows new line
+// split between two reads. The test fails if ReadLines returns an error or other lines.
+func TestReadLinesLong(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Create a line ending right before the end of the first read and a line longer than bufio.MaxScanTokenSize
+	l1, l2 := strings.Repeat("a", 32*1024-1), strings.Repeat(testcase, 128*1024/len(testcase))
+	// Write file a to d
+	writeTree(t, d, map[string]string{"a": l1 + "\r\n" + l2 + "\r\n" + testcase})
+	// The test fails if the lines differ
+	testLines(t, tsfio.Filename(filepath.Join(string(d), "a")), []string{l1, l2, testcase})
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestWriteLines tests WriteLines to replace a file with lines and AppendLines to append lines to the file.
+// The test fails if any of them returns an error or if the contents differ.
+func TestWriteLines(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write file a to d
+	writeTree(t, d, map[string]string{"a": testcase})
+	fn := tsfio.Filename(filepath.Join(string(d), "a"))
+	// Replace a with lines and append lines
+	if e := tsfio.WriteLines(fn, []string{"a", "", "b"}); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "WriteLines", Fn: string(fn), Err: e}))
+	}
+	if e := tsfio.AppendLines(fn, []string{"c"}); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "AppendLines", Fn: string(fn), Err: e}))
+	}
+	// The test fails if the contents or lines differ
+	checkTree(t, d, map[string]string{"a": "a\n\nb\nc\n"})
+	testLines(t, fn, []string{"a", "", "b", "c"})
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestLineReader tests a LineReader of an FS with a MemBackend to return the lines with their numbers.
+// The test fails if OpenLines returns an error or if the lines or their numbers differ.
+func TestLineReader(t *testing.T) {
+	// Create FS with MemBackend and Directory d
+	fsys, _, d := memFS(t)
+	// Write file a to d
+	memTree(t, fsys, d, map[string]string{"a": "a\nb\r\nc"})
+	fn := tsfio.Filename(filepath.Join(string(d), "a"))
+	// Open the lines of a
+	l, err := fsys.OpenLines(fn)
+	if err != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "OpenLines", Fn: string(fn), Err: err}))
+	}
+	defer l.Close()
+	// The test fails if the lines or their numbers differ
+	ls := []string{"a", "b", "c"}
+	for l.Next() {
+		if w := ls[min(l.Number(), len(ls))-1]; l.Line() != w {
+			t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: fmt.Sprintf("line %d", l.Number()), Actual: l.Line(), Want: w}))
+		}
+	}
+	if l.Number() != len(ls) {
+		t.Error(tserr.Equal(&tserr.EqualArgs{Var: "number of lines", Actual: int64(l.Number()), Want: int64(len(ls))}))
+	}
+	// The test fails if Err returns an error
+	if e := l.Err(); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "Err", Fn: string(fn), Err: e}))
+	}
+}
+
+// TestReadLinesErr tests ReadLines to return an error for an empty filename, a file, which does not exist, and
+// a directory. The test fails if ReadLines returns nil.
+func TestReadLinesErr(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// The test fails if ReadLines returns nil
+	for _, fn := range []tsfio.Filename{"", tsfio.Filename(filepath.Join(string(d), "a")), tsfio.Filename(d)} {
+		if _, e := tsfio.ReadLines(fn); e == nil {
+			t.Error(tserr.NilFailed(fmt.Sprintf("ReadLines of %v", fn)))
+		}
+	}
+	// Remove d
+	rm(t, d)
+}
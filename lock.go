@@ -0,0 +1,311 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio
+
+// Import standard library packages and tserr
+import (
+	"context" // context
+	"io"      // io
+	"os"      // os
+	"syscall" // syscall
+	"time"    // time
+
+	"github.com/thorstenrie/tserr" // tserr
+)
+
+// LockOptions holds the options for locking files with LockFile and TryLockFile. The zero value
+// acquires an exclusive lock and waits until the lock is acquired.
+type LockOptions struct {
+	Shared  bool          // Shared acquires a shared lock instead of an exclusive lock
+	Timeout time.Duration // Timeout limits the time to wait for the lock, if greater than zero
+}
+
+// Retry intervals for acquiring a lock with a timeout. The interval starts at lockRetryMin
+// and is doubled after each attempt up to lockRetryMax.
+const (
+	lockRetryMin time.Duration = time.Millisecond      // First retry interval
+	lockRetryMax time.Duration = 50 * time.Millisecond // Maximum retry interval
+)
+
+// A locker acquires and releases an advisory lock on an open file
+type locker interface {
+	lock(shared, block bool) (bool, error) // lock acquires the lock and waits, if block is true
+	unlock() error                         // unlock releases the lock
+}
+
+// LockFile acquires an advisory lock on the open file f. The lock is exclusive, unless o.Shared is true.
+// An exclusive lock can be held by a single open file only, whereas a shared lock can be held by several
+// open files at once. LockFile waits until the lock is acquired. If o.Timeout is greater than zero and the
+// lock is not acquired within o.Timeout, it returns an error. Locking an already locked file converts the lock.
+// The existing lock is released first, so it is lost, if the converted lock is not acquired. The lock is held
+// until UnlockFile is called or f is closed. The lock is advisory, so it only excludes other callers of LockFile
+// and TryLockFile, including other processes. On Unix the lock is acquired with flock and on Windows with
+// LockFileEx. It returns an error, if any.
+func LockFile(f *os.File, o LockOptions) error {
+	// Pass a nil File instead of a nil *os.File
+	if f == nil {
+		return std.LockFile(nil, o)
+	}
+	return std.LockFile(f, o)
+}
+
+// LockFile performs the package function LockFile with the settings of fsys. It locks files opened
+// by OSBackend or MemBackend.
+func (fsys *FS) LockFile(f File, o LockOptions) error {
+	// Return an error in case f is nil
+	if f == nil {
+		return tserr.NilPtr()
+	}
+	// Acquire the lock, wait if no timeout is set
+	ok, err := lockFile(f, o, o.Timeout <= 0)
+	// Return an error if the lock fails
+	if err != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "lock", Fn: f.Name(), Err: err})
+	}
+	// Return an error if the lock is not acquired within the timeout
+	if !ok {
+		return tserr.Op(&tserr.OpArgs{Op: "lock", Fn: f.Name(), Err: os.ErrDeadlineExceeded})
+	}
+	// No error occurred, return nil
+	return nil
+}
+
+// TryLockFile tries to acquire an advisory lock on the open file f as done by LockFile. If o.Timeout is zero,
+// it does not wait. Otherwise, it tries to acquire the lock for up to o.Timeout. It returns true, if the lock is
+// acquired. It returns false, if the lock is held by another open file. It returns false and an error, if any.
+func TryLockFile(f *os.File, o LockOptions) (bool, error) {
+	// Pass a nil File instead of a nil *os.File
+	if f == nil {
+		return std.TryLockFile(nil, o)
+	}
+	return std.TryLockFile(f, o)
+}
+
+// TryLockFile performs the package function TryLockFile with the settings of fsys. It locks files opened
+// by OSBackend or MemBackend.
+func (fsys *FS) TryLockFile(f File, o LockOptions) (bool, error) {
+	// Return an error in case f is nil
+	if f == nil {
+		return false, tserr.NilPtr()
+	}
+	// Try to acquire the lock without waiting
+	ok, err := lockFile(f, o, false)
+	// Return an error if the lock fails
+	if err != nil {
+		return false, tserr.Op(&tserr.OpArgs{Op: "lock", Fn: f.Name(), Err: err})
+	}
+	// Return whether the lock is acquired
+	return ok, nil
+}
+
+// UnlockFile releases the advisory lock on the open file f acquired by LockFile or TryLockFile.
+// It returns an error, if any.
+func UnlockFile(f *os.File) error {
+	// Pass a nil File instead of a nil *os.File
+	if f == nil {
+		return std.UnlockFile(nil)
+	}
+	return std.UnlockFile(f)
+}
+
+// UnlockFile performs the package function UnlockFile with the settings of fsys.
+func (fsys *FS) UnlockFile(f File) error {
+	// Return an error in case f is nil
+	if f == nil {
+		return tserr.NilPtr()
+	}
+	// Retrieve the locker of f
+	l, err := fileLocker(f)
+	if err != nil {
+		return err
+	}
+	// Release the lock
+	if e := l.unlock(); e != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "unlock", Fn: f.Name(), Err: e})
+	}
+	// No error occurred, return nil
+	return nil
+}
+
+// WriteStrLocked writes string s to file with filename fn while holding an exclusive lock on fn. It behaves
+// like WriteStr, but concurrent callers of WriteStrLocked, AppendFileLocked and LockFile on fn, also in other
+// processes, are excluded while s is written. It returns an error, if any.
+func WriteStrLocked(fn Filename, s string) error {
+	return std.WriteStrLocked(fn, s)
+}
+
+// WriteStrLocked performs the package function WriteStrLocked with the settings of fsys.
+func (fsys *FS) WriteStrLocked(fn Filename, s string) error {
+	// Resolve fn against the root directory of fsys
+	fn = rooted(fsys, fn)
+	// Return an error in case fn contains a blocked directory or filename
+	if e := fsys.CheckFile(fn); e != nil {
+		return tserr.Check(&tserr.CheckArgs{F: string(fn), Err: e})
+	}
+	// Open fn and acquire an exclusive lock
+	f, err := fsys.openLocked(fn)
+	if err != nil {
+		return err
+	}
+	// Write string s to file fn
+	if _, e := io.WriteString(f, s); e != nil {
+		// On error, close file and return error
+		f.Close()
+		return tserr.Op(&tserr.OpArgs{Op: "write string to", Fn: string(fn), Err: e})
+	}
+	// Release the lock and close the file
+	return fsys.closeLocked(f)
+}
+
+// AppendFileLocked appends a file to another file while holding an exclusive lock on fileA. It behaves like
+// AppendFile, but concurrent callers of WriteStrLocked, AppendFileLocked and LockFile on fileA, also in other
+// processes, are excluded while fileI is appended. FileI is not locked. It returns an error, if any.
+func AppendFileLocked(a *Append) error {
+	return std.AppendFileLocked(a)
+}
+
+// AppendFileLocked performs the package function AppendFileLocked with the settings of fsys.
+func (fsys *FS) AppendFileLocked(a *Append) error {
+	// Return error if pointer a is nil.
+	if a == nil {
+		return tserr.NilPtr()
+	}
+	// Resolve fileA and fileI against the root directory of fsys
+	a = &Append{FileA: rooted(fsys, a.FileA), FileI: rooted(fsys, a.FileI)}
+	// Return an error in case fileA contains a blocked directory or filename
+	if e := fsys.CheckFile(a.FileA); e != nil {
+		return tserr.Check(&tserr.CheckArgs{F: string(a.FileA), Err: e})
+	}
+	// Return an error in case fileI contains a blocked directory or filename
+	if e := fsys.CheckFile(a.FileI); e != nil {
+		return tserr.Check(&tserr.CheckArgs{F: string(a.FileI), Err: e})
+	}
+	// Open fileA and acquire an exclusive lock
+	f, err := fsys.openLocked(a.FileA)
+	if err != nil {
+		return err
+	}
+	// Append the contents of fileI to fileA
+	if e := fsys.appendFile(context.Background(), f, a, true); e != nil {
+		// If appendFile fails, close fileA and return error
+		f.Close()
+		return e
+	}
+	// Release the lock and close fileA
+	return fsys.closeLocked(f)
+}
+
+// openLocked opens fn with OpenFile and acquires an exclusive lock with LockFile. It returns the open file
+// or an error, if any.
+func (fsys *FS) openLocked(fn Filename) (File, error) {
+	// Open file fn. If the file does not exist, it is created.
+	f, err := fsys.OpenFile(fn)
+	if err != nil {
+		return nil, tserr.Op(&tserr.OpArgs{Op: "OpenFile", Fn: string(fn), Err: err})
+	}
+	// Acquire an exclusive lock on fn
+	if e := fsys.LockFile(f, LockOptions{}); e != nil {
+		// On error, close file and return error
+		f.Close()
+		return nil, tserr.Op(&tserr.OpArgs{Op: "LockFile", Fn: string(fn), Err: e})
+	}
+	// Return the open and locked file
+	return f, nil
+}
+
+// closeLocked releases the lock on f with UnlockFile and closes f. It returns an error, if any.
+func (fsys *FS) closeLocked(f File) error {
+	// Sync the file while holding the lock as requested by the durability of fsys
+	if e := fsys.syncFile(f); e != nil {
+		fsys.UnlockFile(f)
+		f.Close()
+		return e
+	}
+	// Release the lock
+	if e := fsys.UnlockFile(f); e != nil {
+		// On error, close file and return error
+		f.Close()
+		return tserr.Op(&tserr.OpArgs{Op: "UnlockFile", Fn: f.Name(), Err: e})
+	}
+	// Close the file
+	if e := f.Close(); e != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "Close", Fn: f.Name(), Err: e})
+	}
+	// Sync the directory of the file as requested by the durability of fsys
+	return fsys.syncParents(f.Name())
+}
+
+// lockFile acquires the lock on f with options o. If o.Timeout is greater than zero, it retries to acquire the lock
+// until o.Timeout elapsed. Otherwise it waits for the lock, if block is true. It returns true, if the lock is acquired.
+// It returns false and an error, if any.
+func lockFile(f File, o LockOptions, block bool) (bool, error) {
+	// Retrieve the locker of f
+	l, err := fileLocker(f)
+	if err != nil {
+		return false, err
+	}
+	// Acquire the lock without timeout
+	if o.Timeout <= 0 {
+		return l.lock(o.Shared, block)
+	}
+	// Retry to acquire the lock until the timeout elapsed
+	deadline := time.Now().Add(o.Timeout)
+	for d := lockRetryMin; ; d = min(2*d, lockRetryMax) {
+		// Return, if the lock is acquired, fails or the timeout elapsed
+		ok, err := l.lock(o.Shared, false)
+		if ok || (err != nil) || !time.Now().Before(deadline) {
+			return ok, err
+		}
+		// Wait for the next attempt
+		time.Sleep(min(d, time.Until(deadline)))
+	}
+}
+
+// fileLocker returns the locker of f. Files of OSBackend are locked by the operating system and files of MemBackend
+// in memory. It returns an error, if f cannot be locked.
+func fileLocker(f File) (locker, error) {
+	switch l := f.(type) {
+	case locker:
+		return l, nil
+	case syscall.Conn:
+		// Retrieve the raw connection to the file descriptor of f
+		rc, err := l.SyscallConn()
+		if err != nil {
+			return nil, tserr.Op(&tserr.OpArgs{Op: "SyscallConn", Fn: f.Name(), Err: err})
+		}
+		return &osLock{rc: rc}, nil
+	default:
+		return nil, tserr.TypeNotMatching(&tserr.TypeNotMatchingArgs{Actual: f.Name(), Want: "lockable file"})
+	}
+}
+
+// An osLock locks a file of the operating system
+type osLock struct {
+	rc syscall.RawConn // rc holds the raw connection to the file descriptor
+}
+
+// lock acquires the lock with lockFd. It returns true, if the lock is acquired. It returns false and an error, if any.
+func (l *osLock) lock(shared, block bool) (bool, error) {
+	var ok bool
+	var err error
+	// Acquire the lock on the file descriptor
+	if e := l.rc.Control(func(fd uintptr) {
+		ok, err = lockFd(fd, shared, block)
+	}); e != nil {
+		return false, e
+	}
+	return ok, err
+}
+
+// unlock releases the lock with unlockFd. It returns an error, if any.
+func (l *osLock) unlock() error {
+	var err error
+	// Release the lock on the file descriptor
+	if e := l.rc.Control(func(fd uintptr) {
+		err = unlockFd(fd)
+	}); e != nil {
+		return e
+	}
+	return err
+}
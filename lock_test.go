@@ -0,0 +1,355 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio_test
+
+// Import standard library packages as well as tserr and tsfio
+import (
+	"bufio"         // bufio
+	"fmt"           // fmt
+	"os"            // os
+	"os/exec"       // exec
+	"path/filepath" // filepath
+	"strconv"       // strconv
+	"strings"       // strings
+	"testing"       // testing
+	"time"          // time
+
+	"github.com/thorstenrie/tserr" // tserr
+	"github.com/thorstenrie/tsfio" // tsfio
+)
+
+// Environment variables and settings of the helper processes
+const (
+	lockHelperEnv  string        = "TSFIO_LOCK_HELPER"   // lockHelperEnv holds the mode of the helper process
+	lockFileEnv    string        = "TSFIO_LOCK_FILE"     // lockFileEnv holds the locked file of the helper process
+	lockHelpers    int           = 4                     // lockHelpers holds the number of helper processes
+	lockIterations int           = 25                    // lockIterations holds the number of iterations of a helper process
+	lockTimeout    time.Duration = 20 * time.Millisecond // lockTimeout holds the timeout of locks held by a helper process
+)
+
+// TestLockHelper is the helper process started by the lock tests with the test binary. It does nothing, if the
+// environment variable TSFIO_LOCK_HELPER is not set. In mode append, it writes begin and end lines with its process ID
+// to the file in TSFIO_LOCK_FILE while holding an exclusive lock. In modes writestr and appendfile, it writes the begin
+// and end lines with WriteStrLocked or appends them from another file with AppendFileLocked. In mode hold, it acquires
+// an exclusive lock on the file, writes locked to stdout and holds the lock until stdin is closed.
+func TestLockHelper(t *testing.T) {
+	// Return, if not started as helper process
+	m := os.Getenv(lockHelperEnv)
+	if m == "" {
+		return
+	}
+	// Open the locked file
+	fn := tsfio.Filename(os.Getenv(lockFileEnv))
+	f, err := tsfio.OpenFile(fn)
+	if err != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "OpenFile", Fn: string(fn), Err: err}))
+	}
+	defer f.Close()
+	switch m {
+	case "append":
+		for i := 0; i < lockIterations; i++ {
+			// Acquire an exclusive lock
+			if e := tsfio.LockFile(f, tsfio.LockOptions{}); e != nil {
+				t.Fatal(tserr.Op(&tserr.OpArgs{Op: "LockFile", Fn: string(fn), Err: e}))
+			}
+			// Write begin and end lines with a pause in between
+			fmt.Fprintf(f, "begin %d\n", os.Getpid())
+			time.Sleep(time.Millisecond)
+			fmt.Fprintf(f, "end %d\n", os.Getpid())
+			// Release the lock
+			if e := tsfio.UnlockFile(f); e != nil {
+				t.Fatal(tserr.Op(&tserr.OpArgs{Op: "UnlockFile", Fn: string(fn), Err: e}))
+			}
+		}
+	case "writestr":
+		for i := 0; i < lockIterations; i++ {
+			// Write begin and end lines with WriteStrLocked
+			if e := tsfio.WriteStrLocked(fn, fmt.Sprintf("begin %d\nend %d\n", os.Getpid(), os.Getpid())); e != nil {
+				t.Fatal(tserr.Op(&tserr.OpArgs{Op: "WriteStrLocked", Fn: string(fn), Err: e}))
+			}
+		}
+	case "appendfile":
+		// Write begin and end lines to input file fi
+		fi := tsfio.Filename(fmt.Sprintf("%v.%d", fn, os.Getpid()))
+		if e := tsfio.WriteSingleStr(fi, fmt.Sprintf("begin %d\nend %d\n", os.Getpid(), os.Getpid())); e != nil {
+			t.Fatal(tserr.Op(&tserr.OpArgs{Op: "WriteSingleStr", Fn: string(fi), Err: e}))
+		}
+		defer os.Remove(string(fi))
+		for i := 0; i < lockIterations; i++ {
+			// Append fi with AppendFileLocked
+			if e := tsfio.AppendFileLocked(&tsfio.Append{FileA: fn, FileI: fi}); e != nil {
+				t.Fatal(tserr.Op(&tserr.OpArgs{Op: "AppendFileLocked", Fn: string(fn), Err: e}))
+			}
+		}
+	case "hold":
+		// Acquire an exclusive lock
+		if e := tsfio.LockFile(f, tsfio.LockOptions{}); e != nil {
+			t.Fatal(tserr.Op(&tserr.OpArgs{Op: "LockFile", Fn: string(fn), Err: e}))
+		}
+		// Report the lock and wait until stdin is closed
+		fmt.Println("locked")
+		bufio.NewReader(os.Stdin).ReadString('\n')
+	}
+}
+
+// lockHelper returns the command of a helper process with mode m for file fn.
+func lockHelper(m string, fn tsfio.Filename) *exec.Cmd {
+	c := exec.Command(os.Args[0], "-test.run=^TestLockHelper$")
+	c.Env = append(os.Environ(), lockHelperEnv+"="+m, lockFileEnv+"="+string(fn))
+	return c
+}
+
+// TestLockExclusion tests LockFile to exclude helper processes writing begin and end lines to the same file.
+// The test fails if a helper process fails or if the begin and end lines of different processes interleave.
+func TestLockExclusion(t *testing.T) {
+	ms := make([]string, lockHelpers)
+	for i := range ms {
+		ms[i] = "append"
+	}
+	lockExclusion(t, ms)
+}
+
+// TestLockedExclusion tests WriteStrLocked and AppendFileLocked to exclude helper processes writing begin and end
+// lines to the same file with LockFile. The test fails if a helper process fails or if the begin and end lines of
+// different processes interleave.
+func TestLockedExclusion(t *testing.T) {
+	lockExclusion(t, []string{"append", "writestr", "append", "appendfile"})
+}
+
+// lockExclusion starts a helper process for each mode in ms writing begin and end lines to the same file. The test
+// fails if a helper process fails or if the begin and end lines of different processes interleave.
+func lockExclusion(t *testing.T, ms []string) {
+	// Panic if t is nil
+	if t == nil {
+		panic("nil pointer")
+	}
+	// Create temporary file fn
+	fn := tmpFile(t)
+	// Start the helper processes
+	cs := make([]*exec.Cmd, len(ms))
+	for i := range cs {
+		cs[i] = lockHelper(ms[i], fn)
+		if e := cs[i].Start(); e != nil {
+			t.Fatal(tserr.Op(&tserr.OpArgs{Op: "start helper process for", Fn: string(fn), Err: e}))
+		}
+	}
+	// Wait for the helper processes. The test fails if a helper process fails.
+	for _, c := range cs {
+		if e := c.Wait(); e != nil {
+			t.Error(tserr.Op(&tserr.OpArgs{Op: "helper process for", Fn: string(fn), Err: e}))
+		}
+	}
+	// Read the lines of fn
+	b, err := os.ReadFile(string(fn))
+	if err != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "ReadFile", Fn: string(fn), Err: err}))
+	}
+	ls := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	// The test fails if the number of lines differs
+	if len(ls) != 2*len(ms)*lockIterations {
+		t.Error(tserr.Equal(&tserr.EqualArgs{Var: "number of lines", Actual: int64(len(ls)), Want: int64(2 * len(ms) * lockIterations)}))
+	}
+	// The test fails if a begin line is not followed by the end line of the same process
+	for i := 0; i+1 < len(ls); i += 2 {
+		p := strings.TrimPrefix(ls[i], "begin ")
+		if (p == ls[i]) || (ls[i+1] != "end "+p) {
+			t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: "line " + strconv.Itoa(i+2), Actual: ls[i+1], Want: "end " + p}))
+			break
+		}
+	}
+	// Remove fn
+	rm(t, fn)
+}
+
+// TestTryLockFile tests TryLockFile and LockFile with a timeout while a helper process holds an exclusive lock.
+// The test fails if the lock is acquired while the helper process holds it or if it is not acquired afterwards.
+func TestTryLockFile(t *testing.T) {
+	// Create temporary file fn
+	fn := tmpFile(t)
+	// Start the helper process holding the lock
+	c := lockHelper("hold", fn)
+	in, err := c.StdinPipe()
+	if err != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "StdinPipe", Fn: string(fn), Err: err}))
+	}
+	out, err := c.StdoutPipe()
+	if err != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "StdoutPipe", Fn: string(fn), Err: err}))
+	}
+	if e := c.Start(); e != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "start helper process for", Fn: string(fn), Err: e}))
+	}
+	// Wait until the helper process holds the lock
+	if s, e := bufio.NewReader(out).ReadString('\n'); (e != nil) || (s != "locked\n") {
+		t.Fatal(tserr.Return(&tserr.ReturnArgs{Op: "helper process", Actual: s, Want: "locked"}))
+	}
+	// Open fn
+	f, err := tsfio.OpenFile(fn)
+	if err != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "OpenFile", Fn: string(fn), Err: err}))
+	}
+	// The test fails if TryLockFile acquires the lock or returns an error
+	for _, o := range []tsfio.LockOptions{{}, {Shared: true}, {Timeout: lockTimeout}} {
+		if ok, e := tsfio.TryLockFile(f, o); ok || (e != nil) {
+			t.Error(tserr.Return(&tserr.ReturnArgs{Op: fmt.Sprintf("TryLockFile with %v", o), Actual: fmt.Sprint(ok, e), Want: "false <nil>"}))
+		}
+	}
+	// The test fails if LockFile with a timeout returns nil
+	if e := tsfio.LockFile(f, tsfio.LockOptions{Timeout: lockTimeout}); e == nil {
+		t.Error(tserr.NilFailed("LockFile"))
+	}
+	// Let the helper process release the lock and exit
+	in.Close()
+	if e := c.Wait(); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "helper process for", Fn: string(fn), Err: e}))
+	}
+	// The test fails if LockFile or UnlockFile return an error
+	if e := tsfio.LockFile(f, tsfio.LockOptions{}); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "LockFile", Fn: string(fn), Err: e}))
+	}
+	if e := tsfio.UnlockFile(f); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "UnlockFile", Fn: string(fn), Err: e}))
+	}
+	// Close and remove fn
+	f.Close()
+	rm(t, fn)
+}
+
+// TestLockShared tests TryLockFile to acquire shared locks on two open files and to fail to acquire an exclusive
+// lock on a third open file of the same file. The test fails if a lock is acquired or not as expected.
+func TestLockShared(t *testing.T) {
+	// Create temporary file fn
+	fn := tmpFile(t)
+	// Open fn three times
+	var fl [3]*os.File
+	for i := range fl {
+		f, err := tsfio.OpenFile(fn)
+		if err != nil {
+			t.Fatal(tserr.Op(&tserr.OpArgs{Op: "OpenFile", Fn: string(fn), Err: err}))
+		}
+		defer f.Close()
+		fl[i] = f
+	}
+	// The test fails if the shared locks are not acquired or the exclusive lock is acquired
+	for i, o := range []tsfio.LockOptions{{Shared: true}, {Shared: true}, {}} {
+		if ok, e := tsfio.TryLockFile(fl[i], o); (ok != (i < 2)) || (e != nil) {
+			t.Error(tserr.Return(&tserr.ReturnArgs{Op: fmt.Sprintf("TryLockFile %d with %v", i, o), Actual: fmt.Sprint(ok, e), Want: fmt.Sprint(i < 2, nil)}))
+		}
+	}
+	// Remove fn
+	rm(t, fn)
+}
+
+// TestLockConvert tests LockFile to convert an exclusive lock to a shared lock and back. The test fails if a shared
+// lock on another open file is not acquired after the conversion to a shared lock or if the exclusive lock is not
+// acquired after the other open file is closed.
+func TestLockConvert(t *testing.T) {
+	// Create temporary file fn
+	fn := tmpFile(t)
+	// Open fn twice
+	var fl [2]*os.File
+	for i := range fl {
+		f, err := tsfio.OpenFile(fn)
+		if err != nil {
+			t.Fatal(tserr.Op(&tserr.OpArgs{Op: "OpenFile", Fn: string(fn), Err: err}))
+		}
+		defer f.Close()
+		fl[i] = f
+	}
+	// Acquire an exclusive lock on the first open file and convert it to a shared lock
+	for _, o := range []tsfio.LockOptions{{}, {Shared: true}} {
+		if e := tsfio.LockFile(fl[0], o); e != nil {
+			t.Error(tserr.Op(&tserr.OpArgs{Op: fmt.Sprintf("LockFile with %v", o), Fn: string(fn), Err: e}))
+		}
+	}
+	// The test fails if the shared lock on the second open file is not acquired
+	if ok, e := tsfio.TryLockFile(fl[1], tsfio.LockOptions{Shared: true}); !ok || (e != nil) {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "TryLockFile with shared lock", Actual: fmt.Sprint(ok, e), Want: "true <nil>"}))
+	}
+	// The test fails if the exclusive lock is acquired while the second open file holds the shared lock
+	if ok, e := tsfio.TryLockFile(fl[0], tsfio.LockOptions{}); ok || (e != nil) {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "TryLockFile with exclusive lock", Actual: fmt.Sprint(ok, e), Want: "false <nil>"}))
+	}
+	// The test fails if the exclusive lock is not acquired after the second open file is closed
+	fl[1].Close()
+	if ok, e := tsfio.TryLockFile(fl[0], tsfio.LockOptions{}); !ok || (e != nil) {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "TryLockFile with exclusive lock", Actual: fmt.Sprint(ok, e), Want: "true <nil>"}))
+	}
+	// Close and remove fn
+	fl[0].Close()
+	rm(t, fn)
+}
+
+// TestLockMem tests LockFile and TryLockFile of an FS with a MemBackend. The test fails if a lock is acquired while
+// another open file holds an exclusive lock, or if a waiting LockFile does not acquire the lock after it is released.
+func TestLockMem(t *testing.T) {
+	// Create FS with MemBackend and Directory d
+	fsys, _, d := memFS(t)
+	fn := tsfio.Filename(filepath.Join(string(d), string(testfile)))
+	// Open fn twice
+	f1, err := fsys.OpenFile(fn)
+	if err != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "OpenFile", Fn: string(fn), Err: err}))
+	}
+	f2, err := fsys.OpenFile(fn)
+	if err != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "OpenFile", Fn: string(fn), Err: err}))
+	}
+	// Acquire an exclusive lock on f1
+	if e := fsys.LockFile(f1, tsfio.LockOptions{}); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "LockFile", Fn: string(fn), Err: e}))
+	}
+	// The test fails if TryLockFile acquires the lock on f2
+	if ok, e := fsys.TryLockFile(f2, tsfio.LockOptions{Shared: true}); ok || (e != nil) {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "TryLockFile", Actual: fmt.Sprint(ok, e), Want: "false <nil>"}))
+	}
+	// Wait for the lock on f2 and release the lock on f1 by closing it
+	done := make(chan error)
+	go func() { done <- fsys.LockFile(f2, tsfio.LockOptions{}) }()
+	f1.Close()
+	// The test fails if LockFile returns an error
+	if e := <-done; e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "LockFile", Fn: string(fn), Err: e}))
+	}
+	f2.Close()
+}
+
+// TestWriteStrLocked tests WriteStrLocked and AppendFileLocked to write and append to a temporary file.
+// The test fails if any of them returns an error or if the contents differ.
+func TestWriteStrLocked(t *testing.T) {
+	// Create temporary files fn and fi
+	fn, fi := tmpFile(t), tmpFile(t)
+	// Write testcase to fn and fi
+	for _, f := range []tsfio.Filename{fn, fi} {
+		if e := tsfio.WriteStrLocked(f, testcase); e != nil {
+			t.Error(tserr.Op(&tserr.OpArgs{Op: "WriteStrLocked", Fn: string(f), Err: e}))
+		}
+	}
+	// Append fi to fn
+	if e := tsfio.AppendFileLocked(&tsfio.Append{FileA: fn, FileI: fi}); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "AppendFileLocked", Fn: string(fn), Err: e}))
+	}
+	// The test fails if fn does not hold testcase twice
+	checkTree(t, tsfio.Directory(filepath.Dir(string(fn))), map[string]string{filepath.Base(string(fn)): testcase + testcase})
+	// Remove fn and fi
+	rm(t, fn)
+	rm(t, fi)
+}
+
+// TestLockFileNil tests LockFile, TryLockFile, UnlockFile and AppendFileLocked to return an error for nil.
+// The test fails if any of them returns nil.
+func TestLockFileNil(t *testing.T) {
+	if e := tsfio.LockFile(nil, tsfio.LockOptions{}); e == nil {
+		t.Error(tserr.NilFailed("LockFile"))
+	}
+	if _, e := tsfio.TryLockFile(nil, tsfio.LockOptions{}); e == nil {
+		t.Error(tserr.NilFailed("TryLockFile"))
+	}
+	if e := tsfio.UnlockFile(nil); e == nil {
+		t.Error(tserr.NilFailed("UnlockFile"))
+	}
+	if e := tsfio.AppendFileLocked(nil); e == nil {
+		t.Error(tserr.NilFailed("AppendFileLocked"))
+	}
+}
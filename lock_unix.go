@@ -0,0 +1,47 @@
+//go:build !windows
+
+package tsfio
+
+// Import standard library package syscall
+import "syscall" // syscall
+
+// lockFd acquires an advisory lock on file descriptor fd with flock. The lock is shared, if shared is true,
+// and exclusive otherwise. If block is true, it waits until the lock is acquired. It returns true, if the lock
+// is acquired. It returns false, if block is false and the lock is held by another open file. It returns
+// false and an error, if any.
+func lockFd(fd uintptr, shared, block bool) (bool, error) {
+	// Set the operation for a shared or exclusive and a blocking or non-blocking lock
+	how := syscall.LOCK_EX
+	if shared {
+		how = syscall.LOCK_SH
+	}
+	if !block {
+		how |= syscall.LOCK_NB
+	}
+	for {
+		err := syscall.Flock(int(fd), how)
+		switch err {
+		case nil:
+			// The lock is acquired
+			return true, nil
+		case syscall.EINTR:
+			// Retry, if interrupted by a signal
+			continue
+		case syscall.EWOULDBLOCK:
+			// The lock is held by another open file
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+}
+
+// unlockFd releases the advisory lock on file descriptor fd with flock. It returns an error, if any.
+func unlockFd(fd uintptr) error {
+	for {
+		// Retry, if interrupted by a signal
+		if err := syscall.Flock(int(fd), syscall.LOCK_UN); err != syscall.EINTR {
+			return err
+		}
+	}
+}
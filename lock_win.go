@@ -0,0 +1,67 @@
+//go:build windows
+
+package tsfio
+
+// Import standard library packages syscall and unsafe
+import (
+	"syscall" // syscall
+	"unsafe"  // unsafe
+)
+
+// The procedures LockFileEx and UnlockFileEx of kernel32.dll lock and unlock files
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll") // kernel32 holds kernel32.dll
+	procLockFileEx   = kernel32.NewProc("LockFileEx")     // procLockFileEx holds LockFileEx
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")   // procUnlockFileEx holds UnlockFileEx
+)
+
+// Flags of LockFileEx and errors returned by LockFileEx
+const (
+	lockfileFailImmediately uint32        = 0x1        // LOCKFILE_FAIL_IMMEDIATELY returns immediately, if the lock is held
+	lockfileExclusiveLock   uint32        = 0x2        // LOCKFILE_EXCLUSIVE_LOCK acquires an exclusive lock
+	lockAllBytes            uint32        = ^uint32(0) // lockAllBytes holds the low and high order of the number of bytes to lock
+	errLockViolation        syscall.Errno = 33         // ERROR_LOCK_VIOLATION is returned, if the lock is held
+	errNotLocked            syscall.Errno = 158        // ERROR_NOT_LOCKED is returned, if no lock is released
+)
+
+// lockFd acquires a lock on all bytes of file handle fd with LockFileEx. The lock is shared, if shared is true,
+// and exclusive otherwise. If block is true, it waits until the lock is acquired. It returns true, if the lock
+// is acquired. It returns false, if block is false and the lock is held by another open file. It returns
+// false and an error, if any. An existing lock of fd is released first, so the lock is converted like with flock
+// on Unix and a shared lock is not held twice.
+func lockFd(fd uintptr, shared, block bool) (bool, error) {
+	// Release an existing lock of fd, if any
+	if err := unlockFd(fd); (err != nil) && (err != errNotLocked) {
+		return false, err
+	}
+	// Set the flags for a shared or exclusive and a blocking or non-blocking lock
+	var flags uint32
+	if !shared {
+		flags |= lockfileExclusiveLock
+	}
+	if !block {
+		flags |= lockfileFailImmediately
+	}
+	// Lock all bytes of the file starting at offset zero
+	ol := new(syscall.Overlapped)
+	r, _, err := procLockFileEx.Call(fd, uintptr(flags), 0, uintptr(lockAllBytes), uintptr(lockAllBytes), uintptr(unsafe.Pointer(ol)))
+	if r != 0 {
+		// The lock is acquired
+		return true, nil
+	}
+	if err == errLockViolation {
+		// The lock is held by another open file
+		return false, nil
+	}
+	return false, err
+}
+
+// unlockFd releases the lock on all bytes of file handle fd with UnlockFileEx. It returns an error, if any.
+func unlockFd(fd uintptr) error {
+	// Unlock all bytes of the file starting at offset zero
+	ol := new(syscall.Overlapped)
+	if r, _, err := procUnlockFileEx.Call(fd, 0, uintptr(lockAllBytes), uintptr(lockAllBytes), uintptr(unsafe.Pointer(ol))); r == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,715 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio
+
+// Import standard library packages
+import (
+	"io"            // io
+	"io/fs"         // fs
+	"os"            // os
+	"path/filepath" // filepath
+	"slices"        // slices
+	"strconv"       // strconv
+	"strings"       // strings
+	"sync"          // sync
+	"syscall"       // syscall
+	"time"          // time
+)
+
+// MemBackend is a Backend holding all files and directories in memory. Nothing is read from or written to the
+// operating system. Relative names are resolved against the working directory, as done by the operating system.
+// The root directory of each volume exists. Files and directories are created with the permission bits as given,
+// without a umask. Symbolic links are not supported. Open files can be locked with LockFile of an FS, which excludes
+// other open files of the MemBackend. The zero value of MemBackend is an empty file system ready
+// to use. A MemBackend is safe for concurrent use and must not be copied after first use.
+type MemBackend struct {
+	mu    sync.Mutex          // mu protects nodes, temp and the locks of files
+	nodes map[string]*memNode // nodes holds the files and directories by absolute path
+	temp  uint64              // temp holds the counter for names of temporary files and directories
+	cond  *sync.Cond          // cond signals released locks of files
+}
+
+// A memNode holds a file or directory of a MemBackend
+type memNode struct {
+	data  []byte      // data holds the contents of a file
+	mode  fs.FileMode // mode holds the file mode and permission bits
+	mtime time.Time   // mtime holds the modification time
+	locks int         // locks holds the number of shared locks or -1 for an exclusive lock
+}
+
+// A memInfo holds the FileInfo of a memNode
+type memInfo struct {
+	name string      // name holds the base name
+	size int64       // size holds the length in bytes
+	node *memNode    // node holds the described memNode
+	mode fs.FileMode // mode holds the file mode and permission bits
+	time time.Time   // time holds the modification time
+}
+
+// Name returns the base name of the file or directory
+func (i *memInfo) Name() string { return i.name }
+
+// Size returns the length in bytes of a file
+func (i *memInfo) Size() int64 { return i.size }
+
+// Mode returns the file mode and permission bits
+func (i *memInfo) Mode() fs.FileMode { return i.mode }
+
+// ModTime returns the modification time
+func (i *memInfo) ModTime() time.Time { return i.time }
+
+// IsDir returns true for a directory
+func (i *memInfo) IsDir() bool { return i.mode.IsDir() }
+
+// Sys returns nil
+func (i *memInfo) Sys() any { return nil }
+
+// info returns the FileInfo of n with base name of path p
+func (n *memNode) info(p string) fs.FileInfo {
+	return &memInfo{name: filepath.Base(p), size: int64(len(n.data)), node: n, mode: n.mode, time: n.mtime}
+}
+
+// memErr returns an *fs.PathError for operation op on name with error err
+func memErr(op, name string, err error) error {
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// abs returns the absolute path of name. It returns an error, if the absolute path cannot be retrieved.
+func (m *MemBackend) abs(op, name string) (string, error) {
+	p, err := filepath.Abs(name)
+	if err != nil {
+		return "", memErr(op, name, err)
+	}
+	return p, nil
+}
+
+// node returns the memNode of absolute path p or nil, if p does not exist. The root directory of a volume always
+// exists. The caller must hold m.mu.
+func (m *MemBackend) node(p string) *memNode {
+	// Create nodes on first use
+	if m.nodes == nil {
+		m.nodes = make(map[string]*memNode)
+	}
+	n, ok := m.nodes[p]
+	// Create the root directory of the volume on first use
+	if !ok && (filepath.Dir(p) == p) {
+		n = &memNode{mode: fs.ModeDir | dperm, mtime: time.Now()}
+		m.nodes[p] = n
+	}
+	return n
+}
+
+// parent returns the memNode of the parent directory of absolute path p. It returns an error for operation op on name,
+// if the parent directory does not exist or is not a directory. The caller must hold m.mu.
+func (m *MemBackend) parent(op, name, p string) (*memNode, error) {
+	d := m.node(filepath.Dir(p))
+	if d == nil {
+		return nil, memErr(op, name, fs.ErrNotExist)
+	}
+	if !d.mode.IsDir() {
+		return nil, memErr(op, name, syscall.ENOTDIR)
+	}
+	return d, nil
+}
+
+// lookup returns the memNode of name. It returns an error for operation op, if name does not exist.
+// The caller must hold m.mu.
+func (m *MemBackend) lookup(op, name string) (string, *memNode, error) {
+	p, err := m.abs(op, name)
+	if err != nil {
+		return "", nil, err
+	}
+	n := m.node(p)
+	if n == nil {
+		return "", nil, memErr(op, name, fs.ErrNotExist)
+	}
+	return p, n, nil
+}
+
+// Stat returns the FileInfo of name.
+func (m *MemBackend) Stat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, n, err := m.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return n.info(p), nil
+}
+
+// Lstat returns the FileInfo of name. It behaves like Stat, since symbolic links are not supported.
+func (m *MemBackend) Lstat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, n, err := m.lookup("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	return n.info(p), nil
+}
+
+// Readlink returns an error, since symbolic links are not supported.
+func (m *MemBackend) Readlink(name string) (string, error) {
+	return "", memErr("readlink", name, fs.ErrInvalid)
+}
+
+// OpenFile opens name with flag and creates it with permission bits perm, if flag contains os.O_CREATE.
+// It returns an error, if name is a directory.
+func (m *MemBackend) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.open(name, flag, perm)
+}
+
+// open opens name with flag and permission bits perm. The caller must hold m.mu.
+func (m *MemBackend) open(name string, flag int, perm fs.FileMode) (File, error) {
+	p, err := m.abs("open", name)
+	if err != nil {
+		return nil, err
+	}
+	n := m.node(p)
+	switch {
+	case n == nil:
+		// Return an error if name does not exist and may not be created
+		if flag&os.O_CREATE == 0 {
+			return nil, memErr("open", name, fs.ErrNotExist)
+		}
+		// Return an error if the parent directory does not exist
+		if _, e := m.parent("open", name, p); e != nil {
+			return nil, e
+		}
+		// Create name
+		n = &memNode{mode: perm.Perm(), mtime: time.Now()}
+		m.nodes[p] = n
+	case (flag&os.O_CREATE != 0) && (flag&os.O_EXCL != 0):
+		// Return an error if name exists and must be created
+		return nil, memErr("open", name, fs.ErrExist)
+	case n.mode.IsDir():
+		// Return an error if name is a directory
+		return nil, memErr("open", name, syscall.EISDIR)
+	case (flag&os.O_TRUNC != 0) && (flag&(os.O_WRONLY|os.O_RDWR) != 0):
+		// Truncate name
+		n.data, n.mtime = nil, time.Now()
+	}
+	return &memFile{m: m, n: n, name: name, flag: flag}, nil
+}
+
+// ReadFile returns the contents of name.
+func (m *MemBackend) ReadFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, n, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if n.mode.IsDir() {
+		return nil, memErr("read", name, syscall.EISDIR)
+	}
+	return append([]byte{}, n.data...), nil
+}
+
+// ReadDir returns the entries of directory name sorted by filename.
+func (m *MemBackend) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, n, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if !n.mode.IsDir() {
+		return nil, memErr("readdirent", name, syscall.ENOTDIR)
+	}
+	// Collect the entries of p
+	var des []fs.DirEntry
+	for k, c := range m.nodes {
+		if (k != p) && (filepath.Dir(k) == p) {
+			des = append(des, fs.FileInfoToDirEntry(c.info(k)))
+		}
+	}
+	// Sort the entries by filename
+	slices.SortFunc(des, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return des, nil
+}
+
+// Truncate changes the size of file name.
+func (m *MemBackend) Truncate(name string, size int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, n, err := m.lookup("truncate", name)
+	if err != nil {
+		return err
+	}
+	if n.mode.IsDir() {
+		return memErr("truncate", name, syscall.EISDIR)
+	}
+	return n.truncate("truncate", name, size)
+}
+
+// truncate changes the size of file n with name. It returns an error for operation op, if size is negative.
+// The caller must hold the mutex of the MemBackend.
+func (n *memNode) truncate(op, name string, size int64) error {
+	if size < 0 {
+		return memErr(op, name, fs.ErrInvalid)
+	}
+	if size <= int64(len(n.data)) {
+		n.data = n.data[:size]
+	} else {
+		n.data = append(n.data, make([]byte, size-int64(len(n.data)))...)
+	}
+	n.mtime = time.Now()
+	return nil
+}
+
+// Chtimes changes the modification time of name. The access time is not held. A zero mtime leaves the
+// modification time unchanged.
+func (m *MemBackend) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, n, err := m.lookup("chtimes", name)
+	if err != nil {
+		return err
+	}
+	if !mtime.IsZero() {
+		n.mtime = mtime
+	}
+	return nil
+}
+
+// Chmod changes the permission bits of name to the permission bits of mode.
+func (m *MemBackend) Chmod(name string, mode fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, n, err := m.lookup("chmod", name)
+	if err != nil {
+		return err
+	}
+	n.mode = (n.mode &^ fs.ModePerm) | mode.Perm()
+	return nil
+}
+
+// MkdirAll creates directory name with permission bits perm and any necessary parents. If name is a directory,
+// it does nothing and returns nil.
+func (m *MemBackend) MkdirAll(name string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, err := m.abs("mkdir", name)
+	if err != nil {
+		return err
+	}
+	// Collect the missing directories from p up to the first existing parent
+	var ps []string
+	for n := m.node(p); n == nil; n = m.node(p) {
+		ps = append(ps, p)
+		p = filepath.Dir(p)
+	}
+	// Return an error, if the existing parent is not a directory
+	if !m.node(p).mode.IsDir() {
+		return memErr("mkdir", p, syscall.ENOTDIR)
+	}
+	// Create the missing directories top down
+	for i := len(ps) - 1; i >= 0; i-- {
+		m.nodes[ps[i]] = &memNode{mode: fs.ModeDir | perm.Perm(), mtime: time.Now()}
+	}
+	return nil
+}
+
+// Remove removes file or empty directory name.
+func (m *MemBackend) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, n, err := m.lookup("remove", name)
+	if err != nil {
+		return err
+	}
+	// Return an error for the root directory of a volume
+	if filepath.Dir(p) == p {
+		return memErr("remove", name, fs.ErrInvalid)
+	}
+	// Return an error for a directory with entries
+	if n.mode.IsDir() && m.children(p) {
+		return memErr("remove", name, syscall.ENOTEMPTY)
+	}
+	delete(m.nodes, p)
+	return nil
+}
+
+// children returns true, if directory p contains entries. The caller must hold m.mu.
+func (m *MemBackend) children(p string) bool {
+	for k := range m.nodes {
+		if (k != p) && (filepath.Dir(k) == p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rename moves oldname to newname. An existing file newname is replaced by file oldname and an existing
+// empty directory newname is replaced by directory oldname. It returns an error, if newname resides in oldname.
+func (m *MemBackend) Rename(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// e returns a *os.LinkError for err
+	e := func(err error) error {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+	}
+	po, no, err := m.lookup("rename", oldname)
+	if err != nil {
+		return e(fs.ErrNotExist)
+	}
+	pn, err := m.abs("rename", newname)
+	if err != nil {
+		return err
+	}
+	// Nothing to do, if oldname and newname are the same
+	if po == pn {
+		return nil
+	}
+	// Return an error, if the parent directory of newname does not exist or newname resides in oldname
+	if _, err := m.parent("rename", newname, pn); err != nil {
+		return e(err.(*fs.PathError).Err)
+	}
+	if (filepath.Dir(po) == po) || within(po, pn) {
+		return e(fs.ErrInvalid)
+	}
+	// Check an existing newname
+	if nn := m.node(pn); nn != nil {
+		switch {
+		case no.mode.IsDir() && !nn.mode.IsDir():
+			return e(syscall.ENOTDIR)
+		case !no.mode.IsDir() && nn.mode.IsDir():
+			return e(syscall.EISDIR)
+		case nn.mode.IsDir() && m.children(pn):
+			return e(syscall.ENOTEMPTY)
+		}
+	}
+	// Collect oldname and its entries
+	var ks []string
+	for k := range m.nodes {
+		if within(po, k) {
+			ks = append(ks, k)
+		}
+	}
+	// Move oldname and its entries to newname
+	for _, k := range ks {
+		n := m.nodes[k]
+		delete(m.nodes, k)
+		m.nodes[pn+k[len(po):]] = n
+	}
+	return nil
+}
+
+// tempName returns the name in dir for pattern. The last * in pattern is replaced by a counter. If pattern does not
+// contain a *, the counter is appended. It returns an error for operation op, if pattern contains a path separator.
+// The caller must hold m.mu.
+func (m *MemBackend) tempName(op, dir, pattern string) (string, error) {
+	// Return an error, if pattern contains a path separator
+	if strings.ContainsFunc(pattern, isSeparator) {
+		return "", memErr(op, pattern, fs.ErrInvalid)
+	}
+	// Use the default directory for temporary files, if dir is empty
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	// Split pattern into prefix and suffix
+	pre, suf := pattern, ""
+	if i := strings.LastIndex(pattern, "*"); i >= 0 {
+		pre, suf = pattern[:i], pattern[i+1:]
+	}
+	m.temp++
+	return filepath.Join(dir, pre+strconv.FormatUint(m.temp, 10)+suf), nil
+}
+
+// CreateTemp creates and opens a new temporary file in directory dir for reading and writing. The name of the file
+// is generated from pattern, as done by os.CreateTemp.
+func (m *MemBackend) CreateTemp(dir, pattern string) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for {
+		// Retrieve the name of the temporary file
+		name, err := m.tempName("createtemp", dir, pattern)
+		if err != nil {
+			return nil, err
+		}
+		// Create the temporary file and try again with another name, if it exists
+		f, err := m.open(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+		if (err == nil) || !os.IsExist(err) {
+			return f, err
+		}
+	}
+}
+
+// MkdirTemp creates a new temporary directory in directory dir and returns its name. The name of the directory
+// is generated from pattern, as done by os.MkdirTemp.
+func (m *MemBackend) MkdirTemp(dir, pattern string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for {
+		// Retrieve the name of the temporary directory
+		name, err := m.tempName("mkdirtemp", dir, pattern)
+		if err != nil {
+			return "", err
+		}
+		p, err := m.abs("mkdir", name)
+		if err != nil {
+			return "", err
+		}
+		// Try again with another name, if the temporary directory exists
+		if m.node(p) != nil {
+			continue
+		}
+		// Return an error, if the parent directory does not exist
+		if _, e := m.parent("mkdir", name, p); e != nil {
+			return "", e
+		}
+		// Create the temporary directory
+		m.nodes[p] = &memNode{mode: fs.ModeDir | 0700, mtime: time.Now()}
+		return name, nil
+	}
+}
+
+// SameFile returns true, if fi1 and fi2 describe the same file or directory of a MemBackend.
+func (m *MemBackend) SameFile(fi1, fi2 fs.FileInfo) bool {
+	i1, ok1 := fi1.(*memInfo)
+	i2, ok2 := fi2.(*memInfo)
+	return ok1 && ok2 && (i1.node == i2.node)
+}
+
+// SyncDir does nothing. It returns an error, if directory name does not exist.
+func (m *MemBackend) SyncDir(name string) error {
+	fi, err := m.Stat(name)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return memErr("sync", name, syscall.ENOTDIR)
+	}
+	return nil
+}
+
+// A memFile is an open file of a MemBackend
+type memFile struct {
+	m      *MemBackend // m holds the MemBackend of the file
+	n      *memNode    // n holds the memNode of the file
+	name   string      // name holds the name as passed to OpenFile
+	flag   int         // flag holds the flags passed to OpenFile
+	off    int64       // off holds the offset for reading and writing
+	closed bool        // closed is true after Close
+	held   int         // held holds the lock of the file: 0 for none, 1 for a shared lock or -1 for an exclusive lock
+}
+
+// check returns an error for operation op, if f is closed or, if w is true and f is not open for writing,
+// or, if w is false and f is not open for reading. The caller must hold the mutex of the MemBackend.
+func (f *memFile) check(op string, w bool) error {
+	if f.closed {
+		return memErr(op, f.name, fs.ErrClosed)
+	}
+	a := f.flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR)
+	if (w && (a == os.O_RDONLY)) || (!w && (a == os.O_WRONLY)) {
+		return memErr(op, f.name, syscall.EBADF)
+	}
+	return nil
+}
+
+// Read reads up to len(b) bytes from f. It returns io.EOF at the end of the file.
+func (f *memFile) Read(b []byte) (int, error) {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if err := f.check("read", false); err != nil {
+		return 0, err
+	}
+	if f.off >= int64(len(f.n.data)) {
+		return 0, io.EOF
+	}
+	n := copy(b, f.n.data[f.off:])
+	f.off += int64(n)
+	return n, nil
+}
+
+// Write writes b to f. If f is opened with os.O_APPEND, b is written to the end of the file.
+func (f *memFile) Write(b []byte) (int, error) {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if err := f.check("write", true); err != nil {
+		return 0, err
+	}
+	if f.flag&os.O_APPEND != 0 {
+		f.off = int64(len(f.n.data))
+	}
+	// Extend the file, if b is written beyond its end
+	if e := f.off + int64(len(b)); e > int64(len(f.n.data)) {
+		f.n.data = append(f.n.data, make([]byte, e-int64(len(f.n.data)))...)
+	}
+	n := copy(f.n.data[f.off:], b)
+	f.off += int64(n)
+	f.n.mtime = time.Now()
+	return n, nil
+}
+
+// ReadAt reads len(b) bytes from f starting at offset off. The offset for Read is not changed. It returns
+// io.EOF, if fewer than len(b) bytes remain.
+func (f *memFile) ReadAt(b []byte, off int64) (int, error) {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if err := f.check("readat", false); err != nil {
+		return 0, err
+	}
+	if off < 0 {
+		return 0, memErr("readat", f.name, fs.ErrInvalid)
+	}
+	if off >= int64(len(f.n.data)) {
+		return 0, io.EOF
+	}
+	n := copy(b, f.n.data[off:])
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// WriteAt writes b to f starting at offset off. The offset for Write is not changed. It returns an error, if f
+// is opened with os.O_APPEND, as done by os.File.
+func (f *memFile) WriteAt(b []byte, off int64) (int, error) {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if err := f.check("writeat", true); err != nil {
+		return 0, err
+	}
+	if (f.flag&os.O_APPEND != 0) || (off < 0) {
+		return 0, memErr("writeat", f.name, fs.ErrInvalid)
+	}
+	// Extend the file, if b is written beyond its end
+	if e := off + int64(len(b)); e > int64(len(f.n.data)) {
+		f.n.data = append(f.n.data, make([]byte, e-int64(len(f.n.data)))...)
+	}
+	n := copy(f.n.data[off:], b)
+	f.n.mtime = time.Now()
+	return n, nil
+}
+
+// Close closes f. It returns an error, if f is already closed.
+func (f *memFile) Close() error {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if f.closed {
+		return memErr("close", f.name, fs.ErrClosed)
+	}
+	// Release the lock of f, if any
+	f.release()
+	f.closed = true
+	return nil
+}
+
+// Name returns the name of f as passed to OpenFile.
+func (f *memFile) Name() string {
+	return f.name
+}
+
+// Stat returns the FileInfo of f.
+func (f *memFile) Stat() (fs.FileInfo, error) {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if f.closed {
+		return nil, memErr("stat", f.name, fs.ErrClosed)
+	}
+	return f.n.info(f.name), nil
+}
+
+// Sync does nothing. It returns an error, if f is closed.
+func (f *memFile) Sync() error {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if f.closed {
+		return memErr("sync", f.name, fs.ErrClosed)
+	}
+	return nil
+}
+
+// Truncate changes the size of f.
+func (f *memFile) Truncate(size int64) error {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if err := f.check("truncate", true); err != nil {
+		return err
+	}
+	return f.n.truncate("truncate", f.name, size)
+}
+
+// Chmod changes the permission bits of f to the permission bits of mode.
+func (f *memFile) Chmod(mode fs.FileMode) error {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if f.closed {
+		return memErr("chmod", f.name, fs.ErrClosed)
+	}
+	f.n.mode = (f.n.mode &^ fs.ModePerm) | mode.Perm()
+	return nil
+}
+
+// lock acquires a shared lock on f, if shared is true, or an exclusive lock otherwise. An existing lock of f is
+// converted. If block is true, it waits until the lock is acquired. It returns true, if the lock is acquired.
+// It returns false, if block is false and the lock is held by another open file. It returns false and an error, if f is closed.
+func (f *memFile) lock(shared, block bool) (bool, error) {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	// Create cond on first use
+	if f.m.cond == nil {
+		f.m.cond = sync.NewCond(&f.m.mu)
+	}
+	for {
+		// Return an error, if f is closed
+		if f.closed {
+			return false, memErr("lock", f.name, fs.ErrClosed)
+		}
+		// Release the lock of f to convert it
+		f.release()
+		// Acquire the lock, if it is not held by another open file
+		if shared && (f.n.locks >= 0) {
+			f.n.locks++
+			f.held = 1
+			return true, nil
+		}
+		if !shared && (f.n.locks == 0) {
+			f.n.locks, f.held = -1, -1
+			return true, nil
+		}
+		// Return false, if f may not wait
+		if !block {
+			return false, nil
+		}
+		// Wait for a released lock
+		f.m.cond.Wait()
+	}
+}
+
+// unlock releases the lock of f. It returns an error, if f is closed.
+func (f *memFile) unlock() error {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if f.closed {
+		return memErr("unlock", f.name, fs.ErrClosed)
+	}
+	f.release()
+	return nil
+}
+
+// release releases the lock of f, if any, and signals waiting open files. The caller must hold the mutex of the MemBackend.
+func (f *memFile) release() {
+	// Return, if f does not hold a lock
+	if f.held == 0 {
+		return
+	}
+	// Release a shared or exclusive lock
+	if f.held > 0 {
+		f.n.locks--
+	} else {
+		f.n.locks = 0
+	}
+	f.held = 0
+	// Signal waiting open files
+	if f.m.cond != nil {
+		f.m.cond.Broadcast()
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio_test
+
+// Import standard library packages as well as tserr and tsfio
+import (
+	"errors"        // errors
+	"fmt"           // fmt
+	"io/fs"         // fs
+	"os"            // os
+	"path/filepath" // filepath
+	"testing"       // testing
+
+	"github.com/thorstenrie/tserr" // tserr
+	"github.com/thorstenrie/tsfio" // tsfio
+)
+
+// memFS returns an FS with a new MemBackend and a Directory, which does not exist in the file system of
+// the operating system. The Directory is created in the MemBackend. In case of an error, execution stops.
+func memFS(t *testing.T) (*tsfio.FS, *tsfio.MemBackend, tsfio.Directory) {
+	// Panic if t is nil
+	if t == nil {
+		panic("nil pointer")
+	}
+	// Retrieve a Directory d, which does not exist in the file system
+	d := tmpDir(t)
+	rm(t, d)
+	// Create FS with a new MemBackend
+	m := &tsfio.MemBackend{}
+	fsys := &tsfio.FS{Backend: m}
+	// Create d in the MemBackend
+	if e := fsys.CreateDir(d); e != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "CreateDir", Fn: string(d), Err: e}))
+	}
+	// Return fsys, m and d
+	return fsys, m, d
+}
+
+// memNotOnDisk tests if Directory d does not exist in the file system of the operating system.
+// The test fails if d exists.
+func memNotOnDisk(t *testing.T, d tsfio.Directory) {
+	// Panic if t is nil
+	if t == nil {
+		panic("nil pointer")
+	}
+	// The test fails if d exists
+	if _, err := os.Stat(string(d)); !errors.Is(err, fs.ErrNotExist) {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: fmt.Sprintf("Stat of %v", d), Actual: fmt.Sprint(err), Want: "not existent"}))
+	}
+}
+
+// memTree creates the files of the map m in Directory d with fsys. The keys of m are the paths of the files
+// relative to d and the values are the contents of the files. Parent directories are created as needed.
+// In case of an error, execution stops.
+func memTree(t *testing.T, fsys *tsfio.FS, d tsfio.Directory, m map[string]string) {
+	// Panic if t is nil
+	if t == nil {
+		panic("nil pointer")
+	}
+	// Iterate over the files in m
+	for n, c := range m {
+		// Retrieve the path of the file
+		p := filepath.Join(string(d), n)
+		// Create the parent directories of the file
+		if e := fsys.CreateDir(tsfio.Directory(filepath.Dir(p))); e != nil {
+			t.Fatal(tserr.Op(&tserr.OpArgs{Op: "CreateDir", Fn: filepath.Dir(p), Err: e}))
+		}
+		// Write the contents to the file
+		if e := fsys.WriteSingleStr(tsfio.Filename(p), c); e != nil {
+			t.Fatal(tserr.Op(&tserr.OpArgs{Op: "WriteSingleStr", Fn: p, Err: e}))
+		}
+	}
+}
+
+// memCheckTree tests if the files of the map m exist in Directory d of fsys with the expected contents.
+// The test fails if a file cannot be read or its contents differ.
+func memCheckTree(t *testing.T, fsys *tsfio.FS, d tsfio.Directory, m map[string]string) {
+	// Panic if t is nil
+	if t == nil {
+		panic("nil pointer")
+	}
+	// Iterate over the files in m
+	for n, c := range m {
+		// Retrieve the path of the file
+		p := tsfio.Filename(filepath.Join(string(d), n))
+		// Read the file
+		b, err := fsys.ReadFile(p)
+		// The test fails if ReadFile returns an error
+		if err != nil {
+			t.Error(tserr.Op(&tserr.OpArgs{Op: "ReadFile", Fn: string(p), Err: err}))
+			continue
+		}
+		// The test fails if the contents differ
+		if string(b) != c {
+			t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: string(p), Actual: string(b), Want: c}))
+		}
+	}
+}
+
+// TestMemWrite tests an FS with a MemBackend to write, append, reset and read files. The test fails if any
+// function returns an error, if the contents differ or if a file is written to the file system.
+func TestMemWrite(t *testing.T) {
+	// Create FS with MemBackend and Directory d
+	fsys, _, d := memFS(t)
+	// Create filenames a and b in d
+	a, b := tsfio.Filename(filepath.Join(string(d), "a")), tsfio.Filename(filepath.Join(string(d), "b"))
+	// Write testcase to a and b, and append b to a
+	if e := fsys.WriteStr(a, testcase); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "WriteStr", Fn: string(a), Err: e}))
+	}
+	if e := fsys.WriteSingleStr(b, testcase); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "WriteSingleStr", Fn: string(b), Err: e}))
+	}
+	if e := fsys.AppendFile(&tsfio.Append{FileA: a, FileI: b}); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "AppendFile", Fn: string(a), Err: e}))
+	}
+	// The test fails if a and b do not hold the expected contents
+	memCheckTree(t, fsys, d, map[string]string{"a": testcase + testcase, "b": testcase})
+	// The test fails if FileSize of a differs
+	if s, e := fsys.FileSize(a); (e != nil) || (s != int64(2*len(testcase))) {
+		t.Error(tserr.Equal(&tserr.EqualArgs{Var: "FileSize of " + string(a), Actual: s, Want: int64(2 * len(testcase))}))
+	}
+	// Reset b and touch c
+	if e := fsys.ResetFile(b); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "ResetFile", Fn: string(b), Err: e}))
+	}
+	c := tsfio.Filename(filepath.Join(string(d), "c"))
+	if e := fsys.TouchFile(c); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "TouchFile", Fn: string(c), Err: e}))
+	}
+	// The test fails if b and c are not empty
+	memCheckTree(t, fsys, d, map[string]string{"b": "", "c": ""})
+	// Remove c
+	if e := fsys.RemoveFile(c); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "RemoveFile", Fn: string(c), Err: e}))
+	}
+	// The test fails if c exists
+	if ok, e := fsys.ExistsFile(c); ok || (e != nil) {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "ExistsFile " + string(c), Actual: fmt.Sprint(ok, e), Want: "false <nil>"}))
+	}
+	// The test fails if d exists in the file system
+	memNotOnDisk(t, d)
+}
+
+// TestMemAppendFiles tests an FS with a MemBackend to append files with a separator, including the file to be
+// extended itself. The test fails if AppendFilesWith returns an error or if the contents differ.
+func TestMemAppendFiles(t *testing.T) {
+	// Create FS with MemBackend and Directory d
+	fsys, _, d := memFS(t)
+	// Write a and b to d
+	memTree(t, fsys, d, map[string]string{"a": testcase, "b": testcase_unix})
+	a, b := tsfio.Filename(filepath.Join(string(d), "a")), tsfio.Filename(filepath.Join(string(d), "b"))
+	// Append b and a to a with a separator
+	if e := fsys.AppendFilesWith(a, []tsfio.Filename{b, a}, tsfio.AppendOptions{Separator: "|"}); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "AppendFilesWith", Fn: string(a), Err: e}))
+	}
+	// The test fails if a does not hold the expected contents. When a is appended to itself, it holds
+	// b and the separator already.
+	w := testcase + testcase_unix + "|"
+	w += w
+	memCheckTree(t, fsys, d, map[string]string{"a": w, "b": testcase_unix})
+	// The test fails if d exists in the file system
+	memNotOnDisk(t, d)
+}
+
+// TestMemTree tests an FS with a MemBackend to copy, move and remove a directory tree. The test fails if any
+// function returns an error, if the contents differ or if a directory is written to the file system.
+func TestMemTree(t *testing.T) {
+	// Create FS with MemBackend and Directory d
+	fsys, _, d := memFS(t)
+	// Create directories src, cp and mv in d
+	src := tsfio.Directory(filepath.Join(string(d), "src"))
+	cp := tsfio.Directory(filepath.Join(string(d), "cp"))
+	mv := tsfio.Directory(filepath.Join(string(d), "mv"))
+	// Write testTree to src
+	memTree(t, fsys, src, testTree)
+	// Copy src to cp
+	if e := fsys.CopyDirWith(src, cp, tsfio.CopyOptions{Mode: true, ModTime: true}); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "CopyDirWith " + string(src) + " to", Fn: string(cp), Err: e}))
+	}
+	// Move cp to mv
+	if e := fsys.MoveDir(cp, mv); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "MoveDir " + string(cp) + " to", Fn: string(mv), Err: e}))
+	}
+	// The test fails if mv does not hold testTree
+	memCheckTree(t, fsys, mv, testTree)
+	// The test fails if cp exists
+	if ok, e := fsys.ExistsDir(cp); ok || (e != nil) {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "ExistsDir " + string(cp), Actual: fmt.Sprint(ok, e), Want: "false <nil>"}))
+	}
+	// The test fails if RemoveDir removes a directory which is not empty
+	if e := fsys.RemoveDir(src, tsfio.RemoveOptions{}); e == nil {
+		t.Error(tserr.NilFailed("RemoveDir"))
+	}
+	// Remove d recursively
+	if e := fsys.RemoveDir(d, tsfio.RemoveOptions{Recursive: true}); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "RemoveDir", Fn: string(d), Err: e}))
+	}
+	// The test fails if d exists
+	if ok, e := fsys.ExistsDir(d); ok || (e != nil) {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "ExistsDir " + string(d), Actual: fmt.Sprint(ok, e), Want: "false <nil>"}))
+	}
+	// The test fails if d exists in the file system
+	memNotOnDisk(t, d)
+}
+
+// TestMemCheck tests an FS with a MemBackend to perform the checks of CheckFile and CheckDir. The test fails if CheckDir
+// returns nil for a file, if CheckFile returns nil for a directory, if WriteStr returns nil for a blocked directory or if
+// OpenFile returns nil for a file in a directory, which does not exist.
+func TestMemCheck(t *testing.T) {
+	// Create FS with MemBackend and Directory d
+	fsys, _, d := memFS(t)
+	// Create file a in d
+	a := tsfio.Filename(filepath.Join(string(d), "a"))
+	if e := fsys.TouchFile(a); e != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "TouchFile", Fn: string(a), Err: e}))
+	}
+	// The test fails if CheckDir returns nil for a
+	if e := fsys.CheckDir(tsfio.Directory(a)); e == nil {
+		t.Error(tserr.NilFailed("CheckDir of " + string(a)))
+	}
+	// The test fails if CheckFile returns nil for d
+	if e := fsys.CheckFile(tsfio.Filename(d)); e == nil {
+		t.Error(tserr.NilFailed("CheckFile of " + string(d)))
+	}
+	// The test fails if WriteStr returns nil for a file in a blocked directory
+	if x := tsfio.Filename(filepath.Join(string(tsfio.InvalDir()[0]), string(testfile))); fsys.WriteStr(x, testcase) == nil {
+		t.Error(tserr.NilFailed("WriteStr to " + string(x)))
+	}
+	// The test fails if OpenFile returns nil for a file in a directory, which does not exist
+	b := tsfio.Filename(filepath.Join(string(d), "b", "c"))
+	if _, e := fsys.OpenFile(b); e == nil {
+		t.Error(tserr.NilFailed("OpenFile of " + string(b)))
+	}
+}
+
+// TestMemBackend tests the methods of a MemBackend to return errors. The test fails if a method returns nil
+// or an error with an unexpected type.
+func TestMemBackend(t *testing.T) {
+	// Create FS with MemBackend m and Directory d
+	_, m, d := memFS(t)
+	// Create filename a in d
+	a := filepath.Join(string(d), "a")
+	// The test fails if OpenFile returns an error other than fs.ErrNotExist for a file, which does not exist
+	if _, e := m.OpenFile(a, os.O_RDONLY, 0); !errors.Is(e, fs.ErrNotExist) {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "OpenFile " + a, Actual: fmt.Sprint(e), Want: fs.ErrNotExist.Error()}))
+	}
+	// Create a write-only file a
+	f, err := m.OpenFile(a, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "OpenFile", Fn: a, Err: err}))
+	}
+	// The test fails if Read returns nil for the write-only file a
+	if _, e := f.Read(make([]byte, 1)); e == nil {
+		t.Error(tserr.NilFailed("Read of " + a))
+	}
+	// The test fails if the second Close returns an error other than fs.ErrClosed
+	f.Close()
+	if e := f.Close(); !errors.Is(e, fs.ErrClosed) {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "Close " + a, Actual: fmt.Sprint(e), Want: fs.ErrClosed.Error()}))
+	}
+	// The test fails if OpenFile returns an error other than fs.ErrExist for an existing file created exclusively
+	if _, e := m.OpenFile(a, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644); !errors.Is(e, fs.ErrExist) {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "OpenFile " + a, Actual: fmt.Sprint(e), Want: fs.ErrExist.Error()}))
+	}
+	// The test fails if Remove returns nil for d, which is not empty
+	if e := m.Remove(string(d)); e == nil {
+		t.Error(tserr.NilFailed("Remove of " + string(d)))
+	}
+	// The test fails if Rename returns nil for moving d into itself
+	if e := m.Rename(string(d), filepath.Join(string(d), "b")); e == nil {
+		t.Error(tserr.NilFailed("Rename of " + string(d)))
+	}
+	// The test fails if CreateTemp returns nil for a pattern with a path separator
+	if _, e := m.CreateTemp(string(d), "a"+string(filepath.Separator)+"*"); e == nil {
+		t.Error(tserr.NilFailed("CreateTemp"))
+	}
+	// The test fails if MkdirAll returns nil for a path in file a
+	if e := m.MkdirAll(filepath.Join(a, "b"), 0755); e == nil {
+		t.Error(tserr.NilFailed("MkdirAll of " + filepath.Join(a, "b")))
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio
+
+// Import standard library packages and tserr
+import (
+	"context"       // context
+	"io/fs"         // fs
+	"path/filepath" // filepath
+
+	"github.com/thorstenrie/tserr" // tserr
+)
+
+// MoveOptions holds the options for moving files and directories with MoveFileWith and MoveDirWith.
+// The zero value does not replace an existing destination.
+type MoveOptions struct {
+	Overwrite bool // Overwrite replaces an existing destination
+}
+
+// MoveFile moves regular file src to dst. It renames src to dst. If src and dst reside on different
+// devices or mount points, src is copied to dst with its permission bits and modification time and src is
+// removed afterwards. If the copy or the removal fails, dst is removed and src is kept. If dst exists,
+// MoveFile returns an error. It returns an error, if any.
+func MoveFile(src, dst Filename) error {
+	return std.MoveFile(src, dst)
+}
+
+// MoveFile performs the package function MoveFile with the settings of fsys.
+func (fsys *FS) MoveFile(src, dst Filename) error {
+	return fsys.MoveFileWith(src, dst, MoveOptions{})
+}
+
+// MoveFileWith moves regular file src to dst with the options o. If o.Overwrite is true, an existing
+// dst is replaced. The replaced dst is restored, if the move fails. Otherwise, it behaves like MoveFile.
+// It returns an error, if any.
+func MoveFileWith(src, dst Filename, o MoveOptions) error {
+	return std.MoveFileWith(src, dst, o)
+}
+
+// MoveFileWith performs the package function MoveFileWith with the settings of fsys.
+func (fsys *FS) MoveFileWith(src, dst Filename, o MoveOptions) error {
+	return fsys.MoveFileWithCtx(context.Background(), src, dst, o)
+}
+
+// MoveFileCtx moves regular file src to dst as done by MoveFile. It is a shortcut for MoveFileWithCtx with the zero
+// value of MoveOptions. It returns an error, if any, also if ctx is canceled.
+func MoveFileCtx(ctx context.Context, src, dst Filename) error {
+	return std.MoveFileCtx(ctx, src, dst)
+}
+
+// MoveFileCtx performs the package function MoveFileCtx with the settings of fsys.
+func (fsys *FS) MoveFileCtx(ctx context.Context, src, dst Filename) error {
+	return fsys.MoveFileWithCtx(ctx, src, dst, MoveOptions{})
+}
+
+// MoveFileWithCtx moves regular file src to dst with the options o as done by MoveFileWith. If src and dst reside
+// on different devices or mount points, src is copied as done by CopyFileWithCtx. If ctx is canceled, the partial
+// copy is removed, src is kept and a replaced dst is restored. It returns an error, if any, also if ctx is canceled.
+func MoveFileWithCtx(ctx context.Context, src, dst Filename, o MoveOptions) error {
+	return std.MoveFileWithCtx(ctx, src, dst, o)
+}
+
+// MoveFileWithCtx performs the package function MoveFileWithCtx with the settings of fsys.
+func (fsys *FS) MoveFileWithCtx(ctx context.Context, src, dst Filename, o MoveOptions) error {
+	// Resolve src and dst against the root directory of fsys
+	src, dst = rooted(fsys, src), rooted(fsys, dst)
+	// Return an error in case src contains a blocked directory or filename
+	if e := fsys.CheckFile(src); e != nil {
+		return tserr.Check(&tserr.CheckArgs{F: string(src), Err: e})
+	}
+	// Return an error in case dst contains a blocked directory or filename
+	if e := fsys.CheckFile(dst); e != nil {
+		return tserr.Check(&tserr.CheckArgs{F: string(dst), Err: e})
+	}
+	// Move src to dst
+	if e := move(ctx, fsys, src, dst, o); e != nil {
+		// Return an error if move fails
+		return tserr.Op(&tserr.OpArgs{Op: "move " + string(src) + " to", Fn: string(dst), Err: e})
+	}
+	// No error occurred, return nil
+	return nil
+}
+
+// MoveDir moves directory src to dst. It renames src to dst. If src and dst reside on different
+// devices or mount points, the directory tree src is copied to dst with permission bits and modification
+// times and src is removed afterwards. If the copy fails, dst is removed and src is kept. If the removal of src
+// fails, src is restored from dst and dst is removed. If dst exists or if dst resides in src, MoveDir returns
+// an error. It returns an error, if any.
+func MoveDir(src, dst Directory) error {
+	return std.MoveDir(src, dst)
+}
+
+// MoveDir performs the package function MoveDir with the settings of fsys.
+func (fsys *FS) MoveDir(src, dst Directory) error {
+	return fsys.MoveDirWith(src, dst, MoveOptions{})
+}
+
+// MoveDirWith moves directory src to dst with the options o. If o.Overwrite is true, an existing dst is
+// replaced. The replaced dst is restored, if the move fails. Otherwise, it behaves like MoveDir.
+// It returns an error, if any.
+func MoveDirWith(src, dst Directory, o MoveOptions) error {
+	return std.MoveDirWith(src, dst, o)
+}
+
+// MoveDirWith performs the package function MoveDirWith with the settings of fsys.
+func (fsys *FS) MoveDirWith(src, dst Directory, o MoveOptions) error {
+	return fsys.MoveDirWithCtx(context.Background(), src, dst, o)
+}
+
+// MoveDirCtx moves directory src to dst as done by MoveDir. It is a shortcut for MoveDirWithCtx with the zero
+// value of MoveOptions. It returns an error, if any, also if ctx is canceled.
+func MoveDirCtx(ctx context.Context, src, dst Directory) error {
+	return std.MoveDirCtx(ctx, src, dst)
+}
+
+// MoveDirCtx performs the package function MoveDirCtx with the settings of fsys.
+func (fsys *FS) MoveDirCtx(ctx context.Context, src, dst Directory) error {
+	return fsys.MoveDirWithCtx(ctx, src, dst, MoveOptions{})
+}
+
+// MoveDirWithCtx moves directory src to dst with the options o as done by MoveDirWith. If src and dst reside
+// on different devices or mount points, the directory tree src is copied as done by CopyDirWithCtx. If ctx is
+// canceled, the partial copy is removed, src is kept and a replaced dst is restored. It returns an error, if any,
+// also if ctx is canceled.
+func MoveDirWithCtx(ctx context.Context, src, dst Directory, o MoveOptions) error {
+	return std.MoveDirWithCtx(ctx, src, dst, o)
+}
+
+// MoveDirWithCtx performs the package function MoveDirWithCtx with the settings of fsys.
+func (fsys *FS) MoveDirWithCtx(ctx context.Context, src, dst Directory, o MoveOptions) error {
+	// Resolve src and dst against the root directory of fsys
+	src, dst = rooted(fsys, src), rooted(fsys, dst)
+	// Return an error in case src contains a blocked directory or filename
+	if e := fsys.CheckDir(src); e != nil {
+		return tserr.Check(&tserr.CheckArgs{F: string(src), Err: e})
+	}
+	// Return an error in case dst contains a blocked directory or filename
+	if e := fsys.CheckDir(dst); e != nil {
+		return tserr.Check(&tserr.CheckArgs{F: string(dst), Err: e})
+	}
+	// Return an error if dst is src or resides in src
+	in, err := inside(fsys.backend(), src, dst)
+	if err != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "compare paths", Fn: string(dst), Err: err})
+	}
+	if in {
+		return tserr.Forbidden("move " + string(src) + " into itself")
+	}
+	// Move src to dst
+	if e := move(ctx, fsys, src, dst, o); e != nil {
+		// Return an error if move fails
+		return tserr.Op(&tserr.OpArgs{Op: "move " + string(src) + " to", Fn: string(dst), Err: e})
+	}
+	// No error occurred, return nil
+	return nil
+}
+
+// move moves the regular file or directory src to dst with the options o and the settings of fsys. An existing dst is moved to a
+// backup in the directory of dst, which is removed after a successful move or restored on failure. If src is copied,
+// ctx is checked for cancellation as done by copyFile and copyDir. It returns an error, if any, also if ctx is canceled.
+func move[T Fio](ctx context.Context, fsys *FS, src, dst T, o MoveOptions) error {
+	// Return an error if ctx is canceled
+	if e := ctx.Err(); e != nil {
+		return e
+	}
+	// Return an error if src does not exist
+	ok, err := exists(fsys.backend(), src)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errNotExist(string(src))
+	}
+	// Check if dst exists
+	ok, err = exists(fsys.backend(), dst)
+	if err != nil {
+		return err
+	}
+	// tmp holds the temporary directory containing the backup of dst, if any
+	tmp, bak := "", ""
+	if ok {
+		// Return an error if dst exists and may not be replaced
+		if !o.Overwrite {
+			return fs.ErrExist
+		}
+		// Return an error if src and dst are the same file or directory
+		si, e := fsys.backend().Stat(string(src))
+		if e != nil {
+			return e
+		}
+		if di, e := fsys.backend().Stat(string(dst)); (e == nil) && fsys.backend().SameFile(si, di) {
+			return tserr.Forbidden("move " + string(src) + " to itself")
+		}
+		// Create the temporary directory in the directory of dst
+		if tmp, e = fsys.backend().MkdirTemp(filepath.Dir(string(dst)), tmpPrefix+filepath.Base(string(dst))+".*"+tmpSuffix); e != nil {
+			return e
+		}
+		// Move dst to the backup in tmp
+		bak = filepath.Join(tmp, filepath.Base(string(dst)))
+		if e := fsys.backend().Rename(string(dst), bak); e != nil {
+			fsys.backend().Remove(tmp)
+			return e
+		}
+	}
+	// Rename src to dst
+	err = fsys.backend().Rename(string(src), string(dst))
+	// Copy src to dst and remove src, if src and dst reside on different devices
+	if isXDev(err) {
+		err = moveCopy(ctx, fsys, src, dst)
+	}
+	// On error, restore dst from its backup, if any, and return the error
+	if err != nil {
+		if tmp != "" {
+			fsys.backend().Rename(bak, string(dst))
+			fsys.backend().Remove(tmp)
+		}
+		return err
+	}
+	// Remove the backup of dst, if any
+	if tmp != "" {
+		if e := fsys.removeTree(Directory(tmp)); e != nil {
+			return e
+		}
+	}
+	// Sync the directories of src and dst as requested by the durability of fsys
+	return fsys.syncParents(string(src), string(dst))
+}
+
+// moveCopy moves the regular file or directory src to dst, which does not exist, with the settings of fsys by copying src to dst with
+// its permission bits and modification times and removing src afterwards. On failure, it rolls back: a partial
+// dst is removed and a partially removed src is restored from dst. The copy of src is canceled with ctx, but not the
+// restore of src. It returns an error, if any.
+func moveCopy[T Fio](ctx context.Context, fsys *FS, src, dst T) error {
+	// Preserve permission bits and modification times
+	o := CopyOptions{Mode: true, ModTime: true}
+	// Retrieve FileInfo of src
+	si, err := fsys.backend().Stat(string(src))
+	if err != nil {
+		return err
+	}
+	// Move a regular file
+	if !si.IsDir() {
+		// Copy src to dst. On error, remove the partial dst.
+		if e := fsys.copyFile(ctx, Filename(src), Filename(dst), si, o); e != nil {
+			fsys.backend().Remove(string(dst))
+			return e
+		}
+		// Remove src. On error, remove dst to keep src only.
+		if e := fsys.backend().Remove(string(src)); e != nil {
+			fsys.backend().Remove(string(dst))
+			return e
+		}
+		// No error occurred, return nil
+		return nil
+	}
+	// Copy the directory tree src to dst. On error, remove the partial dst.
+	if e := fsys.copyDir(ctx, Directory(src), Directory(dst), o); e != nil {
+		fsys.removeTree(Directory(dst))
+		return e
+	}
+	// Remove src. On error, restore the removed parts of src from dst and remove dst.
+	if e := fsys.removeTree(Directory(src)); e != nil {
+		if fsys.copyDir(context.Background(), Directory(dst), Directory(src), o) == nil {
+			fsys.removeTree(Directory(dst))
+		}
+		return e
+	}
+	// No error occurred, return nil
+	return nil
+}
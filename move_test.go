@@ -0,0 +1,182 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio_test
+
+// Import standard library packages as well as tserr and tsfio
+import (
+	"fmt"           // fmt
+	"os"            // os
+	"path/filepath" // filepath
+	"testing"       // testing
+
+	"github.com/thorstenrie/tserr" // tserr
+	"github.com/thorstenrie/tsfio" // tsfio
+)
+
+// TestMoveFile tests MoveFile to move a temporary file to a Filename, which does not exist. The test fails
+// if MoveFile returns an error, if the source still exists or if the destination does not hold the contents of the source.
+func TestMoveFile(t *testing.T) {
+	// Create temporary files src and dst, dst does not exist
+	src, dst := tmpFile(t), tmpFile(t)
+	rm(t, dst)
+	// Write testcase to src
+	if e := os.WriteFile(string(src), []byte(testcase), 0644); e != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "WriteFile", Fn: string(src), Err: e}))
+	}
+	// Move src to dst
+	if e := tsfio.MoveFile(src, dst); e != nil {
+		// If MoveFile returns an error, the test fails
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: fmt.Sprintf("MoveFile %v to", src), Fn: string(dst), Err: e}))
+	}
+	// If src still exists, the test fails
+	if b, _ := tsfio.ExistsFile(src); b {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: fmt.Sprintf("ExistsFile of %v", src), Actual: "true", Want: "false"}))
+	}
+	// The test fails if dst does not hold the contents of src
+	checkTree(t, tsfio.Directory(filepath.Dir(string(dst))), map[string]string{filepath.Base(string(dst)): testcase})
+	// Remove dst
+	rm(t, dst)
+}
+
+// TestMoveFileExists tests MoveFile to return an error if the destination exists and MoveFileWith to replace
+// the destination if requested. The test fails if MoveFile returns nil, if MoveFileWith returns an error or if the
+// destination does not hold the expected contents.
+func TestMoveFileExists(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Create files a and b in d
+	writeTree(t, d, map[string]string{"a": testcase, "b": ""})
+	src, dst := tsfio.Filename(filepath.Join(string(d), "a")), tsfio.Filename(filepath.Join(string(d), "b"))
+	// If MoveFile returns nil for an existing destination, the test fails
+	if e := tsfio.MoveFile(src, dst); e == nil {
+		t.Error(tserr.NilFailed(fmt.Sprintf("MoveFile %v to %v", src, dst)))
+	}
+	// The test fails if src or dst have been changed
+	checkTree(t, d, map[string]string{"a": testcase, "b": ""})
+	// Move src to dst and replace dst
+	if e := tsfio.MoveFileWith(src, dst, tsfio.MoveOptions{Overwrite: true}); e != nil {
+		// If MoveFileWith returns an error, the test fails
+		t.Error(tserr.Op(&tserr.OpArgs{Op: fmt.Sprintf("MoveFileWith %v to", src), Fn: string(dst), Err: e}))
+	}
+	// The test fails if dst does not hold the contents of src
+	checkTree(t, d, map[string]string{"b": testcase})
+	// The test fails if d contains other entries than dst, e.g., src or a backup of dst
+	testEntries(t, d, 1)
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestMoveDir tests MoveDir to move a temporary directory tree to a directory, which does not exist. The test fails
+// if MoveDir returns an error, if the source still exists or if the moved tree differs from the source.
+func TestMoveDir(t *testing.T) {
+	// Create temporary directories src and dst
+	src, dst := tmpDir(t), tmpDir(t)
+	// Create the test tree in src
+	writeTree(t, src, testTree)
+	// Move src to a directory in dst, which does not exist
+	mv := tsfio.Directory(filepath.Join(string(dst), string(testfile)))
+	if e := tsfio.MoveDir(src, mv); e != nil {
+		// If MoveDir returns an error, the test fails
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: fmt.Sprintf("MoveDir %v to", src), Fn: string(mv), Err: e}))
+	}
+	// If src still exists, the test fails
+	if b, _ := tsfio.ExistsDir(src); b {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: fmt.Sprintf("ExistsDir of %v", src), Actual: "true", Want: "false"}))
+	}
+	// The test fails if the moved tree differs from the source
+	checkTree(t, mv, testTree)
+	// Remove dst
+	rmAll(t, dst)
+}
+
+// TestMoveDirExists tests MoveDir to return an error if the destination exists and MoveDirWith to replace
+// the destination if requested. The test fails if MoveDir returns nil, if MoveDirWith returns an error or if the
+// destination does not hold the expected tree.
+func TestMoveDirExists(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Create the test tree in sub directory a and a file in sub directory b
+	src, dst := tsfio.Directory(filepath.Join(string(d), "a")), tsfio.Directory(filepath.Join(string(d), "b"))
+	writeTree(t, src, testTree)
+	writeTree(t, dst, map[string]string{string(testfile): testcase})
+	// If MoveDir returns nil for an existing destination, the test fails
+	if e := tsfio.MoveDir(src, dst); e == nil {
+		t.Error(tserr.NilFailed(fmt.Sprintf("MoveDir %v to %v", src, dst)))
+	}
+	// Move src to dst and replace dst
+	if e := tsfio.MoveDirWith(src, dst, tsfio.MoveOptions{Overwrite: true}); e != nil {
+		// If MoveDirWith returns an error, the test fails
+		t.Error(tserr.Op(&tserr.OpArgs{Op: fmt.Sprintf("MoveDirWith %v to", src), Fn: string(dst), Err: e}))
+	}
+	// The test fails if dst does not hold the test tree
+	checkTree(t, dst, testTree)
+	// The test fails if d contains other entries than dst, e.g., src or a backup of dst
+	testEntries(t, d, 1)
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestMoveDirItself tests MoveDir to return an error if a directory is moved into itself.
+// If MoveDir returns nil, the test fails.
+func TestMoveDirItself(t *testing.T) {
+	// Create temporary directory src
+	src := tmpDir(t)
+	// Create destination in src
+	dst := tsfio.Directory(filepath.Join(string(src), string(testfile)))
+	// If MoveDirWith returns nil, the test fails
+	if e := tsfio.MoveDirWith(src, dst, tsfio.MoveOptions{Overwrite: true}); e == nil {
+		t.Error(tserr.NilFailed(fmt.Sprintf("MoveDirWith %v to %v", src, dst)))
+	}
+	// Remove src
+	rmAll(t, src)
+}
+
+// TestMoveDirItselfSymlink tests MoveDir to return an error if a directory is moved into itself through a
+// symbolic link to a sub directory of the source. If MoveDirWith returns nil, the test fails. The test is skipped if
+// symbolic links cannot be created.
+func TestMoveDirItselfSymlink(t *testing.T) {
+	// Create temporary directories src and d
+	src, d := tmpDir(t), tmpDir(t)
+	// Create the test tree in src
+	writeTree(t, src, testTree)
+	// Create symbolic link l in d pointing to the sub directory b of src
+	l := filepath.Join(string(d), string(testfile))
+	if e := os.Symlink(filepath.Join(string(src), "b"), l); e != nil {
+		rmAll(t, src)
+		rm(t, d)
+		t.Skip(e)
+	}
+	// Destination in src reached through l
+	dst := tsfio.Directory(filepath.Join(l, string(testfile)))
+	// If MoveDirWith returns nil, the test fails
+	if e := tsfio.MoveDirWith(src, dst, tsfio.MoveOptions{Overwrite: true}); e == nil {
+		t.Error(tserr.NilFailed(fmt.Sprintf("MoveDirWith %v to %v", src, dst)))
+	}
+	// The test fails if the test tree in src has been changed
+	checkTree(t, src, testTree)
+	// Remove src and d
+	rmAll(t, src)
+	rmAll(t, d)
+}
+
+// TestMoveErr tests MoveFile and MoveDir to return an error for empty names and a source which does not exist.
+// If MoveFile or MoveDir returns nil, the test fails.
+func TestMoveErr(t *testing.T) {
+	// Create temporary file fn and temporary directory d, which do not exist
+	fn, d := tmpFile(t), tmpDir(t)
+	rm(t, fn)
+	rm(t, d)
+	// If MoveFile returns nil, the test fails
+	for _, c := range [][2]tsfio.Filename{{"", fn}, {fn, ""}, {fn, fn + fn}} {
+		if e := tsfio.MoveFile(c[0], c[1]); e == nil {
+			t.Error(tserr.NilFailed(fmt.Sprintf("MoveFile %v to %v", c[0], c[1])))
+		}
+	}
+	// If MoveDir returns nil, the test fails
+	for _, c := range [][2]tsfio.Directory{{"", d}, {d, ""}, {d, d + d}} {
+		if e := tsfio.MoveDir(c[0], c[1]); e == nil {
+			t.Error(tserr.NilFailed(fmt.Sprintf("MoveDir %v to %v", c[0], c[1])))
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio
+
+// Import standard library packages
+import (
+	"path/filepath" // filepath
+	"slices"        // slices
+	"sync"          // sync
+)
+
+// A Policy defines the files and directories tsfio functions may access. CheckFile and CheckDir consult the
+// package policy, which can be installed with SetPolicy. The default policy is returned by DefaultPolicy.
+// It blocks the directories returned by InvalDir and the filenames returned by InvalFile.
+type Policy struct {
+	BlockedDirs   []Directory // BlockedDirs holds the blocked directories. A directory and its contents must not be accessed.
+	BlockedFiles  []Filename  // BlockedFiles holds the blocked filenames. A blocked filename must not be accessed.
+	AllowedRoots  []Directory // AllowedRoots optionally holds root directories. If not empty, only their contents may be accessed.
+	AllowSymlinks bool        // AllowSymlinks allows symbolic links in paths, if true
+}
+
+// The package policy pol is protected by the mutex mpol
+var (
+	mpol sync.RWMutex                   // mpol protects pol
+	pol  Policy       = DefaultPolicy() // pol holds the package policy
+)
+
+// DefaultPolicy returns a copy of the default policy. It blocks the directories returned by InvalDir and
+// the filenames returned by InvalFile. It does not define allowed roots and allows symbolic links.
+func DefaultPolicy() Policy {
+	// Retrieve blocked directories and filenames
+	d, f := InvalDir(), InvalFile()
+	// Return the default policy
+	return Policy{
+		BlockedDirs:   slices.Clone(d[:]),
+		BlockedFiles:  slices.Clone(f[:]),
+		AllowSymlinks: true,
+	}
+}
+
+// SetPolicy installs a copy of policy p as package policy consulted by CheckFile, CheckDir and all tsfio functions.
+// It returns the previous package policy. The previous policy can be restored by calling SetPolicy again, e.g.,
+//
+//	defer tsfio.SetPolicy(tsfio.SetPolicy(p))
+//
+// installs p for the scope of the calling function.
+func SetPolicy(p Policy) Policy {
+	// Lock the package policy for writing
+	mpol.Lock()
+	defer mpol.Unlock()
+	// Install a copy of p and return the previous package policy
+	prev := pol
+	pol = p.clone()
+	return prev
+}
+
+// CheckFile performs the checks of the package function CheckFile on file f with policy p instead of the
+// package policy. It returns an error, if any check fails. Otherwise it returns nil.
+func (p Policy) CheckFile(f Filename) error {
+	return checkWrapper(OSBackend{}, p, f, false)
+}
+
+// CheckDir performs the checks of the package function CheckDir on directory d with policy p instead of the
+// package policy. It returns an error, if any check fails. Otherwise it returns nil.
+func (p Policy) CheckDir(d Directory) error {
+	return checkWrapper(OSBackend{}, p, d, true)
+}
+
+// policy returns a copy of the package policy.
+func policy() Policy {
+	// Lock the package policy for reading
+	mpol.RLock()
+	defer mpol.RUnlock()
+	// Return a copy of the package policy
+	return pol.clone()
+}
+
+// clone returns a deep copy of policy p.
+func (p Policy) clone() Policy {
+	return Policy{
+		BlockedDirs:   slices.Clone(p.BlockedDirs),
+		BlockedFiles:  slices.Clone(p.BlockedFiles),
+		AllowedRoots:  slices.Clone(p.AllowedRoots),
+		AllowSymlinks: p.AllowSymlinks,
+	}
+}
+
+// blocked returns the blocked filename of policy p, if the absolute path x equals a blocked filename. It returns the blocked
+// directory, if x equals a blocked directory or resides in it. Otherwise, it returns an empty string.
+func (p Policy) blocked(x string) string {
+	// Normalize the case of x
+	xc := normCase(x)
+	// Iterate i over blocked filenames
+	for _, i := range p.BlockedFiles {
+		// If the blocked filename and x match, then return the blocked filename
+		if normCase(filepath.Clean(string(i))) == xc {
+			return string(i)
+		}
+	}
+	// Iterate i over blocked directories
+	for _, i := range p.BlockedDirs {
+		// If x matches the blocked directory or resides in it, then return the blocked directory
+		if within(normCase(filepath.Clean(string(i))), xc) {
+			return string(i)
+		}
+	}
+	// No match, return an empty string
+	return ""
+}
+
+// allowed returns true, if policy p does not define allowed roots or if the evaluated absolute path x resides
+// in one of the allowed roots. The allowed roots are evaluated for symbolic links with Backend b before the comparison.
+// It returns false, if x does not reside in one of the allowed roots. It returns false and an error, if an
+// allowed root cannot be evaluated.
+func (p Policy) allowed(b Backend, x string) (bool, error) {
+	// Return true, if no allowed roots are defined
+	if len(p.AllowedRoots) == 0 {
+		return true, nil
+	}
+	// Iterate i over allowed roots
+	for _, i := range p.AllowedRoots {
+		// Evaluate the allowed root
+		r, err := resolve(b, string(i))
+		if err != nil {
+			return false, err
+		}
+		// Return true, if x resides in the allowed root
+		if within(normCase(r), normCase(x)) {
+			return true, nil
+		}
+	}
+	// x does not reside in an allowed root, return false
+	return false, nil
+}
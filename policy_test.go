@@ -0,0 +1,139 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio_test
+
+// Import standard library packages as well as tserr and tsfio
+import (
+	"fmt"           // fmt
+	"os"            // os
+	"path/filepath" // filepath
+	"testing"       // testing
+
+	"github.com/thorstenrie/tserr" // tserr
+	"github.com/thorstenrie/tsfio" // tsfio
+)
+
+// TestDefaultPolicy tests DefaultPolicy to return the blocked directories of InvalDir and the blocked filenames
+// of InvalFile. The test fails if the default policy differs.
+func TestDefaultPolicy(t *testing.T) {
+	// Retrieve the default policy, blocked directories and filenames
+	p, d, f := tsfio.DefaultPolicy(), tsfio.InvalDir(), tsfio.InvalFile()
+	// The test fails if the blocked directories differ
+	if fmt.Sprint(p.BlockedDirs) != fmt.Sprint(d[:]) {
+		t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: "BlockedDirs", Actual: fmt.Sprint(p.BlockedDirs), Want: fmt.Sprint(d[:])}))
+	}
+	// The test fails if the blocked filenames differ
+	if fmt.Sprint(p.BlockedFiles) != fmt.Sprint(f[:]) {
+		t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: "BlockedFiles", Actual: fmt.Sprint(p.BlockedFiles), Want: fmt.Sprint(f[:])}))
+	}
+	// The test fails if allowed roots are defined or symbolic links are not allowed
+	if (len(p.AllowedRoots) > 0) || !p.AllowSymlinks {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "DefaultPolicy", Actual: fmt.Sprint(p), Want: "no allowed roots and symbolic links allowed"}))
+	}
+}
+
+// TestPolicyBlocked tests a policy with an additional blocked directory and filename. The test fails if CheckDir or CheckFile of
+// the policy return nil for the blocked directory or filename, or if the default policy returns an error for them.
+func TestPolicyBlocked(t *testing.T) {
+	// Create temporary directory d and temporary file fn
+	d, fn := tmpDir(t), tmpFile(t)
+	// Create a policy blocking d and fn
+	p := tsfio.DefaultPolicy()
+	p.BlockedDirs = append(p.BlockedDirs, d)
+	p.BlockedFiles = append(p.BlockedFiles, fn)
+	// The test fails if CheckDir or CheckFile of the policy return nil
+	if e := p.CheckDir(d); e == nil {
+		t.Error(tserr.NilFailed(fmt.Sprintf("CheckDir of %v", d)))
+	}
+	if f := tsfio.Filename(filepath.Join(string(d), string(testfile))); p.CheckFile(f) == nil {
+		t.Error(tserr.NilFailed(fmt.Sprintf("CheckFile of %v", f)))
+	}
+	if e := p.CheckFile(fn); e == nil {
+		t.Error(tserr.NilFailed(fmt.Sprintf("CheckFile of %v", fn)))
+	}
+	// The test fails if the default policy returns an error
+	if e := tsfio.DefaultPolicy().CheckDir(d); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "CheckDir", Fn: string(d), Err: e}))
+	}
+	// Remove d and fn
+	rm(t, d)
+	rm(t, fn)
+}
+
+// TestPolicyAllowedRoots tests a policy with an allowed root. The test fails if CheckFile returns an error for a file in the
+// allowed root, or if it returns nil for a file outside of the allowed root or reached by a symbolic link leaving the allowed root.
+func TestPolicyAllowedRoots(t *testing.T) {
+	// Create temporary directories d and o
+	d, o := tmpDir(t), tmpDir(t)
+	// Create a policy with allowed root d
+	p := tsfio.DefaultPolicy()
+	p.AllowedRoots = []tsfio.Directory{d}
+	// The test fails if CheckFile returns an error for a file in d
+	if f := tsfio.Filename(filepath.Join(string(d), string(testfile))); p.CheckFile(f) != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "CheckFile", Fn: string(f), Err: p.CheckFile(f)}))
+	}
+	// The test fails if CheckFile returns nil for a file in o
+	if f := tsfio.Filename(filepath.Join(string(o), string(testfile))); p.CheckFile(f) == nil {
+		t.Error(tserr.NilFailed(fmt.Sprintf("CheckFile of %v", f)))
+	}
+	// Create symbolic link l in d pointing to o
+	l := filepath.Join(string(d), string(testfile))
+	if e := os.Symlink(string(o), l); e == nil {
+		// The test fails if CheckFile returns nil for a file reached by l
+		if f := tsfio.Filename(filepath.Join(l, string(testfile))); p.CheckFile(f) == nil {
+			t.Error(tserr.NilFailed(fmt.Sprintf("CheckFile of %v", f)))
+		}
+	}
+	// Remove d and o
+	rmAll(t, d)
+	rm(t, o)
+}
+
+// TestPolicySymlinks tests a policy not allowing symbolic links. The test fails if CheckDir returns nil for a symbolic link.
+// The test is skipped if symbolic links cannot be created.
+func TestPolicySymlinks(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Create symbolic link l in d pointing to d
+	l := filepath.Join(string(d), string(testfile))
+	if e := os.Symlink(string(d), l); e != nil {
+		rm(t, d)
+		t.Skip(e)
+	}
+	// Create a policy not allowing symbolic links
+	p := tsfio.DefaultPolicy()
+	p.AllowSymlinks = false
+	// The test fails if CheckDir returns nil for l
+	if e := p.CheckDir(tsfio.Directory(l)); e == nil {
+		t.Error(tserr.NilFailed(fmt.Sprintf("CheckDir of %v", l)))
+	}
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestSetPolicy tests SetPolicy to install a policy blocking a temporary directory and to restore the previous policy.
+// The test fails if WriteStr returns nil for a file in the blocked directory or an error after the previous policy is restored.
+func TestSetPolicy(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Create Filename fn in d
+	fn := tsfio.Filename(filepath.Join(string(d), string(testfile)))
+	// Create a policy blocking d
+	p := tsfio.DefaultPolicy()
+	p.BlockedDirs = append(p.BlockedDirs, d)
+	// Install the policy
+	prev := tsfio.SetPolicy(p)
+	// The test fails if WriteStr returns nil
+	if e := tsfio.WriteStr(fn, testcase); e == nil {
+		t.Error(tserr.NilFailed(fmt.Sprintf("WriteStr to %v", fn)))
+	}
+	// Restore the previous policy
+	tsfio.SetPolicy(prev)
+	// The test fails if WriteStr returns an error
+	if e := tsfio.WriteStr(fn, testcase); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "WriteStr", Fn: string(fn), Err: e}))
+	}
+	// Remove d
+	rmAll(t, d)
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio
+
+// Import standard library packages and tserr
+import (
+	"os" // os
+
+	"github.com/thorstenrie/tserr" // tserr
+)
+
+// ReadAt reads length bytes of file fn starting at byte offset and returns them. The range must lie within
+// the contents of fn, so offset and length must not be negative and offset plus length must not exceed
+// the size of fn. Otherwise, it returns an error of tserr.Higher or tserr.Lower. It returns an error, if fn
+// does not exist, is not a regular file or if any other error occurs.
+func ReadAt(fn Filename, offset, length int64) ([]byte, error) {
+	return std.ReadAt(fn, offset, length)
+}
+
+// ReadAt performs the package function ReadAt with the settings of fsys.
+func (fsys *FS) ReadAt(fn Filename, offset, length int64) ([]byte, error) {
+	// Return an error, if length is negative
+	if length < 0 {
+		return nil, tserr.Higher(&tserr.HigherArgs{Var: "length", Actual: length, LowerBound: -1})
+	}
+	// Open fn read-only and retrieve its size
+	f, size, err := fsys.openRange(fn, os.O_RDONLY, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	// Return an error, if the range exceeds the end of fn
+	if length > size-offset {
+		return nil, tserr.Lower(&tserr.LowerArgs{Var: "length", Actual: length, HigherBound: size - offset + 1})
+	}
+	// Read the range
+	return readRange(f, offset, length)
+}
+
+// ReadTail reads the last n bytes of file fn and returns them. If fn holds fewer than n bytes, it returns the
+// contents of fn. It returns an error of tserr.Higher, if n is negative. It returns an error, if fn does not exist,
+// is not a regular file or if any other error occurs.
+func ReadTail(fn Filename, n int64) ([]byte, error) {
+	return std.ReadTail(fn, n)
+}
+
+// ReadTail performs the package function ReadTail with the settings of fsys.
+func (fsys *FS) ReadTail(fn Filename, n int64) ([]byte, error) {
+	// Return an error, if n is negative
+	if n < 0 {
+		return nil, tserr.Higher(&tserr.HigherArgs{Var: "n", Actual: n, LowerBound: -1})
+	}
+	// Open fn read-only and retrieve its size
+	f, size, err := fsys.openRange(fn, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	// Read at most the last n bytes
+	n = min(n, size)
+	return readRange(f, size-n, n)
+}
+
+// WriteAt writes data to file fn starting at byte offset and returns the number of bytes written. The contents
+// of fn are changed in place. Offset must not be negative and must not exceed the size of fn. Otherwise, it
+// returns an error of tserr.Higher or tserr.Lower. If data reaches beyond the end of fn, fn is extended.
+// Therefore, an offset equal to the size of fn appends data. WriteAt does not create fn. It returns an error,
+// if fn does not exist, is not a regular file or if any other error occurs.
+func WriteAt(fn Filename, offset int64, data []byte) (int, error) {
+	return std.WriteAt(fn, offset, data)
+}
+
+// WriteAt performs the package function WriteAt with the settings of fsys.
+func (fsys *FS) WriteAt(fn Filename, offset int64, data []byte) (int, error) {
+	// Open fn write-only and retrieve its size
+	f, _, err := fsys.openRange(fn, os.O_WRONLY, offset)
+	if err != nil {
+		return 0, err
+	}
+	// Write data at offset
+	n, err := f.WriteAt(data, offset)
+	if err != nil {
+		f.Close()
+		return n, tserr.Op(&tserr.OpArgs{Op: "WriteAt", Fn: f.Name(), Err: err})
+	}
+	// Sync and close fn as requested by the durability of fsys
+	if e := fsys.closeDurable(f); e != nil {
+		return n, e
+	}
+	// No error occurred, return number of bytes written and nil
+	return n, nil
+}
+
+// openRange opens the existing regular file fn with flag and returns it with its size. It returns an error,
+// if offset is negative or exceeds the size of fn, or if any other error occurs.
+func (fsys *FS) openRange(fn Filename, flag int, offset int64) (File, int64, error) {
+	// Return an error, if offset is negative
+	if offset < 0 {
+		return nil, 0, tserr.Higher(&tserr.HigherArgs{Var: "offset", Actual: offset, LowerBound: -1})
+	}
+	// Resolve fn against the root directory of fsys
+	fn = rooted(fsys, fn)
+	// Return an error in case fn contains a blocked directory or filename
+	if e := fsys.CheckFile(fn); e != nil {
+		return nil, 0, tserr.Check(&tserr.CheckArgs{F: string(fn), Err: e})
+	}
+	// Open the existing file fn
+	f, err := fsys.backend().OpenFile(string(fn), flag, 0)
+	if err != nil {
+		return nil, 0, tserr.Op(&tserr.OpArgs{Op: "OpenFile", Fn: string(fn), Err: err})
+	}
+	// Retrieve the size of fn from the open file, so it matches the contents read or written
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, tserr.Op(&tserr.OpArgs{Op: "FileInfo (Stat) of", Fn: string(fn), Err: err})
+	}
+	// Return an error, if fn is not a regular file
+	if !fi.Mode().IsRegular() {
+		f.Close()
+		return nil, 0, errNotRegular(string(fn), "regular file")
+	}
+	// Return an error, if offset exceeds the size of fn
+	if offset > fi.Size() {
+		f.Close()
+		return nil, 0, tserr.Lower(&tserr.LowerArgs{Var: "offset", Actual: offset, HigherBound: fi.Size() + 1})
+	}
+	// Return the open file and its size
+	return f, fi.Size(), nil
+}
+
+// readRange reads length bytes of the open file f starting at offset. It returns an error, if fewer bytes
+// are read, e.g., if the file was truncated while it is read.
+func readRange(f File, offset, length int64) ([]byte, error) {
+	b := make([]byte, length)
+	// ReadAt may return io.EOF together with all bytes at the end of the file
+	if n, err := f.ReadAt(b, offset); int64(n) < length {
+		return nil, tserr.Op(&tserr.OpArgs{Op: "ReadAt", Fn: f.Name(), Err: err})
+	}
+	return b, nil
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio_test
+
+// Import standard library packages as well as tserr and tsfio
+import (
+	"fmt"           // fmt
+	"path/filepath" // filepath
+	"testing"       // testing
+
+	"github.com/thorstenrie/tserr" // tserr
+	"github.com/thorstenrie/tsfio" // tsfio
+)
+
+// TestReadAt tests ReadAt to read ranges within a file and ReadTail to read the end of a file. The test fails
+// if any of them returns an error or other contents.
+func TestReadAt(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write file a to d
+	writeTree(t, d, map[string]string{"a": testcase})
+	fn := tsfio.Filename(filepath.Join(string(d), "a"))
+	n := int64(len(testcase))
+	// The test fails if ReadAt returns an error or other contents
+	for _, r := range [][2]int64{{0, n}, {1, 3}, {n - 2, 2}, {n, 0}} {
+		if b, e := tsfio.ReadAt(fn, r[0], r[1]); (e != nil) || (string(b) != testcase[r[0]:r[0]+r[1]]) {
+			t.Error(tserr.Return(&tserr.ReturnArgs{Op: fmt.Sprintf("ReadAt %d %d", r[0], r[1]), Actual: fmt.Sprint(string(b), e), Want: fmt.Sprint(testcase[r[0]:r[0]+r[1]], nil)}))
+		}
+	}
+	// The test fails if ReadTail returns an error or other contents
+	for _, k := range []int64{0, 3, n, n + 1} {
+		w := testcase[n-min(k, n):]
+		if b, e := tsfio.ReadTail(fn, k); (e != nil) || (string(b) != w) {
+			t.Error(tserr.Return(&tserr.ReturnArgs{Op: fmt.Sprintf("ReadTail %d", k), Actual: fmt.Sprint(string(b), e), Want: fmt.Sprint(w, nil)}))
+		}
+	}
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestWriteAt tests WriteAt to change a file in place, to append at its end and to extend it. The test fails if
+// WriteAt returns an error, another number of bytes written or if the contents differ.
+func TestWriteAt(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write file a to d
+	writeTree(t, d, map[string]string{"a": "0123456789"})
+	fn := tsfio.Filename(filepath.Join(string(d), "a"))
+	// Change a in place, append to a and extend a
+	for _, w := range []struct {
+		off  int64
+		data string
+	}{{2, "ab"}, {10, "c"}, {9, "de"}} {
+		if n, e := tsfio.WriteAt(fn, w.off, []byte(w.data)); (e != nil) || (n != len(w.data)) {
+			t.Error(tserr.Return(&tserr.ReturnArgs{Op: fmt.Sprintf("WriteAt %d", w.off), Actual: fmt.Sprint(n, e), Want: fmt.Sprint(len(w.data), nil)}))
+		}
+	}
+	// The test fails if the contents differ
+	checkTree(t, d, map[string]string{"a": "01ab45678de"})
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestRangeMem tests ReadAt, ReadTail and WriteAt of an FS with a MemBackend. The test fails if any of them
+// returns an error or if the contents differ.
+func TestRangeMem(t *testing.T) {
+	// Create FS with MemBackend and Directory d
+	fsys, _, d := memFS(t)
+	// Write file a to d
+	memTree(t, fsys, d, map[string]string{"a": "0123456789"})
+	fn := tsfio.Filename(filepath.Join(string(d), "a"))
+	// Change a in place and extend a
+	if _, e := fsys.WriteAt(fn, 8, []byte("abc")); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "WriteAt", Fn: string(fn), Err: e}))
+	}
+	// The test fails if ReadAt or ReadTail return an error or other contents
+	if b, e := fsys.ReadAt(fn, 7, 3); (e != nil) || (string(b) != "7ab") {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "ReadAt", Actual: fmt.Sprint(string(b), e), Want: fmt.Sprint("7ab", nil)}))
+	}
+	if b, e := fsys.ReadTail(fn, 2); (e != nil) || (string(b) != "bc") {
+		t.Error(tserr.Return(&tserr.ReturnArgs{Op: "ReadTail", Actual: fmt.Sprint(string(b), e), Want: fmt.Sprint("bc", nil)}))
+	}
+	// The test fails if the contents differ
+	memCheckTree(t, fsys, d, map[string]string{"a": "01234567abc"})
+	// The test fails if d exists in the file system
+	memNotOnDisk(t, d)
+}
+
+// TestRangeErr tests ReadAt, ReadTail and WriteAt to return an error for ranges outside of a file, a file, which
+// does not exist, and a directory. The test fails if any of them returns nil or if the file changed or was created.
+func TestRangeErr(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write file a to d
+	writeTree(t, d, map[string]string{"a": testcase})
+	fn := func(n string) tsfio.Filename { return tsfio.Filename(filepath.Join(string(d), n)) }
+	n := int64(len(testcase))
+	// The test fails if any of them returns nil
+	for o, f := range map[string]func() error{
+		"ReadAt with empty name":      func() error { _, e := tsfio.ReadAt("", 0, 0); return e },
+		"ReadAt with negative offset": func() error { _, e := tsfio.ReadAt(fn("a"), -1, 1); return e },
+		"ReadAt with negative length": func() error { _, e := tsfio.ReadAt(fn("a"), 0, -1); return e },
+		"ReadAt beyond the end":       func() error { _, e := tsfio.ReadAt(fn("a"), 1, n); return e },
+		"ReadAt after the end":        func() error { _, e := tsfio.ReadAt(fn("a"), n+1, 0); return e },
+		"ReadAt of missing file":      func() error { _, e := tsfio.ReadAt(fn("b"), 0, 0); return e },
+		"ReadAt of directory":         func() error { _, e := tsfio.ReadAt(tsfio.Filename(d), 0, 0); return e },
+		"ReadTail with empty name":    func() error { _, e := tsfio.ReadTail("", 0); return e },
+		"ReadTail with negative n":    func() error { _, e := tsfio.ReadTail(fn("a"), -1); return e },
+		"ReadTail of missing file":    func() error { _, e := tsfio.ReadTail(fn("b"), 1); return e },
+		"ReadTail of directory":       func() error { _, e := tsfio.ReadTail(tsfio.Filename(d), 1); return e },
+		"WriteAt with empty name":     func() error { _, e := tsfio.WriteAt("", 0, []byte(testcase)); return e },
+		"WriteAt with negative offset": func() error {
+			_, e := tsfio.WriteAt(fn("a"), -1, []byte(testcase))
+			return e
+		},
+		"WriteAt after the end":   func() error { _, e := tsfio.WriteAt(fn("a"), n+1, []byte(testcase)); return e },
+		"WriteAt to missing file": func() error { _, e := tsfio.WriteAt(fn("b"), 0, []byte(testcase)); return e },
+		"WriteAt to directory":    func() error { _, e := tsfio.WriteAt(tsfio.Filename(d), 0, []byte(testcase)); return e },
+	} {
+		if e := f(); e == nil {
+			t.Error(tserr.NilFailed(o))
+		}
+	}
+	// The test fails if a changed or b was created
+	checkTree(t, d, map[string]string{"a": testcase})
+	testEntries(t, d, 1)
+	// Remove d
+	rmAll(t, d)
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio
+
+// Import standard library packages
+import (
+	"cmp"           // cmp
+	"os"            // os
+	"path/filepath" // filepath
+	"regexp"        // regexp
+	"slices"        // slices
+	"strings"       // strings
+)
+
+// A Scrubber replaces volatile content of test data, which changes with each test run, e.g., timestamps, UUIDs,
+// temporary paths and durations, by a fixed mask. The Scrubbers of a Golden are applied to the testcase data before
+// comparing it with a golden file and before writing it to a golden file. A custom Scrubber is a function, e.g.,
+//
+//	g := &tsfio.Golden{Scrubbers: []tsfio.Scrubber{tsfio.ScrubTimestamps(), strings.ToLower}}
+type Scrubber func(string) string
+
+// Masks of volatile content replaced by the Scrubbers of tsfio
+const (
+	MaskTimestamp string = "[TIMESTAMP]" // MaskTimestamp replaces timestamps
+	MaskUUID      string = "[UUID]"      // MaskUUID replaces UUIDs
+	MaskTempDir   string = "[TEMPDIR]"   // MaskTempDir replaces the temporary directory
+	MaskDuration  string = "[DURATION]"  // MaskDuration replaces durations
+)
+
+// Regular expressions matching volatile content
+var (
+	// reTimestamp matches RFC 3339 and ISO 8601 timestamps as well as timestamps formatted by time.Time.String,
+	// including the monotonic clock reading, e.g., m=+0.000382979
+	reTimestamp = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?( ?(Z|[+-]\d{2}:?\d{2})( [A-Z]{2,5})?)?( m=[+-]\d+\.\d+)?`)
+	// reUUID matches UUIDs in the canonical textual representation
+	reUUID = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	// reDuration matches durations formatted by time.Duration.String, e.g., 1.5s or 1h2m3s. The first submatch holds
+	// the preceding character, which is neither a word character nor a dot, so durations in dotted numbers, e.g.,
+	// 1.2.3s, do not match.
+	reDuration = regexp.MustCompile(`(^|[^\w.])(\d+(\.\d+)?(ns|us|µs|ms|s|m|h))+\b`)
+)
+
+// ScrubRegexp returns a Scrubber replacing the matches of re by repl. Inside repl, $ signs are interpreted as in
+// Regexp.Expand, e.g., $1 for the text of the first submatch.
+func ScrubRegexp(re *regexp.Regexp, repl string) Scrubber {
+	return func(s string) string {
+		return re.ReplaceAllString(s, repl)
+	}
+}
+
+// ScrubTimestamps returns a Scrubber replacing timestamps by [TIMESTAMP]. It matches RFC 3339 and ISO 8601
+// timestamps with date and time, e.g., 2023-01-02T15:04:05Z, as well as timestamps formatted by time.Time.String,
+// including the monotonic clock reading of time.Now, e.g., m=+0.000382979.
+func ScrubTimestamps() Scrubber {
+	return ScrubRegexp(reTimestamp, MaskTimestamp)
+}
+
+// ScrubUUIDs returns a Scrubber replacing UUIDs in the canonical textual representation by [UUID], e.g.,
+// 123e4567-e89b-12d3-a456-426614174000.
+func ScrubUUIDs() Scrubber {
+	return ScrubRegexp(reUUID, MaskUUID)
+}
+
+// ScrubDurations returns a Scrubber replacing durations formatted by time.Duration.String by [DURATION],
+// e.g., 1.5s or 1h2m3s. Durations in dotted numbers, e.g., 1.2.3s, are not replaced.
+func ScrubDurations() Scrubber {
+	return ScrubRegexp(reDuration, "${1}"+MaskDuration)
+}
+
+// ScrubTempDir returns a Scrubber replacing the temporary directory returned by os.TempDir by [TEMPDIR], e.g.,
+// for paths of temporary files. It is a shortcut for ScrubDir with the temporary directory and [TEMPDIR].
+func ScrubTempDir() Scrubber {
+	return ScrubDir(Directory(os.TempDir()), MaskTempDir)
+}
+
+// ScrubDir returns a Scrubber replacing directory d by repl, e.g., for a directory returned by t.TempDir. Besides
+// d, it replaces d with slashes as separator and d with evaluated symbolic links, since the test data can hold
+// either of them. Only complete path components are replaced, e.g., /tmp in /tmp/a, but not in /tmpdata or
+// /var/tmpfiles. If d is empty, the Scrubber does not change the test data.
+func ScrubDir(d Directory, repl string) Scrubber {
+	// Return a Scrubber, which does not change the test data, if d is empty
+	if d == "" {
+		return func(s string) string { return s }
+	}
+	// Retrieve the representations of d
+	ds := []string{filepath.Clean(string(d))}
+	if e, err := filepath.EvalSymlinks(ds[0]); err == nil {
+		ds = append(ds, e)
+	}
+	for _, i := range ds {
+		ds = append(ds, filepath.ToSlash(i))
+	}
+	// Replace longer representations first, since a representation can contain another one, e.g., /private/var
+	// and /var on macOS
+	slices.SortFunc(ds, func(a, b string) int {
+		if c := cmp.Compare(len(b), len(a)); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	ds = slices.Compact(ds)
+	return func(s string) string {
+		for _, i := range ds {
+			s = replacePath(s, i, repl)
+		}
+		return s
+	}
+}
+
+// replacePath returns s with the occurrences of path p replaced by repl. An occurrence is replaced only, if it is
+// neither preceded nor followed by a path character, e.g., a letter or a digit. Trailing dots are skipped, so
+// p at the end of a sentence is replaced, but not p with a file extension.
+func replacePath(s, p, repl string) string {
+	var sb strings.Builder
+	for {
+		// Retrieve the next occurrence of p
+		i := strings.Index(s, p)
+		if i < 0 {
+			break
+		}
+		// Skip trailing dots of the occurrence
+		j := i + len(p)
+		k := j
+		for (k < len(s)) && (s[k] == '.') {
+			k++
+		}
+		// Replace the occurrence on path component boundaries. Otherwise, continue after its first byte.
+		if ((i == 0) || !pathChar(s[i-1])) && ((k == len(s)) || !pathChar(s[k])) {
+			sb.WriteString(s[:i])
+			sb.WriteString(repl)
+			s = s[j:]
+		} else {
+			sb.WriteString(s[:i+1])
+			s = s[i+1:]
+		}
+	}
+	sb.WriteString(s)
+	return sb.String()
+}
+
+// pathChar returns true, if byte c can be part of a file or directory name next to a path, e.g., a letter, a digit,
+// a dot, an underscore or a hyphen, or a byte of a multi-byte UTF-8 character. Path separators are no path characters.
+func pathChar(c byte) bool {
+	return ((c >= 'a') && (c <= 'z')) || ((c >= 'A') && (c <= 'Z')) || ((c >= '0') && (c <= '9')) ||
+		strings.IndexByte("._-~+@", c) >= 0 || (c >= 0x80)
+}
+
+// scrub returns s scrubbed by the Scrubbers sc in the given order.
+func scrub(s string, sc []Scrubber) string {
+	for _, f := range sc {
+		s = f(s)
+	}
+	return s
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio_test
+
+// Import standard library packages as well as tserr and tsfio
+import (
+	"os"            // os
+	"path/filepath" // filepath
+	"regexp"        // regexp
+	"strings"       // strings
+	"testing"       // testing
+	"time"          // time
+
+	"github.com/thorstenrie/tserr" // tserr
+	"github.com/thorstenrie/tsfio" // tsfio
+)
+
+// TestScrubbers tests the Scrubbers of tsfio to mask volatile content. The test fails if the scrubbed string differs.
+func TestScrubbers(t *testing.T) {
+	ts := time.Date(2023, 1, 2, 15, 4, 5, 123456789, time.UTC)
+	tmp := filepath.Join(os.TempDir(), "a")
+	for _, c := range []struct {
+		name string
+		sc   tsfio.Scrubber
+		in   string
+		want string
+	}{
+		{"ScrubTimestamps RFC 3339", tsfio.ScrubTimestamps(), "at " + ts.Format(time.RFC3339) + ".", "at [TIMESTAMP]."},
+		{"ScrubTimestamps RFC 3339 nano", tsfio.ScrubTimestamps(), ts.In(time.FixedZone("", 3600)).Format(time.RFC3339Nano), "[TIMESTAMP]"},
+		{"ScrubTimestamps String", tsfio.ScrubTimestamps(), "at " + ts.String() + ".", "at [TIMESTAMP]."},
+		{"ScrubTimestamps date", tsfio.ScrubTimestamps(), "2023-01-02", "2023-01-02"},
+		{"ScrubTimestamps Now", tsfio.ScrubTimestamps(), "at " + time.Now().String() + ".", "at [TIMESTAMP]."},
+		{"ScrubUUIDs", tsfio.ScrubUUIDs(), "id 123e4567-e89b-12d3-A456-426614174000 x", "id [UUID] x"},
+		{"ScrubDurations", tsfio.ScrubDurations(), "took 1.5s, 1h2m3.5s and 250µs in 3 runs", "took [DURATION], [DURATION] and [DURATION] in 3 runs"},
+		{"ScrubDurations start", tsfio.ScrubDurations(), "2s,3ms", "[DURATION],[DURATION]"},
+		{"ScrubDurations dotted", tsfio.ScrubDurations(), "version 1.2.3s", "version 1.2.3s"},
+		{"ScrubRegexp", tsfio.ScrubRegexp(regexp.MustCompile(`pid=(\d+)`), "pid=N"), "pid=1234 pid=5", "pid=N pid=N"},
+		{"ScrubTempDir", tsfio.ScrubTempDir(), "file " + tmp, "file " + filepath.Join("[TEMPDIR]", "a")},
+		{"ScrubTempDir slashes", tsfio.ScrubTempDir(), filepath.ToSlash(tmp), "[TEMPDIR]/a"},
+		{"ScrubTempDir end", tsfio.ScrubTempDir(), "in " + os.TempDir() + ".", "in [TEMPDIR]."},
+		{"ScrubDir boundaries", tsfio.ScrubDir("/tmp", "[TMP]"), "/tmp /tmp/a /tmpdata /var/tmpfiles /tmp.txt '/tmp'", "[TMP] [TMP]/a /tmpdata /var/tmpfiles /tmp.txt '[TMP]'"},
+		{"ScrubDir empty", tsfio.ScrubDir("", "x"), testcase, testcase},
+		{"custom", strings.ToUpper, "a", "A"},
+	} {
+		// The test fails if the scrubbed string differs
+		if s := c.sc(c.in); s != c.want {
+			t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: c.name, Actual: s, Want: c.want}))
+		}
+	}
+}
+
+// TestGoldenScrubbers tests a Golden with Scrubbers to write the scrubbed testcase data to the golden file and to
+// evaluate testcases with other volatile content as equal. The test fails if CreateGoldenFile or EvalGoldenFile
+// returns an error, if the golden file holds volatile content or if EvalGoldenFile returns nil for a testcase,
+// which is not equal.
+func TestGoldenScrubbers(t *testing.T) {
+	// Create temporary directory d and the Golden g with Scrubbers
+	d := tmpDir(t)
+	g := &tsfio.Golden{Dir: d, Scrubbers: []tsfio.Scrubber{tsfio.ScrubTimestamps(), tsfio.ScrubDir(d, "[DIR]")}}
+	data := func(ts time.Time, s string) string {
+		return "run " + ts.Format(time.RFC3339) + "\r\nin " + filepath.Join(string(d), "out") + "\r\n" + s + "\r\n"
+	}
+	tc := &tsfio.Testcase{Name: "a", Data: data(time.Now(), testcase)}
+	// The test fails if CreateGoldenFile returns an error
+	if e := g.CreateGoldenFile(tc); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "CreateGoldenFile", Fn: tc.Name, Err: e}))
+	}
+	// The test fails if the golden file holds volatile content
+	fn, _ := g.GoldenFilePath(tc.Name)
+	if b, e := tsfio.ReadFile(fn); (e != nil) || strings.Contains(string(b), string(d)) || !strings.Contains(string(b), "[TIMESTAMP]") {
+		t.Error(tserr.EqualStr(&tserr.EqualStrArgs{Var: "golden file " + string(fn), Actual: string(b), Want: "scrubbed data"}))
+	}
+	// The test fails if EvalGoldenFile returns an error for a testcase with another timestamp
+	tc.Data = data(time.Now().Add(time.Hour), testcase)
+	if e := g.EvalGoldenFile(tc); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "EvalGoldenFile", Fn: tc.Name, Err: e}))
+	}
+	// The test fails if EvalGoldenFile returns nil for a testcase, which is not equal
+	tc.Data = data(time.Now(), testcase_unix)
+	if e := g.EvalGoldenFile(tc); e == nil {
+		t.Error(tserr.NilFailed("EvalGoldenFile"))
+	}
+	// Remove d
+	rmAll(t, d)
+}
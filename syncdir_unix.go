@@ -0,0 +1,28 @@
+//go:build !windows
+
+package tsfio
+
+// Import standard library package os
+import "os" // os
+
+// syncFlag holds the flag to open a file for syncing it. Syncing a file does not require write access,
+// so read-only files can be synced.
+const syncFlag int = os.O_RDONLY
+
+// syncDir commits the directory entries of d to stable storage. It returns an error, if any.
+func syncDir(d Directory) error {
+	// Open directory d read-only
+	f, err := os.Open(string(d))
+	// Return an error if Open fails
+	if err != nil {
+		return err
+	}
+	// Sync directory d
+	if e := f.Sync(); e != nil {
+		// On error, close d and return the error
+		f.Close()
+		return e
+	}
+	// Close d and return the error of Close, if any
+	return f.Close()
+}
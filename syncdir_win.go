@@ -0,0 +1,15 @@
+//go:build windows
+
+package tsfio
+
+// Import standard library package os
+import "os" // os
+
+// syncFlag holds the flag to open a file for syncing it. Syncing a file on Windows requires write access.
+const syncFlag int = os.O_WRONLY
+
+// syncDir is a no-op on Windows. Directories cannot be synced on Windows and
+// directory entries are committed by the file system. It always returns nil.
+func syncDir(d Directory) error {
+	return nil
+}
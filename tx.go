@@ -0,0 +1,523 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio
+
+// Import standard library packages and tserr
+import (
+	"encoding/json" // json
+	"errors"        // errors
+	"io"            // io
+	"io/fs"         // fs
+	"os"            // os
+	"path/filepath" // filepath
+	"slices"        // slices
+
+	"github.com/thorstenrie/tserr" // tserr
+)
+
+// A Transaction stages changes to several files and commits them together, so either all or none of the changes
+// are applied. WriteStr, WriteSingleStr, AppendFile and RemoveFile of a Transaction do not change the files.
+// Instead, the new contents of each file are staged in a temporary file in the directory of the file. Commit renames
+// the temporary files to the files and removes the files staged for removal. The staged changes are recorded in a
+// journal file. If a Commit is interrupted, e.g., by a crash, the next OpenTransaction with the same journal rolls
+// the Commit forward. If the Transaction is interrupted before Commit, the next OpenTransaction rolls it back by
+// removing the temporary files. Concurrent readers may see some of the committed files changed before others, but
+// never a partially written file. A Transaction must not be used concurrently and a journal must only be used by
+// one Transaction at a time.
+type Transaction struct {
+	fsys    *FS       // fsys holds the FS of the files
+	journal Filename  // journal holds the absolute path of the journal file
+	entries []txEntry // entries holds the staged changes in the order of staging
+	closed  bool      // closed is true after Commit or Rollback
+}
+
+// A txEntry holds the staged change of a file in the journal
+type txEntry struct {
+	Target string `json:"target"`         // Target holds the absolute path of the file to be changed
+	Temp   string `json:"temp,omitempty"` // Temp holds the temporary file with the new contents. If empty, Target is removed.
+}
+
+// A txJournal holds the contents of a journal file
+type txJournal struct {
+	Committed bool      `json:"committed"` // Committed is true, if the staged changes are to be applied
+	Entries   []txEntry `json:"entries"`   // Entries holds the staged changes
+}
+
+// OpenTransaction opens a Transaction with journal file journal. If journal holds an interrupted Commit, the
+// Commit is rolled forward. If journal holds staged changes of an interrupted Transaction without Commit, they
+// are rolled back. Afterwards, the journal is removed and the returned Transaction has no staged changes. The
+// journal is written only when changes are staged. It returns an error, if any.
+func OpenTransaction(journal Filename) (*Transaction, error) {
+	return std.OpenTransaction(journal)
+}
+
+// OpenTransaction performs the package function OpenTransaction with the settings of fsys.
+func (fsys *FS) OpenTransaction(journal Filename) (*Transaction, error) {
+	// Resolve journal against the root directory of fsys
+	journal = rooted(fsys, journal)
+	// Return an error in case journal contains a blocked directory or filename
+	if e := fsys.CheckFile(journal); e != nil {
+		return nil, tserr.Check(&tserr.CheckArgs{F: string(journal), Err: e})
+	}
+	// Retrieve the absolute path of journal
+	p, err := filepath.Abs(string(journal))
+	if err != nil {
+		return nil, tserr.Op(&tserr.OpArgs{Op: "Abs", Fn: string(journal), Err: err})
+	}
+	tx := &Transaction{fsys: fsys, journal: Filename(p)}
+	// Roll an interrupted Transaction forward or back
+	if e := tx.recover(); e != nil {
+		return nil, e
+	}
+	// Return the Transaction
+	return tx, nil
+}
+
+// WriteStr stages writing string s to file fn as done by the package function WriteStr. The string is appended
+// to the staged contents of fn. If fn does not exist, it is created on Commit. It returns an error, if any.
+func (tx *Transaction) WriteStr(fn Filename, s string) error {
+	// Open the staged contents of fn for appending
+	f, err := tx.stage(fn, true)
+	if err != nil {
+		return err
+	}
+	// Write s to the staged contents
+	if _, e := io.WriteString(f, s); e != nil {
+		// On error, close the temporary file and return error
+		f.Close()
+		return tserr.Op(&tserr.OpArgs{Op: "write string to", Fn: f.Name(), Err: e})
+	}
+	// Sync and close the temporary file
+	return closeStaged(f)
+}
+
+// WriteSingleStr stages writing a single string s to file fn as done by the package function WriteSingleStr.
+// The staged contents of fn are replaced by s. If fn does not exist, it is created on Commit. It returns an
+// error, if any.
+func (tx *Transaction) WriteSingleStr(fn Filename, s string) error {
+	// Open the staged contents of fn truncated
+	f, err := tx.stage(fn, false)
+	if err != nil {
+		return err
+	}
+	// Write s to the staged contents
+	if _, e := io.WriteString(f, s); e != nil {
+		// On error, close the temporary file and return error
+		f.Close()
+		return tserr.Op(&tserr.OpArgs{Op: "write string to", Fn: f.Name(), Err: e})
+	}
+	// Sync and close the temporary file
+	return closeStaged(f)
+}
+
+// AppendFile stages appending fileI to fileA as done by the package function AppendFile. The staged contents
+// of fileI are appended to the staged contents of fileA. If fileA does not exist, it is created on Commit. It
+// returns an error, if fileI does not exist or is staged for removal, or if any other error occurs.
+func (tx *Transaction) AppendFile(a *Append) error {
+	// Return error if pointer a is nil.
+	if a == nil {
+		return tserr.NilPtr()
+	}
+	// Return an error, if the Transaction is closed
+	if tx.closed {
+		return tserr.Forbidden("use of closed transaction " + string(tx.journal))
+	}
+	// Resolve and check fileI
+	fi, err := tx.target(a.FileI)
+	if err != nil {
+		return err
+	}
+	// Retrieve the staged contents of fileI
+	src, ok, err := tx.source(fi)
+	if err != nil {
+		return err
+	}
+	// Return an error if fileI does not exist
+	if !ok {
+		return errNotExist(fi)
+	}
+	// Open the staged contents of fileI read-only before fileA is staged, so fileI can be fileA
+	in, err := tx.fsys.backend().OpenFile(src, os.O_RDONLY, 0)
+	if err != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "open", Fn: src, Err: err})
+	}
+	defer in.Close()
+	// Retrieve the size of the contents of fileI
+	info, err := in.Stat()
+	if err != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "Stat", Fn: src, Err: err})
+	}
+	// Open the staged contents of fileA for appending
+	f, err := tx.stage(a.FileA, true)
+	if err != nil {
+		return err
+	}
+	// Stream the contents of fileI to the staged contents of fileA
+	if _, e := io.CopyBuffer(struct{ io.Writer }{f}, io.LimitReader(in, info.Size()), make([]byte, copyBufSize)); e != nil {
+		// On error, close the temporary file and return error
+		f.Close()
+		return tserr.Op(&tserr.OpArgs{Op: "append file " + fi + " to", Fn: f.Name(), Err: e})
+	}
+	// Sync and close the temporary file
+	return closeStaged(f)
+}
+
+// RemoveFile stages removing file fn as done by the package function RemoveFile. It returns an error, if fn
+// does not exist or is already staged for removal, or if any other error occurs.
+func (tx *Transaction) RemoveFile(fn Filename) error {
+	// Return an error, if the Transaction is closed
+	if tx.closed {
+		return tserr.Forbidden("use of closed transaction " + string(tx.journal))
+	}
+	// Resolve and check fn
+	p, err := tx.target(fn)
+	if err != nil {
+		return err
+	}
+	// Retrieve whether fn exists in the staged contents
+	_, ok, err := tx.source(p)
+	if err != nil {
+		return err
+	}
+	// Return an error if fn does not exist
+	if !ok {
+		return errNotExist(p)
+	}
+	// Stage the removal of fn and record it in the journal
+	prev := slices.Clone(tx.entries)
+	var tmp string
+	if i := tx.find(p); i >= 0 {
+		tmp, tx.entries[i].Temp = tx.entries[i].Temp, ""
+	} else {
+		tx.entries = append(tx.entries, txEntry{Target: p})
+	}
+	if e := tx.writeJournal(false); e != nil {
+		// On error, restore the staged changes and return error
+		tx.entries = prev
+		return e
+	}
+	// Remove the temporary file with the staged contents of fn, if any
+	if tmp != "" {
+		tx.fsys.backend().Remove(tmp)
+	}
+	// No error occurred, return nil
+	return nil
+}
+
+// Commit applies all staged changes. First, the journal is marked as committed. Afterwards, the temporary files
+// are renamed to the files and the files staged for removal are removed. Finally, the directories are synced and
+// the journal is removed. If Commit fails after the journal is marked as committed, the next OpenTransaction with
+// the same journal rolls the Commit forward. The Transaction is closed afterwards. It returns an error, if any.
+func (tx *Transaction) Commit() error {
+	// Return an error, if the Transaction is closed
+	if tx.closed {
+		return tserr.Forbidden("use of closed transaction " + string(tx.journal))
+	}
+	tx.closed = true
+	// Return, if no changes are staged
+	if len(tx.entries) == 0 {
+		return nil
+	}
+	// Mark the journal as committed, which is the point of no return
+	if e := tx.writeJournal(true); e != nil {
+		// On error, roll back the staged changes
+		tx.discard()
+		return e
+	}
+	// Apply the staged changes
+	return tx.apply()
+}
+
+// Rollback discards all staged changes by removing the temporary files and the journal. The files remain
+// unchanged. The Transaction is closed afterwards. It returns an error, if any.
+func (tx *Transaction) Rollback() error {
+	// Return an error, if the Transaction is closed
+	if tx.closed {
+		return tserr.Forbidden("use of closed transaction " + string(tx.journal))
+	}
+	tx.closed = true
+	// Discard the staged changes
+	return tx.discard()
+}
+
+// target resolves fn against the root directory of the FS of tx, checks it with CheckFile and returns its absolute
+// path. It returns an error, if fn is a directory or if any other error occurs.
+func (tx *Transaction) target(fn Filename) (string, error) {
+	// Resolve fn against the root directory
+	fn = rooted(tx.fsys, fn)
+	// Return an error in case fn contains a blocked directory or filename
+	if e := tx.fsys.CheckFile(fn); e != nil {
+		return "", tserr.Check(&tserr.CheckArgs{F: string(fn), Err: e})
+	}
+	// Retrieve the absolute path of fn
+	p, err := filepath.Abs(string(fn))
+	if err != nil {
+		return "", tserr.Op(&tserr.OpArgs{Op: "Abs", Fn: string(fn), Err: err})
+	}
+	// Return an error, if fn is a directory
+	if fi, e := tx.fsys.backend().Stat(p); (e == nil) && fi.IsDir() {
+		return "", errNotRegular(p, "file")
+	}
+	// Return the absolute path of fn
+	return p, nil
+}
+
+// find returns the index of the staged change of the absolute path p or -1, if p is not staged.
+func (tx *Transaction) find(p string) int {
+	return slices.IndexFunc(tx.entries, func(e txEntry) bool { return e.Target == p })
+}
+
+// source returns the file holding the staged contents of the absolute path p. It is the temporary file, if p is
+// staged, or p otherwise. It returns false, if p is staged for removal or does not exist. It returns an error, if any.
+func (tx *Transaction) source(p string) (string, bool, error) {
+	// Return the temporary file, if p is staged
+	if i := tx.find(p); i >= 0 {
+		return tx.entries[i].Temp, tx.entries[i].Temp != "", nil
+	}
+	// Return p, if it exists
+	ok, err := tx.fsys.ExistsFile(Filename(p))
+	if err != nil {
+		return "", false, tserr.Op(&tserr.OpArgs{Op: "check if exists", Fn: p, Err: err})
+	}
+	return p, ok, nil
+}
+
+// stage returns the temporary file with the staged contents of fn opened for writing. If fn is not staged yet,
+// a temporary file is created in the directory of fn, recorded in the journal and, if keep is true, the current
+// contents of fn are copied to it. If keep is true, writes are appended to the staged contents. Otherwise, the
+// staged contents are truncated. The caller must close the returned file with closeStaged. It returns an error,
+// if any.
+func (tx *Transaction) stage(fn Filename, keep bool) (File, error) {
+	// Return an error, if the Transaction is closed
+	if tx.closed {
+		return nil, tserr.Forbidden("use of closed transaction " + string(tx.journal))
+	}
+	// Resolve and check fn
+	p, err := tx.target(fn)
+	if err != nil {
+		return nil, err
+	}
+	// Reopen the temporary file, if fn is staged with contents
+	i := tx.find(p)
+	if (i >= 0) && (tx.entries[i].Temp != "") {
+		flag := os.O_WRONLY | os.O_APPEND
+		if !keep {
+			flag = os.O_WRONLY | os.O_TRUNC
+		}
+		f, e := tx.fsys.backend().OpenFile(tx.entries[i].Temp, flag, 0)
+		if e != nil {
+			return nil, tserr.Op(&tserr.OpArgs{Op: "open", Fn: tx.entries[i].Temp, Err: e})
+		}
+		return f, nil
+	}
+	// Retrieve the current contents of fn, if any
+	src, ok, err := tx.source(p)
+	if err != nil {
+		return nil, err
+	}
+	// Use default permission bits, if fn does not exist. Otherwise, retain the permission bits of fn.
+	perm := tx.fsys.fileMode()
+	if fi, e := tx.fsys.backend().Stat(p); e == nil {
+		perm = fi.Mode().Perm()
+	}
+	// Create the temporary file in the directory of fn
+	dn := filepath.Dir(p)
+	f, err := tx.fsys.backend().CreateTemp(dn, tmpPrefix+filepath.Base(p)+".*"+tmpSuffix)
+	if err != nil {
+		return nil, tserr.Op(&tserr.OpArgs{Op: "create temporary file in", Fn: dn, Err: err})
+	}
+	tmp := f.Name()
+	// Return an error in case the temporary file contains a blocked directory or filename
+	if e := tx.fsys.CheckFile(Filename(tmp)); e != nil {
+		f.Close()
+		tx.fsys.backend().Remove(tmp)
+		return nil, tserr.Check(&tserr.CheckArgs{F: tmp, Err: e})
+	}
+	// Record the temporary file in the journal
+	prev := slices.Clone(tx.entries)
+	if i >= 0 {
+		tx.entries[i].Temp = tmp
+	} else {
+		tx.entries = append(tx.entries, txEntry{Target: p, Temp: tmp})
+	}
+	if e := tx.writeJournal(false); e != nil {
+		// On error, restore the staged changes, remove the temporary file and return error
+		tx.entries = prev
+		f.Close()
+		tx.fsys.backend().Remove(tmp)
+		return nil, e
+	}
+	// Set the permission bits of the temporary file
+	if e := f.Chmod(perm); e != nil {
+		f.Close()
+		return nil, tserr.Op(&tserr.OpArgs{Op: "Chmod", Fn: tmp, Err: e})
+	}
+	// Copy the current contents of fn, if kept. Files staged for removal have no contents.
+	if keep && ok {
+		if e := copyContents(tx.fsys, f, src); e != nil {
+			f.Close()
+			return nil, e
+		}
+	}
+	// Return the temporary file
+	return f, nil
+}
+
+// copyContents streams the contents of file src to the open file f with a buffer of copyBufSize.
+// It returns an error, if any.
+func copyContents(fsys *FS, f File, src string) error {
+	// Open src read-only
+	in, err := fsys.backend().OpenFile(src, os.O_RDONLY, 0)
+	if err != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "open", Fn: src, Err: err})
+	}
+	defer in.Close()
+	// Stream the contents of src to f
+	if _, e := io.CopyBuffer(struct{ io.Writer }{f}, in, make([]byte, copyBufSize)); e != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "copy " + src + " to", Fn: f.Name(), Err: e})
+	}
+	// No error occurred, return nil
+	return nil
+}
+
+// closeStaged syncs the temporary file f to disk and closes it. It always closes f and returns an error, if any.
+func closeStaged(f File) error {
+	// Sync f to disk
+	if e := f.Sync(); e != nil {
+		f.Close()
+		return tserr.Op(&tserr.OpArgs{Op: "Sync", Fn: f.Name(), Err: e})
+	}
+	// Close f
+	if e := f.Close(); e != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "Close", Fn: f.Name(), Err: e})
+	}
+	// No error occurred, return nil
+	return nil
+}
+
+// writeJournal replaces the journal of tx atomically with the staged changes. If committed is true, the journal
+// is marked as committed. It returns an error, if any.
+func (tx *Transaction) writeJournal(committed bool) error {
+	// Encode the journal
+	b, err := json.Marshal(&txJournal{Committed: committed, Entries: tx.entries})
+	if err != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "encode journal", Fn: string(tx.journal), Err: err})
+	}
+	// Write the journal atomically
+	if e := tx.fsys.WriteAtomic(tx.journal, b); e != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "write journal", Fn: string(tx.journal), Err: e})
+	}
+	// No error occurred, return nil
+	return nil
+}
+
+// recover reads the journal of tx, if it exists, and rolls a committed Transaction forward or an uncommitted
+// Transaction back. It returns an error, if any.
+func (tx *Transaction) recover() error {
+	// Read the journal
+	b, err := tx.fsys.backend().ReadFile(string(tx.journal))
+	// Return, if no journal exists
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	// Return an error, if the journal cannot be read
+	if err != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "read journal", Fn: string(tx.journal), Err: err})
+	}
+	// Decode the journal
+	var j txJournal
+	if e := json.Unmarshal(b, &j); e != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "decode journal", Fn: string(tx.journal), Err: e})
+	}
+	tx.entries = j.Entries
+	// Return an error in case an entry contains a blocked directory or filename
+	for _, en := range tx.entries {
+		for _, fn := range []string{en.Target, en.Temp} {
+			if fn == "" {
+				continue
+			}
+			if e := tx.fsys.CheckFile(Filename(fn)); e != nil {
+				return tserr.Check(&tserr.CheckArgs{F: fn, Err: e})
+			}
+		}
+	}
+	// Roll the committed Transaction forward
+	if j.Committed {
+		return tx.apply()
+	}
+	// Roll the uncommitted Transaction back
+	return tx.discard()
+}
+
+// apply renames the temporary files to their targets and removes the targets staged for removal. Temporary files
+// and targets, which do not exist anymore, are skipped, since they were applied by an interrupted Commit. Afterwards,
+// the directories of the targets are synced and the journal is removed. It returns an error, if any.
+func (tx *Transaction) apply() error {
+	// Apply the staged changes in order
+	for _, e := range tx.entries {
+		var err error
+		if e.Temp != "" {
+			// Replace the target with the temporary file
+			err = tx.fsys.backend().Rename(e.Temp, e.Target)
+		} else {
+			// Remove the target
+			err = tx.fsys.backend().Remove(e.Target)
+		}
+		// Return an error, if the change is not applied by an interrupted Commit
+		if (err != nil) && !errors.Is(err, fs.ErrNotExist) {
+			return tserr.Op(&tserr.OpArgs{Op: "commit", Fn: e.Target, Err: err})
+		}
+	}
+	// Sync the directories of the targets
+	for _, d := range tx.dirs() {
+		if e := tx.fsys.backend().SyncDir(d); e != nil {
+			return tserr.Op(&tserr.OpArgs{Op: "sync directory", Fn: d, Err: e})
+		}
+	}
+	// Remove the journal
+	return tx.finish()
+}
+
+// discard removes the temporary files and the journal. Temporary files, which do not exist, are skipped.
+// It returns an error, if any.
+func (tx *Transaction) discard() error {
+	// Remove the temporary files
+	for _, e := range tx.entries {
+		if e.Temp == "" {
+			continue
+		}
+		if err := tx.fsys.backend().Remove(e.Temp); (err != nil) && !errors.Is(err, fs.ErrNotExist) {
+			return tserr.Op(&tserr.OpArgs{Op: "Remove", Fn: e.Temp, Err: err})
+		}
+	}
+	// Remove the journal
+	return tx.finish()
+}
+
+// finish removes the journal, syncs its directory and clears the staged changes. It returns an error, if any.
+func (tx *Transaction) finish() error {
+	// Remove the journal, if it exists
+	if e := tx.fsys.backend().Remove(string(tx.journal)); (e != nil) && !errors.Is(e, fs.ErrNotExist) {
+		return tserr.Op(&tserr.OpArgs{Op: "Remove", Fn: string(tx.journal), Err: e})
+	}
+	// Sync the directory of the journal to persist the removal
+	d := filepath.Dir(string(tx.journal))
+	if e := tx.fsys.backend().SyncDir(d); e != nil {
+		return tserr.Op(&tserr.OpArgs{Op: "sync directory", Fn: d, Err: e})
+	}
+	// Clear the staged changes
+	tx.entries = nil
+	return nil
+}
+
+// dirs returns the distinct directories of the targets of the staged changes.
+func (tx *Transaction) dirs() []string {
+	var ds []string
+	for _, e := range tx.entries {
+		if d := filepath.Dir(e.Target); !slices.Contains(ds, d) {
+			ds = append(ds, d)
+		}
+	}
+	return ds
+}
@@ -0,0 +1,218 @@
+// Copyright (c) 2023 thorstenrie.
+// All Rights Reserved. Use is governed with GNU Affero General Public Licence v3.0
+// that can be found in the LICENSE file.
+package tsfio_test
+
+// Import standard library packages as well as tserr and tsfio
+import (
+	"fmt"           // fmt
+	"os"            // os
+	"path/filepath" // filepath
+	"testing"       // testing
+
+	"github.com/thorstenrie/tserr" // tserr
+	"github.com/thorstenrie/tsfio" // tsfio
+)
+
+// txTree holds the files of the transaction tests
+var txTree = map[string]string{"config": "a=1", "index": "1", "old": "old"}
+
+// txJournal is the filename of the journal of the transaction tests
+const txJournal tsfio.Filename = "journal"
+
+// txStage opens a Transaction of fsys with the journal in Directory d and stages changes to the files of txTree.
+// It replaces config, appends to index, appends index to manifest, which does not exist, and removes old. The test
+// fails if any function returns an error.
+func txStage(t *testing.T, fsys *tsfio.FS, d tsfio.Directory) *tsfio.Transaction {
+	// Panic if t is nil
+	if t == nil {
+		panic("nil pointer")
+	}
+	// Retrieve the path of a file in d
+	fn := func(n string) tsfio.Filename { return tsfio.Filename(filepath.Join(string(d), n)) }
+	// Open the Transaction
+	tx, err := fsys.OpenTransaction(fn(string(txJournal)))
+	if err != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "OpenTransaction", Fn: string(d), Err: err}))
+	}
+	// Stage the changes
+	if e := tx.WriteSingleStr(fn("config"), "a=2"); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "WriteSingleStr", Fn: string(fn("config")), Err: e}))
+	}
+	if e := tx.WriteStr(fn("index"), "2"); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "WriteStr", Fn: string(fn("index")), Err: e}))
+	}
+	if e := tx.AppendFile(&tsfio.Append{FileA: fn("manifest"), FileI: fn("index")}); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "AppendFile", Fn: string(fn("manifest")), Err: e}))
+	}
+	if e := tx.RemoveFile(fn("old")); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "RemoveFile", Fn: string(fn("old")), Err: e}))
+	}
+	// Return the Transaction
+	return tx
+}
+
+// TestTransactionCommit tests a Transaction to leave the files unchanged while changes are staged and to apply
+// all changes with Commit. The test fails if any function returns an error, if the contents differ or if temporary
+// files or the journal remain.
+func TestTransactionCommit(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write txTree to d
+	writeTree(t, d, txTree)
+	// Stage the changes
+	tx := txStage(t, &tsfio.FS{}, d)
+	// The test fails if the files changed
+	checkTree(t, d, txTree)
+	// The test fails if Commit returns an error
+	if e := tx.Commit(); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "Commit", Fn: string(d), Err: e}))
+	}
+	// The test fails if the files do not hold the changes
+	checkTree(t, d, map[string]string{"config": "a=2", "index": "12", "manifest": "12"})
+	// The test fails if old, temporary files or the journal remain
+	testEntries(t, d, 3)
+	// The test fails if the Transaction can be used after Commit
+	if e := tx.WriteStr(tsfio.Filename(filepath.Join(string(d), "config")), testcase); e == nil {
+		t.Error(tserr.NilFailed("WriteStr"))
+	}
+	if e := tx.Commit(); e == nil {
+		t.Error(tserr.NilFailed("Commit"))
+	}
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestTransactionRollback tests Rollback to discard all staged changes. The test fails if Rollback returns an
+// error, if the files changed or if temporary files or the journal remain.
+func TestTransactionRollback(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write txTree to d
+	writeTree(t, d, txTree)
+	// Stage the changes and roll them back
+	if e := txStage(t, &tsfio.FS{}, d).Rollback(); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "Rollback", Fn: string(d), Err: e}))
+	}
+	// The test fails if the files changed, or temporary files or the journal remain
+	checkTree(t, d, txTree)
+	testEntries(t, d, len(txTree))
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestTransactionRecoverBack tests OpenTransaction to roll back a Transaction interrupted before Commit.
+// The test fails if OpenTransaction returns an error, if the files changed or if temporary files or the
+// journal remain.
+func TestTransactionRecoverBack(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write txTree to d
+	writeTree(t, d, txTree)
+	// Stage the changes and abandon the Transaction
+	txStage(t, &tsfio.FS{}, d)
+	// The test fails if OpenTransaction returns an error
+	if _, e := tsfio.OpenTransaction(tsfio.Filename(filepath.Join(string(d), string(txJournal)))); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "OpenTransaction", Fn: string(d), Err: e}))
+	}
+	// The test fails if the files changed, or temporary files or the journal remain
+	checkTree(t, d, txTree)
+	testEntries(t, d, len(txTree))
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestTransactionRecoverForward tests OpenTransaction to roll forward a Commit interrupted after the first change
+// was applied. The test fails if OpenTransaction returns an error, if the files do not hold the changes or if
+// temporary files or the journal remain.
+func TestTransactionRecoverForward(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write txTree and a temporary file to d. Temporary file 1 was already renamed to config.
+	writeTree(t, d, map[string]string{"config": "a=2", "index": "1", "old": "old", "2": "12"})
+	p := func(n string) string { return filepath.Join(string(d), n) }
+	// Write the journal of the interrupted Commit
+	j := fmt.Sprintf(`{"committed":true,"entries":[{"target":%q,"temp":%q},{"target":%q,"temp":%q},{"target":%q}]}`,
+		p("config"), p("1"), p("index"), p("2"), p("old"))
+	if e := os.WriteFile(p(string(txJournal)), []byte(j), 0644); e != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "WriteFile", Fn: p(string(txJournal)), Err: e}))
+	}
+	// The test fails if OpenTransaction returns an error
+	if _, e := tsfio.OpenTransaction(tsfio.Filename(p(string(txJournal)))); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "OpenTransaction", Fn: string(d), Err: e}))
+	}
+	// The test fails if the files do not hold the changes, or old, temporary files or the journal remain
+	checkTree(t, d, map[string]string{"config": "a=2", "index": "12"})
+	testEntries(t, d, 2)
+	// Remove d
+	rmAll(t, d)
+}
+
+// TestTransactionMem tests a Transaction of an FS with a MemBackend to apply all changes with Commit.
+// The test fails if any function returns an error, if the contents differ or if a file is written to the file system.
+func TestTransactionMem(t *testing.T) {
+	// Create FS with MemBackend and Directory d
+	fsys, _, d := memFS(t)
+	// Write txTree to d
+	memTree(t, fsys, d, txTree)
+	// Stage the changes, append index to itself and commit
+	tx := txStage(t, fsys, d)
+	fn := tsfio.Filename(filepath.Join(string(d), "index"))
+	if e := tx.AppendFile(&tsfio.Append{FileA: fn, FileI: fn}); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "AppendFile", Fn: string(fn), Err: e}))
+	}
+	if e := tx.Commit(); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "Commit", Fn: string(d), Err: e}))
+	}
+	// The test fails if the files do not hold the changes
+	memCheckTree(t, fsys, d, map[string]string{"config": "a=2", "index": "1212", "manifest": "12"})
+	// The test fails if old or the journal remain
+	for _, n := range []string{"old", string(txJournal)} {
+		if ok, e := fsys.ExistsFile(tsfio.Filename(filepath.Join(string(d), n))); ok || (e != nil) {
+			t.Error(tserr.Return(&tserr.ReturnArgs{Op: "ExistsFile " + n, Actual: fmt.Sprint(ok, e), Want: "false <nil>"}))
+		}
+	}
+	// The test fails if d exists in the file system
+	memNotOnDisk(t, d)
+}
+
+// TestTransactionErr tests a Transaction to return an error for nil, for appending and removing a file, which
+// does not exist, and for removing a file staged for removal. The test fails if any of them returns nil.
+func TestTransactionErr(t *testing.T) {
+	// Create temporary directory d
+	d := tmpDir(t)
+	// Write txTree to d
+	writeTree(t, d, txTree)
+	fn := func(n string) tsfio.Filename { return tsfio.Filename(filepath.Join(string(d), n)) }
+	// Open the Transaction
+	tx, err := tsfio.OpenTransaction(fn(string(txJournal)))
+	if err != nil {
+		t.Fatal(tserr.Op(&tserr.OpArgs{Op: "OpenTransaction", Fn: string(d), Err: err}))
+	}
+	// The test fails if AppendFile returns nil for nil or a file, which does not exist
+	if e := tx.AppendFile(nil); e == nil {
+		t.Error(tserr.NilFailed("AppendFile"))
+	}
+	if e := tx.AppendFile(&tsfio.Append{FileA: fn("config"), FileI: fn("missing")}); e == nil {
+		t.Error(tserr.NilFailed("AppendFile"))
+	}
+	// The test fails if RemoveFile returns nil for a file, which does not exist or is staged for removal
+	if e := tx.RemoveFile(fn("missing")); e == nil {
+		t.Error(tserr.NilFailed("RemoveFile"))
+	}
+	if e := tx.RemoveFile(fn("old")); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "RemoveFile", Fn: string(fn("old")), Err: e}))
+	}
+	if e := tx.RemoveFile(fn("old")); e == nil {
+		t.Error(tserr.NilFailed("RemoveFile"))
+	}
+	// The test fails if Rollback returns an error
+	if e := tx.Rollback(); e != nil {
+		t.Error(tserr.Op(&tserr.OpArgs{Op: "Rollback", Fn: string(d), Err: e}))
+	}
+	// The test fails if the files changed
+	checkTree(t, d, txTree)
+	testEntries(t, d, len(txTree))
+	// Remove d
+	rmAll(t, d)
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package tsfio
+
+// Import standard library packages errors and syscall
+import (
+	"errors"  // errors
+	"syscall" // syscall
+)
+
+// isXDev returns true if err reports a rename across different devices or mount points.
+func isXDev(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
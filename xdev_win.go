@@ -0,0 +1,17 @@
+//go:build windows
+
+package tsfio
+
+// Import standard library packages errors and syscall
+import (
+	"errors"  // errors
+	"syscall" // syscall
+)
+
+// errNotSameDevice holds the Windows error ERROR_NOT_SAME_DEVICE returned for moves across volumes
+const errNotSameDevice syscall.Errno = 17
+
+// isXDev returns true if err reports a rename across different volumes.
+func isXDev(err error) bool {
+	return errors.Is(err, errNotSameDevice)
+}